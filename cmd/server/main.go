@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/zaynkorai/enlabs/internal/app/server"
 	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
 	"github.com/zaynkorai/enlabs/internal/platform/persistence"
+	"github.com/zaynkorai/enlabs/internal/platform/rates"
 	"github.com/zaynkorai/enlabs/internal/transport/http"
 	"github.com/zaynkorai/enlabs/pkg/config"
 	"github.com/zaynkorai/enlabs/pkg/database"
@@ -15,6 +18,9 @@ import (
 // @version 1.0
 // @description API for processing incoming requests from 3rd-party providers and managing user balances.
 // @host localhost:8089
+// @securityDefinitions.apikey AdminKey
+// @in header
+// @name X-Admin-Key
 
 func main() {
 	cfg, err := config.LoadConfig()
@@ -39,10 +45,42 @@ func main() {
 
 	userRepo := persistence.NewUserRepository(db)
 	transactionRepo := persistence.NewTransactionRepository(db)
+	ledgerRepo := persistence.NewLedgerRepository(db)
+	holdRepo := persistence.NewHoldRepository(db)
+	limitRepo := persistence.NewLimitRepository(db)
+	transferRepo := persistence.NewTransferRepository(db)
+	roundRepo := persistence.NewRoundRepository(db)
+	sourceRepo := persistence.NewSourceRepository(db)
+	providerRepo := persistence.NewProviderRepository(db)
 
-	transactionService := services.NewTransactionService(userRepo, transactionRepo)
+	serviceOptions := []services.Option{
+		services.WithLockingStrategy(services.LockingStrategy(cfg.BalanceLockingStrategy), cfg.OptimisticMaxRetries),
+		services.WithDefaultCurrency(cfg.DefaultCurrency),
+		services.WithDebitOrder(user.DebitOrder(cfg.BonusDebitOrder)),
+		services.WithHolds(holdRepo, cfg.HoldDefaultTTL, cfg.HoldMaxTTL),
+		services.WithLimits(limitRepo, cfg.LossLimitCooldown),
+		services.WithTransfers(transferRepo),
+		services.WithRounds(roundRepo, cfg.StrictRounds),
+		services.WithSources(sourceRepo),
+		services.WithProviders(providerRepo, cfg.ProviderAuthWindow),
+	}
+	if cfg.ExchangeRatesFile != "" {
+		rateProvider, err := rates.LoadStaticProvider(cfg.ExchangeRatesFile)
+		if err != nil {
+			log.Fatalf("Failed to load exchange rates: %v", err)
+		}
+		serviceOptions = append(serviceOptions, services.WithRateProvider(rateProvider))
+	}
+	if cfg.AutoProvisionUsers {
+		serviceOptions = append(serviceOptions, services.WithAutoProvisioning())
+	}
+
+	transactionService := services.NewTransactionService(userRepo, transactionRepo, serviceOptions...)
+	go transactionService.RunHoldSweeper(context.Background(), cfg.HoldSweepInterval)
+
+	ledgerService := services.NewLedgerService(ledgerRepo)
 
-	httpHandler := http.NewHandler(transactionService)
+	httpHandler := http.NewHandler(transactionService, ledgerService)
 
 	srv := server.NewServer(cfg, httpHandler)
 	if err := srv.Run(); err != nil {
@@ -37,62 +37,99 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/admin/providers": {
             "get": {
-                "description": "Get the health status of the application",
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Returns every provider with its settings; the keys of a provider are returned by GET /admin/providers/{name}.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Default"
+                    "Admin"
                 ],
-                "summary": "Get health status",
-                "operationId": "get-health-status",
+                "summary": "Lists the providers",
                 "responses": {
                     "200": {
-                        "description": "Successful response with health status code and message",
+                        "description": "Providers ordered by name",
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/http.ProvidersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Provider authentication is not available",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/user/{userId}/balance": {
+        "/admin/providers/{name}": {
             "get": {
-                "description": "Retrieves the current balance for a specified user.",
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Returns the settings of a provider and the IDs of its active signing keys.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Gets current user balance",
+                "summary": "Gets a provider",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "userId",
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Current user balance",
+                        "description": "Provider settings and keys",
                         "schema": {
-                            "$ref": "#/definitions/http.BalanceResponse"
+                            "$ref": "#/definitions/http.ProviderResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request: Invalid userId",
+                        "description": "Bad Request: Provider authentication is not available",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "Not Found: User does not exist",
+                        "description": "Not Found: Provider does not exist",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -106,11 +143,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user/{userId}/transaction": {
-            "post": {
-                "description": "Processes 'win' or 'lose' transactions and updates the user's balance, ensuring idempotency and non-negative balance.",
+            },
+            "put": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Creates the provider if it does not exist yet, or replaces its settings. A provider may only send requests with the Source-Types it is bound to; its name is also the only provider it may address players of on the /provider/{provider}/player routes.\nA new provider has no keys yet; create one with POST /admin/providers/{name}/keys.",
                 "consumes": [
                     "application/json"
                 ],
@@ -118,63 +158,105 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Updates user balance based on a transaction",
+                "summary": "Onboards or reconfigures a provider",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "userId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "enum": [
-                            "game",
-                            "server",
-                            "payment"
-                        ],
                         "type": "string",
-                        "description": "Type of the transaction source (game, server, payment)",
-                        "name": "Source-Type",
-                        "in": "header",
+                        "description": "Provider name",
+                        "name": "name",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Transaction details",
-                        "name": "transaction",
+                        "description": "Provider settings",
+                        "name": "provider",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/http.TransactionRequest"
+                            "$ref": "#/definitions/http.ProviderRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transaction processed successfully",
+                        "description": "Saved provider settings and keys",
+                        "schema": {
+                            "$ref": "#/definitions/http.ProviderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid name or Source-Type, or provider authentication is not available",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/providers/{name}/keys": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Creates a key and returns its secret, which is not shown again. The provider's previous key stays valid so that the provider can switch to the new key without rejected requests; any older key is retired.\nRevoke the previous key once the provider signs with the new one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Creates a signing key for a provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "New key with its secret",
+                        "schema": {
+                            "$ref": "#/definitions/http.ProviderKeyCreatedResponse"
+                        }
+                    },
                     "400": {
-                        "description": "Bad Request: Invalid input or insufficient balance",
+                        "description": "Bad Request: Provider authentication is not available",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Not Found: User does not exist",
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "409": {
-                        "description": "Conflict: Transaction with this ID already processed",
+                    "404": {
+                        "description": "Not Found: Provider does not exist",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -189,44 +271,3799 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "http.BalanceResponse": {
-            "description": "Current user balance information.",
-            "type": "object",
-            "properties": {
-                "balance": {
-                    "type": "string"
-                },
-                "userId": {
-                    "type": "integer"
+        },
+        "/admin/providers/{name}/keys/{keyId}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Deletes the key; requests signed with it are rejected from then on.",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Revokes a signing key of a provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Key revoked"
+                    },
+                    "400": {
+                        "description": "Bad Request: Provider authentication is not available",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Provider has no such key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
                 }
             }
         },
-        "http.TransactionRequest": {
-            "description": "Details for a new transaction to update user balance.",
-            "type": "object",
-            "required": [
-                "amount",
-                "state",
-                "transactionId"
-            ],
-            "properties": {
-                "amount": {
-                    "type": "string"
-                },
-                "state": {
-                    "type": "string",
-                    "enum": [
-                        "win",
-                        "lose"
-                    ]
-                },
-                "transactionId": {
-                    "type": "string"
+        "/admin/source-types": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Returns every Source-Type providers may send transactions with, enabled or not, with its settings.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Lists the Source-Types",
+                "responses": {
+                    "200": {
+                        "description": "Source-Types ordered by name",
+                        "schema": {
+                            "$ref": "#/definitions/http.SourceTypesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
                 }
             }
+        },
+        "/admin/source-types/{name}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Returns the settings of one Source-Type.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Gets a Source-Type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source-Type name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Source-Type settings",
+                        "schema": {
+                            "$ref": "#/definitions/http.SourceTypeResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Source-Type does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Creates the Source-Type if it does not exist yet, or replaces its settings. The settings apply to the transactions and holds sent from then on; transactions already processed are not affected.\nDisabled sources, transaction types not in states and amounts above maxAmount, converted into maxAmountCurrency at the current rate, are rejected with 400. Without checkBalance, debits of the source are booked even if they overdraw the balance, e.g. for chargebacks.\nNames are lower case letters, digits, '_' and '-', starting with a letter; 'transfer' is reserved. Source-Types cannot be deleted, only disabled.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Onboards or reconfigures a Source-Type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source-Type name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Source-Type settings",
+                        "name": "source",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.SourceTypeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved Source-Type settings",
+                        "schema": {
+                            "$ref": "#/definitions/http.SourceTypeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid name, state, maxAmount or maxAmountCurrency",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/user/{userId}/credit-limit": {
+            "put": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Allows the wallet's balance to be drawn down to minus the credit limit by losses, holds and reversals. The default limit of 0 keeps balances from going negative.\nLowering the limit below what is already drawn blocks further debits but leaves the balance unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Sets the credit limit of a user's wallet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Credit limit",
+                        "name": "limit",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.SetCreditLimitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated wallet",
+                        "schema": {
+                            "$ref": "#/definitions/http.WalletResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId, currency or limit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/user/{userId}/status": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Returns the current account status together with the audit trail of status changes, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Gets the account status of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status with the audit trail",
+                        "schema": {
+                            "$ref": "#/definitions/http.UserStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Moves the account through its status machine: active may become frozen, self_excluded or closed; frozen may become active or closed; a self-exclusion may only be extended or the account closed until it ends, after which the account is active again; closed is final.\nFrozen and closed accounts reject all transactions, self-excluded accounts reject debits. Every change is recorded with its reason in the audit trail.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Changes the account status of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New status and reason",
+                        "name": "status",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.SetUserStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated status with the audit trail",
+                        "schema": {
+                            "$ref": "#/definitions/http.UserStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId, status, end date or missing reason",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: The status machine does not allow the change",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Get the health status of the application",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Default"
+                ],
+                "summary": "Get health status",
+                "operationId": "get-health-status",
+                "responses": {
+                    "200": {
+                        "description": "Successful response with health status code and message",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/ledger/audit": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Lists journal entries that do not balance and wallets whose stored balance differs from the ledger.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ledger"
+                ],
+                "summary": "Audits the ledger",
+                "responses": {
+                    "200": {
+                        "description": "Audit result",
+                        "schema": {
+                            "$ref": "#/definitions/http.LedgerAuditResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/provider/{provider}/player/{externalId}/balance": {
+            "get": {
+                "description": "Retrieves the current balance of every wallet of the specified user. A user without transactions has no wallets.\nEach wallet's balance is broken down into real and bonus money; the available balance excludes funds reserved by active holds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets current user balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The provider's player ID",
+                        "name": "externalId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Current user balance",
+                        "schema": {
+                            "$ref": "#/definitions/http.BalanceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/provider/{provider}/player/{externalId}/transaction": {
+            "post": {
+                "description": "Processes a transaction and updates the balance of the user's wallet in the transaction currency, ensuring idempotency and a non-negative balance, or one within the wallet's credit limit.\nThe state is the transaction type. 'win', 'lose' and 'cancel' are accepted from every Source-Type; 'deposit' and 'withdrawal' (real balance) only from payment; 'bonus_grant' (bonus balance) and 'adjustment' only from server; 'fee' and 'refund' from server and payment. 'lose', 'withdrawal' and 'fee' debit the balance, the others credit it.\nThe wallet is opened on the first transaction in a currency; the amount may not have more decimal places than the currency uses.\nIf walletCurrency differs from currency, the amount is converted into the wallet currency at the current exchange rate and the original amount, currency and rate are recorded.\nUnless bucket names the real or bonus balance, a 'lose' is paid from the sub-balances in the configured debit order and a 'win' is split in proportion to them.\nA 'cancel' transaction rolls back the transaction given in referenceTransactionId (same Source-Type); its amount must match the original.\nIdempotency is keyed by (Source-Type, transactionId), so different sources may reuse the same transactionId.\nA roundId links the stakes ('lose') and payouts ('win') of one game round, see GET /rounds/{roundId}; in strict mode a 'win' for an unknown round is rejected with 400 and stakes or payouts for a closed round with 409.\nThe response carries the stored transaction and the balance after the operation; a request repeating an already processed transactionId with the same payload returns the original result with idempotentReplay set, while a differing payload is rejected with 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Updates user balance based on a transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The provider's player ID",
+                        "name": "externalId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered source of the transaction, e.g. game, server or payment",
+                        "name": "Source-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Transaction details",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.TransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction processed successfully, or replayed if the transactionId was already processed",
+                        "schema": {
+                            "$ref": "#/definitions/http.ProcessTransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input or insufficient balance",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: A loss or deposit limit would be exceeded (code LIMIT_EXCEEDED), or the account status does not allow the transaction (code ACCOUNT_RESTRICTED)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: Transaction ID reused with a different payload, balance modified concurrently, or round closed or belonging to another user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/provider/{provider}/player/{externalId}/transactions": {
+            "get": {
+                "description": "Returns the user's transactions newest first using cursor-based pagination over (processedAt, id), with optional filters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Lists user transaction history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The provider's player ID",
+                        "name": "externalId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "win",
+                            "lose",
+                            "cancel",
+                            "deposit",
+                            "withdrawal",
+                            "bonus_grant",
+                            "adjustment",
+                            "fee",
+                            "refund",
+                            "debit",
+                            "credit"
+                        ],
+                        "type": "string",
+                        "description": "Filter by transaction state",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by Source-Type, a registered source or transfer",
+                        "name": "sourceType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by ISO 4217 currency code",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Minimum amount (inclusive)",
+                        "name": "minAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum amount (inclusive)",
+                        "name": "maxAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the time window, RFC3339 (inclusive)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the time window, RFC3339 (exclusive)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as nextCursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of transactions",
+                        "schema": {
+                            "$ref": "#/definitions/http.TransactionHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId or filter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/provider/{provider}/player/{externalId}/transactions/{transactionId}": {
+            "get": {
+                "description": "Looks up a processed transaction by the transactionId supplied by the provider, scoped to the given user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets a user's transaction by its external ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The provider's player ID",
+                        "name": "externalId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "External transaction ID",
+                        "name": "transactionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source the transactionId belongs to; required when the ID is used by several sources",
+                        "name": "Source-Type",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored transaction",
+                        "schema": {
+                            "$ref": "#/definitions/http.TransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId or Source-Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Transaction does not exist for this user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: transactionId is ambiguous without Source-Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/rounds/{roundId}": {
+            "get": {
+                "description": "Looks up a round by the roundId the provider sent with its transactions, with its stakes, payouts and reversals.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rounds"
+                ],
+                "summary": "Gets a game round by its external ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "External round ID",
+                        "name": "roundId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source the roundId belongs to; required when the ID is used by several sources",
+                        "name": "Source-Type",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored round with its transactions",
+                        "schema": {
+                            "$ref": "#/definitions/http.RoundResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid Source-Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Round does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: roundId is ambiguous without Source-Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/batch": {
+            "post": {
+                "description": "Processes up to 500 transactions of any users in one request, each with the idempotency semantics of POST /user/{userId}/transaction; 'cancel' is not supported in batches.\nIn best_effort mode (the default) every item is processed on its own. In atomic mode either all items are applied in one database transaction or, if any item fails, none; the other items are then reported as aborted.\nEvery item gets a result in request order with a status: processed, replayed, insufficient_balance, not_found, rejected, conflict, limit_exceeded, account_restricted, aborted or failed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Processes a batch of transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Registered source of the transaction, e.g. game, server or payment",
+                        "name": "Source-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Batch mode and items",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.BatchTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Results of the items; in atomic mode all items were applied",
+                        "schema": {
+                            "$ref": "#/definitions/http.BatchTransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Malformed batch or item",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity: An item of an atomic batch failed and nothing was applied",
+                        "schema": {
+                            "$ref": "#/definitions/http.BatchTransactionResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{transactionId}": {
+            "get": {
+                "description": "Looks up a processed transaction by the transactionId supplied by the provider.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Gets a transaction by its external ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "External transaction ID",
+                        "name": "transactionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source the transactionId belongs to; required when the ID is used by several sources",
+                        "name": "Source-Type",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored transaction",
+                        "schema": {
+                            "$ref": "#/definitions/http.TransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid Source-Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Transaction does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: transactionId is ambiguous without Source-Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{transactionId}/reverse": {
+            "post": {
+                "description": "Creates a compensating 'cancel' entry linked to the original transaction and applies the inverse balance change atomically.\nThe reversal is idempotent on its own transactionId (derived from the original when omitted). A transaction can only be reversed once,\nand a reversal that would make the balance negative is refused unless force is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Reverses a processed transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "External ID of the transaction to reverse",
+                        "name": "transactionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source of the transaction to reverse",
+                        "name": "Source-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Reversal options",
+                        "name": "reversal",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/http.ReverseTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reversal processed successfully, or replayed",
+                        "schema": {
+                            "$ref": "#/definitions/http.ProcessTransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input, or reversal would make the balance negative",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: The account status does not allow the reversal (code ACCOUNT_RESTRICTED)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Transaction does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: Transaction already reversed, or reversal ID reused",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Moves real money from one user's wallet to another's, e.g. an affiliate payout or a tournament prize. The debit of the sender and the credit of the receiver are applied in one database transaction and recorded as two transactions of Source-Type 'transfer' linked to the transfer.\nIdempotency is keyed by transferId; repeating a transfer returns it with idempotentReplay set, while a differing payload is rejected with 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transfers"
+                ],
+                "summary": "Transfers funds between users",
+                "parameters": [
+                    {
+                        "description": "Transfer details",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.TransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfer executed, or replayed if the transferId was already processed",
+                        "schema": {
+                            "$ref": "#/definitions/http.TransferResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input or insufficient balance of the sender",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: The status of the sender or receiver does not allow the transfer (code ACCOUNT_RESTRICTED)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Sender or receiver does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: Transfer ID reused with a different payload",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/{transferId}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transfers"
+                ],
+                "summary": "Gets a transfer by its external ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "External transfer ID",
+                        "name": "transferId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored transfer with its legs",
+                        "schema": {
+                            "$ref": "#/definitions/http.TransferResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Transfer does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/balance": {
+            "get": {
+                "description": "Retrieves the current balance of every wallet of the specified user. A user without transactions has no wallets.\nEach wallet's balance is broken down into real and bonus money; the available balance excludes funds reserved by active holds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets current user balance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Current user balance",
+                        "schema": {
+                            "$ref": "#/definitions/http.BalanceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/balance/chain": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Walks the user's transactions in the order they were applied and reports, per wallet, every row whose balanceBefore does not equal the previous row's balanceAfter or that did not move the balance by its amount.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ledger"
+                ],
+                "summary": "Checks the user's balance timeline",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Chain check result",
+                        "schema": {
+                            "$ref": "#/definitions/http.TransactionChainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/balance/verify": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Compares the stored balance of each of the user's wallets with the sum of the postings on the wallet's ledger account.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ledger"
+                ],
+                "summary": "Verifies user balance against the ledger",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored and ledger balance per wallet",
+                        "schema": {
+                            "$ref": "#/definitions/http.UserBalanceVerificationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/holds": {
+            "post": {
+                "description": "Reserves an amount of the user's available balance, e.g. the stake of a bet, until the hold is captured, released or expires after its TTL.\nIdempotency is keyed by (Source-Type, holdId); placing the same hold again returns it with idempotentReplay set, while a differing payload is rejected with 409.\nThe held amount counts towards the user's loss limits from the moment the hold is placed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Holds"
+                ],
+                "summary": "Reserves funds of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered source of the transaction, e.g. game, server or payment",
+                        "name": "Source-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Hold details",
+                        "name": "hold",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.PlaceHoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Hold placed, or replayed if the holdId was already placed",
+                        "schema": {
+                            "$ref": "#/definitions/http.PlaceHoldResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input or insufficient available balance",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: A loss limit would be exceeded (code LIMIT_EXCEEDED), or the account status does not allow debits (code ACCOUNT_RESTRICTED)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: Hold ID reused with a different payload",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/holds/{holdId}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Holds"
+                ],
+                "summary": "Gets a hold of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "External hold ID",
+                        "name": "holdId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source that placed the hold",
+                        "name": "Source-Type",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored hold",
+                        "schema": {
+                            "$ref": "#/definitions/http.HoldResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId or Source-Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Hold does not exist for this user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/holds/{holdId}/capture": {
+            "post": {
+                "description": "Converts an active hold into a 'lose' transaction for the held amount and frees the reservation atomically.\nThe capture is idempotent on its transactionId (derived from the hold when omitted); repeating it returns the stored transaction with idempotentReplay set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Holds"
+                ],
+                "summary": "Settles a hold as a loss",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "External hold ID",
+                        "name": "holdId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source that placed the hold",
+                        "name": "Source-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Capture options",
+                        "name": "capture",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/http.CaptureHoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Hold captured, or replayed",
+                        "schema": {
+                            "$ref": "#/definitions/http.ProcessTransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: The account status does not allow debits (code ACCOUNT_RESTRICTED)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Hold does not exist for this user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: Hold is no longer active or has expired",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/holds/{holdId}/release": {
+            "post": {
+                "description": "Frees the reserved funds without moving money. Releasing a hold that was already released or has expired returns it unchanged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Holds"
+                ],
+                "summary": "Releases a hold",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "External hold ID",
+                        "name": "holdId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source that placed the hold",
+                        "name": "Source-Type",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Released hold",
+                        "schema": {
+                            "$ref": "#/definitions/http.HoldResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId or Source-Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Hold does not exist for this user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: Hold has already been captured",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/limits": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Returns the responsible-gaming loss and deposit limits in force, including raises and removals that are still waiting out their cooling-off period.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Limits"
+                ],
+                "summary": "Lists the loss and deposit limits of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Limits of the user",
+                        "schema": {
+                            "$ref": "#/definitions/http.LimitsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/limits/{period}": {
+            "put": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Caps the sum of the user's losses (kind loss, the default) or deposits (kind deposit) in one currency over a rolling window of 24 hours (daily), 7 days (weekly) or 30 days (monthly). Losses or deposits beyond the limit are rejected with 403 and code LIMIT_EXCEEDED.\nA new or lower limit applies immediately. A higher limit is scheduled and only applies after the cooling-off period; until then the current limit stays in force.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Limits"
+                ],
+                "summary": "Sets a loss or deposit limit of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "daily",
+                            "weekly",
+                            "monthly"
+                        ],
+                        "type": "string",
+                        "description": "Length of the rolling window",
+                        "name": "period",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Loss or deposit limit",
+                        "name": "limit",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.SetLimitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Limit in force, with any scheduled raise",
+                        "schema": {
+                            "$ref": "#/definitions/http.LimitResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId, kind, period, currency or amount",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Schedules the removal of the limit after the cooling-off period; the limit stays in force until then and is returned with pendingRemoval set. Answers 204 if the limit was removed immediately.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Limits"
+                ],
+                "summary": "Removes a loss or deposit limit of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "daily",
+                            "weekly",
+                            "monthly"
+                        ],
+                        "type": "string",
+                        "description": "Length of the rolling window",
+                        "name": "period",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "loss",
+                            "deposit"
+                        ],
+                        "type": "string",
+                        "description": "Kind of the limit; loss when omitted",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 code; the configured default currency when omitted",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Limit in force until its removal",
+                        "schema": {
+                            "$ref": "#/definitions/http.LimitResponse"
+                        }
+                    },
+                    "204": {
+                        "description": "Limit removed"
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId or kind",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User or limit does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/transaction": {
+            "post": {
+                "description": "Processes a transaction and updates the balance of the user's wallet in the transaction currency, ensuring idempotency and a non-negative balance, or one within the wallet's credit limit.\nThe state is the transaction type. 'win', 'lose' and 'cancel' are accepted from every Source-Type; 'deposit' and 'withdrawal' (real balance) only from payment; 'bonus_grant' (bonus balance) and 'adjustment' only from server; 'fee' and 'refund' from server and payment. 'lose', 'withdrawal' and 'fee' debit the balance, the others credit it.\nThe wallet is opened on the first transaction in a currency; the amount may not have more decimal places than the currency uses.\nIf walletCurrency differs from currency, the amount is converted into the wallet currency at the current exchange rate and the original amount, currency and rate are recorded.\nUnless bucket names the real or bonus balance, a 'lose' is paid from the sub-balances in the configured debit order and a 'win' is split in proportion to them.\nA 'cancel' transaction rolls back the transaction given in referenceTransactionId (same Source-Type); its amount must match the original.\nIdempotency is keyed by (Source-Type, transactionId), so different sources may reuse the same transactionId.\nA roundId links the stakes ('lose') and payouts ('win') of one game round, see GET /rounds/{roundId}; in strict mode a 'win' for an unknown round is rejected with 400 and stakes or payouts for a closed round with 409.\nThe response carries the stored transaction and the balance after the operation; a request repeating an already processed transactionId with the same payload returns the original result with idempotentReplay set, while a differing payload is rejected with 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Updates user balance based on a transaction",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered source of the transaction, e.g. game, server or payment",
+                        "name": "Source-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Transaction details",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.TransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction processed successfully, or replayed if the transactionId was already processed",
+                        "schema": {
+                            "$ref": "#/definitions/http.ProcessTransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input or insufficient balance",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: A loss or deposit limit would be exceeded (code LIMIT_EXCEEDED), or the account status does not allow the transaction (code ACCOUNT_RESTRICTED)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: Transaction ID reused with a different payload, balance modified concurrently, or round closed or belonging to another user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/transactions": {
+            "get": {
+                "description": "Returns the user's transactions newest first using cursor-based pagination over (processedAt, id), with optional filters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Lists user transaction history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "win",
+                            "lose",
+                            "cancel",
+                            "deposit",
+                            "withdrawal",
+                            "bonus_grant",
+                            "adjustment",
+                            "fee",
+                            "refund",
+                            "debit",
+                            "credit"
+                        ],
+                        "type": "string",
+                        "description": "Filter by transaction state",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by Source-Type, a registered source or transfer",
+                        "name": "sourceType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by ISO 4217 currency code",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Minimum amount (inclusive)",
+                        "name": "minAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum amount (inclusive)",
+                        "name": "maxAmount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the time window, RFC3339 (inclusive)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the time window, RFC3339 (exclusive)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as nextCursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of transactions",
+                        "schema": {
+                            "$ref": "#/definitions/http.TransactionHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId or filter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/transactions/{transactionId}": {
+            "get": {
+                "description": "Looks up a processed transaction by the transactionId supplied by the provider, scoped to the given user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets a user's transaction by its external ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "External transaction ID",
+                        "name": "transactionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source the transactionId belongs to; required when the ID is used by several sources",
+                        "name": "Source-Type",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored transaction",
+                        "schema": {
+                            "$ref": "#/definitions/http.TransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId or Source-Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: Transaction does not exist for this user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: transactionId is ambiguous without Source-Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Returns users ordered by ID using cursor-based pagination.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Lists users",
+                "parameters": [
+                    {
+                        "enum": [
+                            "active",
+                            "frozen",
+                            "self_excluded",
+                            "closed"
+                        ],
+                        "type": "string",
+                        "description": "Filter by account status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as nextCursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of users",
+                        "schema": {
+                            "$ref": "#/definitions/http.UserListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid filter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Provisions a user with an empty wallet in the given currency (the configured default currency when omitted).\nThe account starts active unless another initial status is given; a self-excluded account needs an end date.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Creates a user",
+                "parameters": [
+                    {
+                        "description": "User details",
+                        "name": "user",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/http.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created user",
+                        "schema": {
+                            "$ref": "#/definitions/http.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid currency, status or end date",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: External reference already in use",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{userId}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Returns the user's account details and wallets.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User",
+                        "schema": {
+                            "$ref": "#/definitions/http.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{userId}/external-ids": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Returns the providers' player IDs mapped to the user, ordered by provider.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Lists the player IDs of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Player IDs of the user",
+                        "schema": {
+                            "$ref": "#/definitions/http.ExternalIDsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Maps the ID a provider uses for a player to an existing user, so that the provider can address the user on the /provider/{provider}/player/{externalId} routes.\nA player ID belongs to at most one user per provider; repeating a mapping is idempotent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Maps a provider's player ID to a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Provider and player ID",
+                        "name": "mapping",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.LinkExternalIDRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Player ID mapped to the user",
+                        "schema": {
+                            "$ref": "#/definitions/http.ExternalIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid userId, provider or player ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: Missing or invalid admin key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: User does not exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict: Player ID already mapped to another user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "http.BalanceResponse": {
+            "description": "Current balance of every wallet of the user, ordered by currency.",
+            "type": "object",
+            "properties": {
+                "userId": {
+                    "type": "integer"
+                },
+                "wallets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.WalletResponse"
+                    }
+                }
+            }
+        },
+        "http.BalanceVerificationResponse": {
+            "description": "The stored balance next to the sum of the postings on the wallet's ledger account.",
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "string"
+                },
+                "consistent": {
+                    "type": "boolean"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "ledgerBalance": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.BatchItemResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Status is processed, replayed, insufficient_balance, not_found, rejected, conflict,\nlimit_exceeded, account_restricted, aborted or failed.",
+                    "type": "string"
+                },
+                "transaction": {
+                    "description": "The stored transaction of processed and replayed items",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/http.TransactionResponse"
+                        }
+                    ]
+                },
+                "transactionId": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.BatchTransactionItem": {
+            "type": "object",
+            "required": [
+                "amount",
+                "state",
+                "transactionId",
+                "userId"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "bucket": {
+                    "description": "Bucket books the whole amount on the \"real\" or \"bonus\" balance. When omitted, losses are paid\nin the configured debit order and wins are split in proportion to the current balances.",
+                    "type": "string",
+                    "enum": [
+                        "real",
+                        "bonus"
+                    ]
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the amount; the configured default currency when omitted",
+                    "type": "string"
+                },
+                "gameId": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "referenceTransactionId": {
+                    "description": "Required for \"cancel\": the transaction being rolled back",
+                    "type": "string"
+                },
+                "roundId": {
+                    "description": "RoundID links the stakes and payouts of one game round; GameID optionally names the game.",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "state": {
+                    "description": "State is the transaction type: win, lose, cancel, deposit, withdrawal, bonus_grant,\nadjustment, fee or refund. Each type is only accepted from some Source-Types.",
+                    "type": "string"
+                },
+                "transactionId": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "walletCurrency": {
+                    "description": "WalletCurrency selects the wallet when it differs from the amount's currency; the amount is\nthen converted at the current exchange rate. Defaults to the amount's currency.",
+                    "type": "string"
+                }
+            }
+        },
+        "http.BatchTransactionRequest": {
+            "description": "Transactions processed in one request, in order.",
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/http.BatchTransactionItem"
+                    }
+                },
+                "mode": {
+                    "description": "best_effort when omitted",
+                    "type": "string",
+                    "enum": [
+                        "atomic",
+                        "best_effort"
+                    ]
+                }
+            }
+        },
+        "http.BatchTransactionResponse": {
+            "description": "One result per item, in request order.",
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "description": "Items that failed or, in atomic mode, were aborted",
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.BatchItemResponse"
+                    }
+                },
+                "succeeded": {
+                    "description": "Items processed or replayed",
+                    "type": "integer"
+                }
+            }
+        },
+        "http.CaptureHoldRequest": {
+            "description": "Options for capturing a hold.",
+            "type": "object",
+            "properties": {
+                "transactionId": {
+                    "description": "TransactionID is the idempotency key of the resulting 'lose' transaction; derived from the hold when omitted.",
+                    "type": "string"
+                }
+            }
+        },
+        "http.ChainBreakResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "transactionId": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.CreateUserRequest": {
+            "description": "Details of a new user; every field is optional.",
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "description": "ISO 4217 code of the wallet to open; the configured default currency when omitted",
+                    "type": "string"
+                },
+                "externalRef": {
+                    "description": "Reference in the operator's own systems, unique if set",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Initial account status; active when omitted",
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "frozen",
+                        "self_excluded"
+                    ]
+                },
+                "until": {
+                    "description": "End of the self-exclusion when status is self_excluded",
+                    "type": "string"
+                }
+            }
+        },
+        "http.ExternalIDResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "externalId": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.ExternalIDsResponse": {
+            "type": "object",
+            "properties": {
+                "externalIds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.ExternalIDResponse"
+                    }
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.HoldResponse": {
+            "description": "A reservation of funds; only active holds reduce the available balance.",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "holdId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "sourceType": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"active\", \"captured\", \"released\" or \"expired\"",
+                    "type": "string"
+                },
+                "transactionId": {
+                    "description": "Internal ID of the 'lose' transaction that captured the hold",
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.LedgerAuditResponse": {
+            "description": "Discrepancies found in the ledger; both lists are empty when the ledger is consistent.",
+            "type": "object",
+            "properties": {
+                "consistent": {
+                    "type": "boolean"
+                },
+                "mismatchedWallets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.BalanceVerificationResponse"
+                    }
+                },
+                "unbalancedEntries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.UnbalancedEntryResponse"
+                    }
+                }
+            }
+        },
+        "http.LimitResponse": {
+            "description": "A limit in force, with any scheduled raise or removal.",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "The limit in force now",
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "\"loss\" or \"deposit\"",
+                    "type": "string"
+                },
+                "pendingAmount": {
+                    "description": "Set while a raise or removal waits out its cooling-off period.",
+                    "type": "string"
+                },
+                "pendingFrom": {
+                    "type": "string"
+                },
+                "pendingRemoval": {
+                    "type": "boolean"
+                },
+                "period": {
+                    "description": "\"daily\", \"weekly\" or \"monthly\"",
+                    "type": "string"
+                }
+            }
+        },
+        "http.LimitsResponse": {
+            "type": "object",
+            "properties": {
+                "limits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.LimitResponse"
+                    }
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.LinkExternalIDRequest": {
+            "type": "object",
+            "required": [
+                "externalId",
+                "provider"
+            ],
+            "properties": {
+                "externalId": {
+                    "description": "The provider's ID of the player",
+                    "type": "string"
+                },
+                "provider": {
+                    "description": "Lowercase provider name",
+                    "type": "string"
+                }
+            }
+        },
+        "http.PlaceHoldRequest": {
+            "description": "Details of a new hold.",
+            "type": "object",
+            "required": [
+                "amount",
+                "holdId"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code; the configured default currency when omitted",
+                    "type": "string"
+                },
+                "holdId": {
+                    "description": "Idempotency key, unique per Source-Type",
+                    "type": "string"
+                },
+                "ttlSeconds": {
+                    "description": "Lifetime of the hold; the configured default when omitted",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "http.PlaceHoldResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "holdId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "idempotentReplay": {
+                    "description": "True when the holdId had already been placed and nothing was changed",
+                    "type": "boolean"
+                },
+                "sourceType": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"active\", \"captured\", \"released\" or \"expired\"",
+                    "type": "string"
+                },
+                "transactionId": {
+                    "description": "Internal ID of the 'lose' transaction that captured the hold",
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.ProcessTransactionResponse": {
+            "description": "The processed transaction, including the resulting balance.",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "balanceAfter": {
+                    "description": "Empty for transactions recorded before balances were tracked",
+                    "type": "string"
+                },
+                "balanceBefore": {
+                    "description": "Empty for transactions recorded before balances were tracked",
+                    "type": "string"
+                },
+                "bonusAmount": {
+                    "description": "Part of amount booked on the bonus balance",
+                    "type": "string"
+                },
+                "bucket": {
+                    "description": "Sub-balance the request booked the whole amount on, if it named one",
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "exchangeRate": {
+                    "type": "string"
+                },
+                "gameId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "idempotentReplay": {
+                    "description": "True when the transactionId had already been processed and nothing was changed",
+                    "type": "boolean"
+                },
+                "originalAmount": {
+                    "description": "Set only for transactions converted from another currency; amount is in the wallet currency.",
+                    "type": "string"
+                },
+                "originalCurrency": {
+                    "type": "string"
+                },
+                "processedAt": {
+                    "type": "string"
+                },
+                "rateTimestamp": {
+                    "type": "string"
+                },
+                "reversesId": {
+                    "description": "For 'cancel' transactions, the internal ID of the reversed transaction",
+                    "type": "integer"
+                },
+                "roundId": {
+                    "description": "Game round the transaction belongs to, if any",
+                    "type": "string"
+                },
+                "sourceType": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "transactionId": {
+                    "type": "string"
+                },
+                "transferId": {
+                    "description": "For 'debit' and 'credit' transactions, the internal ID of the transfer",
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.ProviderKeyCreatedResponse": {
+            "description": "The new key with its secret, which is only returned once. The provider signs requests with the secret and sends the key ID.",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "keyId": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.ProviderKeyResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "keyId": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.ProviderRequest": {
+            "description": "The settings of a provider; they replace the current settings as a whole.",
+            "type": "object",
+            "required": [
+                "enabled",
+                "sourceTypes"
+            ],
+            "properties": {
+                "enabled": {
+                    "description": "Requests of disabled providers are rejected",
+                    "type": "boolean"
+                },
+                "sourceTypes": {
+                    "description": "Registered Source-Types the provider may send requests with",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.ProviderResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "keys": {
+                    "description": "Oldest first, at most two; only returned for a single provider",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.ProviderKeyResponse"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sourceTypes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.ProvidersResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.ProviderResponse"
+                    }
+                }
+            }
+        },
+        "http.ReverseTransactionRequest": {
+            "description": "Options for reversing a transaction.",
+            "type": "object",
+            "properties": {
+                "force": {
+                    "description": "Force allows the reversal even if it makes the balance negative.",
+                    "type": "boolean"
+                },
+                "transactionId": {
+                    "description": "TransactionID is the idempotency key of the reversal; derived from the original transaction when omitted.",
+                    "type": "string"
+                }
+            }
+        },
+        "http.RoundResponse": {
+            "description": "A game round: stakes open it, a payout settles it and a reversal cancels it.",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "gameId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "payoutAmount": {
+                    "description": "Sum of the round's 'win' transactions",
+                    "type": "string"
+                },
+                "roundId": {
+                    "type": "string"
+                },
+                "sourceType": {
+                    "type": "string"
+                },
+                "stakeAmount": {
+                    "description": "Sum of the round's 'lose' transactions",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"open\", \"settled\" or \"cancelled\"",
+                    "type": "string"
+                },
+                "transactions": {
+                    "description": "In the order they were applied",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.TransactionResponse"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.SetCreditLimitRequest": {
+            "description": "The credit limit of one wallet; 0 restores the default of not allowing a negative balance.",
+            "type": "object",
+            "required": [
+                "creditLimit"
+            ],
+            "properties": {
+                "creditLimit": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the wallet; the configured default currency when omitted",
+                    "type": "string"
+                }
+            }
+        },
+        "http.SetLimitRequest": {
+            "description": "The maximum a user may lose or deposit in one currency over the period's rolling window.",
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code; the configured default currency when omitted",
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "\"loss\" (the default) or \"deposit\"",
+                    "type": "string"
+                }
+            }
+        },
+        "http.SetUserStatusRequest": {
+            "description": "The new account status and why it is changed.",
+            "type": "object",
+            "required": [
+                "reason",
+                "status"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "Recorded in the audit trail",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "frozen",
+                        "self_excluded",
+                        "closed"
+                    ]
+                },
+                "until": {
+                    "description": "End of a self-exclusion; required for, and only allowed with, self_excluded",
+                    "type": "string"
+                }
+            }
+        },
+        "http.SourceTypeRequest": {
+            "description": "The settings of a Source-Type; they replace the current settings as a whole.",
+            "type": "object",
+            "required": [
+                "checkBalance",
+                "enabled",
+                "states"
+            ],
+            "properties": {
+                "checkBalance": {
+                    "description": "Whether debits that overdraw the balance are rejected",
+                    "type": "boolean"
+                },
+                "enabled": {
+                    "description": "Transactions and holds of disabled sources are rejected",
+                    "type": "boolean"
+                },
+                "maxAmount": {
+                    "description": "Cap on a single transaction's amount; no cap when omitted",
+                    "type": "string"
+                },
+                "maxAmountCurrency": {
+                    "description": "ISO 4217 code of maxAmount, to which other currencies are converted; the configured default currency when omitted",
+                    "type": "string"
+                },
+                "states": {
+                    "description": "Transaction types the source may send",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.SourceTypeResponse": {
+            "type": "object",
+            "properties": {
+                "checkBalance": {
+                    "type": "boolean"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "maxAmount": {
+                    "description": "Empty if amounts are not capped",
+                    "type": "string"
+                },
+                "maxAmountCurrency": {
+                    "description": "ISO 4217 code of maxAmount",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "states": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.SourceTypesResponse": {
+            "type": "object",
+            "properties": {
+                "sourceTypes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.SourceTypeResponse"
+                    }
+                }
+            }
+        },
+        "http.StatusChangeResponse": {
+            "type": "object",
+            "properties": {
+                "changedAt": {
+                    "type": "string"
+                },
+                "fromStatus": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "selfExcludedUntil": {
+                    "type": "string"
+                },
+                "toStatus": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.TransactionChainResponse": {
+            "description": "Whether, per wallet, every transaction's balanceBefore equals the previous transaction's balanceAfter and the last one matches the current balance.",
+            "type": "object",
+            "properties": {
+                "breaks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.ChainBreakResponse"
+                    }
+                },
+                "checkedCount": {
+                    "description": "Transactions with balance snapshots that were checked",
+                    "type": "integer"
+                },
+                "consistent": {
+                    "type": "boolean"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "wallets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.WalletChainResponse"
+                    }
+                }
+            }
+        },
+        "http.TransactionHistoryResponse": {
+            "description": "A page of transactions, newest first. Pass nextCursor as the cursor query parameter to fetch the next page.",
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "type": "string"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.TransactionResponse"
+                    }
+                }
+            }
+        },
+        "http.TransactionRequest": {
+            "description": "Details for a new transaction to update user balance.",
+            "type": "object",
+            "required": [
+                "amount",
+                "state",
+                "transactionId"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "bucket": {
+                    "description": "Bucket books the whole amount on the \"real\" or \"bonus\" balance. When omitted, losses are paid\nin the configured debit order and wins are split in proportion to the current balances.",
+                    "type": "string",
+                    "enum": [
+                        "real",
+                        "bonus"
+                    ]
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the amount; the configured default currency when omitted",
+                    "type": "string"
+                },
+                "gameId": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "referenceTransactionId": {
+                    "description": "Required for \"cancel\": the transaction being rolled back",
+                    "type": "string"
+                },
+                "roundId": {
+                    "description": "RoundID links the stakes and payouts of one game round; GameID optionally names the game.",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "state": {
+                    "description": "State is the transaction type: win, lose, cancel, deposit, withdrawal, bonus_grant,\nadjustment, fee or refund. Each type is only accepted from some Source-Types.",
+                    "type": "string"
+                },
+                "transactionId": {
+                    "type": "string"
+                },
+                "walletCurrency": {
+                    "description": "WalletCurrency selects the wallet when it differs from the amount's currency; the amount is\nthen converted at the current exchange rate. Defaults to the amount's currency.",
+                    "type": "string"
+                }
+            }
+        },
+        "http.TransactionResponse": {
+            "description": "A processed transaction record.",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "balanceAfter": {
+                    "description": "Empty for transactions recorded before balances were tracked",
+                    "type": "string"
+                },
+                "balanceBefore": {
+                    "description": "Empty for transactions recorded before balances were tracked",
+                    "type": "string"
+                },
+                "bonusAmount": {
+                    "description": "Part of amount booked on the bonus balance",
+                    "type": "string"
+                },
+                "bucket": {
+                    "description": "Sub-balance the request booked the whole amount on, if it named one",
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "exchangeRate": {
+                    "type": "string"
+                },
+                "gameId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "originalAmount": {
+                    "description": "Set only for transactions converted from another currency; amount is in the wallet currency.",
+                    "type": "string"
+                },
+                "originalCurrency": {
+                    "type": "string"
+                },
+                "processedAt": {
+                    "type": "string"
+                },
+                "rateTimestamp": {
+                    "type": "string"
+                },
+                "reversesId": {
+                    "description": "For 'cancel' transactions, the internal ID of the reversed transaction",
+                    "type": "integer"
+                },
+                "roundId": {
+                    "description": "Game round the transaction belongs to, if any",
+                    "type": "string"
+                },
+                "sourceType": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "transactionId": {
+                    "type": "string"
+                },
+                "transferId": {
+                    "description": "For 'debit' and 'credit' transactions, the internal ID of the transfer",
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.TransferRequest": {
+            "description": "Details of a transfer of real money from one user to another.",
+            "type": "object",
+            "required": [
+                "amount",
+                "fromUserId",
+                "toUserId",
+                "transferId"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code; the configured default currency when omitted",
+                    "type": "string"
+                },
+                "fromUserId": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "description": "E.g. \"affiliate payout\"",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "toUserId": {
+                    "type": "integer"
+                },
+                "transferId": {
+                    "description": "Idempotency key of the transfer",
+                    "type": "string",
+                    "maxLength": 200
+                }
+            }
+        },
+        "http.TransferResponse": {
+            "description": "A transfer; its debit and credit legs are transactions of Source-Type 'transfer' whose transferId is the transfer's id.",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "credit": {
+                    "$ref": "#/definitions/http.TransactionResponse"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "debit": {
+                    "$ref": "#/definitions/http.TransactionResponse"
+                },
+                "fromUserId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "idempotentReplay": {
+                    "description": "True when the transferId had already been processed and nothing was changed",
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "toUserId": {
+                    "type": "integer"
+                },
+                "transferId": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.UnbalancedEntryResponse": {
+            "type": "object",
+            "properties": {
+                "journalEntryId": {
+                    "type": "integer"
+                },
+                "sum": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.UserBalanceVerificationResponse": {
+            "description": "The result for each wallet; consistent is true if every wallet matches its ledger account.",
+            "type": "object",
+            "properties": {
+                "consistent": {
+                    "type": "boolean"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "wallets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.BalanceVerificationResponse"
+                    }
+                }
+            }
+        },
+        "http.UserListResponse": {
+            "description": "A page of users ordered by ID. Pass nextCursor as the cursor query parameter to fetch the next page.",
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.UserResponse"
+                    }
+                }
+            }
+        },
+        "http.UserResponse": {
+            "description": "Account details of a user; wallets are only included for a single user.",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "externalRef": {
+                    "type": "string"
+                },
+                "selfExcludedUntil": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "wallets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.WalletResponse"
+                    }
+                }
+            }
+        },
+        "http.UserStatusResponse": {
+            "description": "The current account status and the audit trail of its changes, oldest first.",
+            "type": "object",
+            "properties": {
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.StatusChangeResponse"
+                    }
+                },
+                "selfExcludedUntil": {
+                    "description": "Set while self-excluded",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"active\", \"frozen\", \"self_excluded\" or \"closed\"",
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.WalletChainResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "description": "Empty if the balance kept changing during the check",
+                    "type": "string"
+                },
+                "consistent": {
+                    "type": "boolean"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "lastBalanceAfter": {
+                    "description": "Empty if the latest transaction has no snapshot",
+                    "type": "string"
+                }
+            }
+        },
+        "http.WalletResponse": {
+            "type": "object",
+            "properties": {
+                "availableBalance": {
+                    "description": "Part of balance not reserved by active holds",
+                    "type": "string"
+                },
+                "balance": {
+                    "description": "Total, formatted with the currency's number of decimal places",
+                    "type": "string"
+                },
+                "bonusBalance": {
+                    "description": "Part of balance that is bonus money",
+                    "type": "string"
+                },
+                "creditLimit": {
+                    "description": "How far the balance may be drawn below zero",
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "realBalance": {
+                    "description": "Part of balance that is real money",
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminKey": {
+            "type": "apiKey",
+            "name": "X-Admin-Key",
+            "in": "header"
         }
     }
 }`
@@ -24,8 +24,59 @@ func NewServer(cfg *config.Config, handler *http.Handler) *Server {
 	engine.GET("/", getAPIBaseStatus)
 	engine.GET("/health", getHealthStatus)
 
-	engine.POST("/user/:userId/transaction", handler.ProcessTransaction)
-	engine.GET("/user/:userId/balance", handler.GetUserBalance)
+	// Routes operators use to manage users, accounts, limits, transfers, Source-Types and providers.
+	admin := engine.Group("/", http.AuthenticateAdmin(cfg.AdminAPIKey))
+	admin.POST("/users", handler.CreateUser)
+	admin.GET("/users", handler.ListUsers)
+	admin.GET("/users/:userId", handler.GetUser)
+	admin.GET("/users/:userId/external-ids", handler.ListExternalIDs)
+	admin.POST("/users/:userId/external-ids", handler.LinkExternalID)
+	admin.GET("/user/:userId/balance/verify", handler.VerifyUserBalance)
+	admin.GET("/user/:userId/balance/chain", handler.VerifyTransactionChain)
+	admin.GET("/ledger/audit", handler.AuditLedger)
+	admin.POST("/transfers", handler.CreateTransfer)
+	admin.GET("/transfers/:transferId", handler.GetTransfer)
+	admin.PUT("/admin/user/:userId/credit-limit", handler.SetCreditLimit)
+	admin.GET("/admin/user/:userId/status", handler.GetUserStatus)
+	admin.PUT("/admin/user/:userId/status", handler.SetUserStatus)
+	admin.GET("/admin/source-types", handler.ListSourceTypes)
+	admin.GET("/admin/source-types/:name", handler.GetSourceType)
+	admin.PUT("/admin/source-types/:name", handler.SaveSourceType)
+	admin.GET("/admin/providers", handler.ListProviders)
+	admin.GET("/admin/providers/:name", handler.GetProvider)
+	admin.PUT("/admin/providers/:name", handler.SaveProvider)
+	admin.POST("/admin/providers/:name/keys", handler.RotateProviderKey)
+	admin.DELETE("/admin/providers/:name/keys/:keyId", handler.RevokeProviderKey)
+	admin.GET("/user/:userId/limits", handler.GetLimits)
+	admin.PUT("/user/:userId/limits/:period", handler.SetLimit)
+	admin.DELETE("/user/:userId/limits/:period", handler.RemoveLimit)
+
+	// Routes providers call to move money and look up balances, transactions and rounds; with
+	// PROVIDER_AUTH their requests must be signed.
+	provider := engine.Group("/")
+	if cfg.ProviderAuth {
+		provider.Use(handler.AuthenticateProvider)
+	} else {
+		log.Printf("WARNING: PROVIDER_AUTH is off, provider requests are accepted without signatures")
+	}
+	provider.GET("/user/:userId/balance", handler.GetUserBalance)
+	provider.GET("/user/:userId/transactions", handler.GetUserTransactions)
+	provider.GET("/user/:userId/transactions/:transactionId", handler.GetUserTransaction)
+	provider.GET("/transactions/:transactionId", handler.GetTransaction)
+	provider.GET("/rounds/:roundId", handler.GetRound)
+	provider.POST("/user/:userId/transaction", handler.ProcessTransaction)
+	provider.POST("/transactions/batch", handler.ProcessTransactionBatch)
+	provider.POST("/transactions/:transactionId/reverse", handler.ReverseTransaction)
+	provider.POST("/user/:userId/holds", handler.PlaceHold)
+	provider.GET("/user/:userId/holds/:holdId", handler.GetHold)
+	provider.POST("/user/:userId/holds/:holdId/capture", handler.CaptureHold)
+	provider.POST("/user/:userId/holds/:holdId/release", handler.ReleaseHold)
+
+	player := provider.Group("/provider/:provider/player/:externalId", handler.ResolveExternalPlayer)
+	player.POST("/transaction", handler.ProcessTransaction)
+	player.GET("/balance", handler.GetUserBalance)
+	player.GET("/transactions", handler.GetUserTransactions)
+	player.GET("/transactions/:transactionId", handler.GetUserTransaction)
 
 	engine.GET("/api/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -65,6 +116,11 @@ func getHealthStatus(c *gin.Context) {
 	})
 }
 
+// Engine returns the router serving the API, e.g. to serve requests in tests.
+func (s *Server) Engine() *gin.Engine {
+	return s.engine
+}
+
 func (s *Server) Run() error {
 	addr := fmt.Sprintf(":%s", s.cfg.AppPort)
 	log.Printf("Server starting on %s", addr)
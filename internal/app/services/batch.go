@@ -0,0 +1,196 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/zaynkorai/enlabs/internal/domain/round"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// BatchMode selects what happens to the other items of a batch when one of them fails.
+type BatchMode string
+
+const (
+	// BatchBestEffort processes every item on its own, as if it had been sent separately.
+	BatchBestEffort BatchMode = "best_effort"
+	// BatchAtomic applies all items in one database transaction, or none of them if any item fails.
+	BatchAtomic BatchMode = "atomic"
+)
+
+// MaxBatchSize bounds the number of items of a batch.
+const MaxBatchSize = 500
+
+// ErrBatchAborted is the result of the items of an atomic batch that were not applied because
+// another item failed.
+var ErrBatchAborted = errors.New("not applied because another item of the atomic batch failed")
+
+// BatchItem is one transaction of a batch.
+type BatchItem struct {
+	UserID      uint64
+	Transaction *transaction.Transaction
+}
+
+// BatchItemResult is the outcome of one item: Result on success, including replays, or Err.
+type BatchItemResult struct {
+	Result *ProcessResult
+	Err    error
+}
+
+// ProcessBatch processes the items with the idempotency semantics of ProcessTransaction and returns
+// one result per item, in order. An error is only returned if the batch as a whole could not be
+// processed; the failures of single items are reported in their results.
+func (s *TransactionService) ProcessBatch(mode BatchMode, items []BatchItem) ([]BatchItemResult, error) {
+	if len(items) == 0 || len(items) > MaxBatchSize {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("a batch must have 1 to %d items", MaxBatchSize))
+	}
+	switch mode {
+	case BatchBestEffort:
+		results := make([]BatchItemResult, len(items))
+		for i, item := range items {
+			results[i].Result, results[i].Err = s.ProcessTransaction(item.UserID, item.Transaction)
+		}
+		return results, nil
+	case BatchAtomic:
+		return s.processBatchAtomically(items)
+	default:
+		return nil, appErrors.NewValidationError(fmt.Sprintf("invalid batch mode %q", mode))
+	}
+}
+
+type batchWalletKey struct {
+	userID   uint64
+	currency string
+}
+
+type batchTransactionKey struct {
+	sourceType    string
+	transactionID string
+}
+
+// processBatchAtomically prepares every item first, checking each against the wallets as the
+// earlier items of the batch leave them, and applies the balance updates only if all items pass.
+// The repository re-checks every update against the locked wallets. Atomic batches always lock
+// pessimistically, whatever the configured locking strategy.
+func (s *TransactionService) processBatchAtomically(items []BatchItem) ([]BatchItemResult, error) {
+	results := make([]BatchItemResult, len(items))
+	updates := make([]user.BatchUpdate, 0, len(items))
+	updateItems := make([]int, 0, len(items)) // index of the item of each update
+	wallets := make(map[batchWalletKey]*user.Wallet)
+	rounds := make(map[roundKey]*round.Round)
+	firstItems := make(map[batchTransactionKey]int) // index of the first item with each transactionId
+	duplicates := make(map[int]int)                 // item index -> index of the first item with its transactionId
+	failed := false
+
+	for i, item := range items {
+		t := item.Transaction
+		replay, change, err := s.prepareTransaction(item.UserID, t)
+		if err == nil && replay == nil {
+			key := batchTransactionKey{t.SourceType, t.TransactionID}
+			if first, ok := firstItems[key]; ok {
+				if items[first].Transaction.MatchesRequest(t) {
+					duplicates[i] = first
+					continue
+				}
+				err = appErrors.NewConflictError("transaction with this ID appears earlier in the batch with a different payload")
+			} else {
+				firstItems[key] = i
+				var update user.BalanceUpdate
+				update, err = s.prepareBatchUpdate(item.UserID, t, change, wallets, rounds)
+				if err == nil {
+					updates = append(updates, user.BatchUpdate{UserID: item.UserID, Update: update})
+					updateItems = append(updateItems, i)
+				}
+			}
+		}
+		if err != nil {
+			if !isItemError(err) {
+				return nil, err
+			}
+			results[i].Err = err
+			failed = true
+			continue
+		}
+		results[i].Result = replay
+	}
+	if failed {
+		return abortBatch(results), nil
+	}
+
+	if len(updates) > 0 {
+		err := s.userRepo.ApplyBatch(updates)
+		var batchErr *user.BatchError
+		if errors.As(err, &batchErr) && batchErr.Index < len(updateItems) && isItemError(batchErr.Err) {
+			itemErr := batchErr.Err
+			if appErrors.IsAlreadyProcessedError(itemErr) {
+				itemErr = appErrors.NewConflictError("transaction with this ID was processed concurrently, please retry")
+			}
+			results[updateItems[batchErr.Index]].Err = itemErr
+			return abortBatch(results), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply batch: %w", err)
+		}
+	}
+
+	for _, i := range updateItems {
+		results[i].Result = &ProcessResult{Transaction: items[i].Transaction}
+	}
+	for i, first := range duplicates {
+		results[i].Result = &ProcessResult{Transaction: items[first].Transaction, IdempotentReplay: true}
+	}
+	log.Printf("Atomic batch of %d items applied with %d balance updates", len(items), len(updates))
+	return results, nil
+}
+
+// prepareBatchUpdate computes the update of a batch item against the wallet and round as the earlier
+// items of the batch leave them, and books the item on that simulated wallet and round. Loss limits
+// are checked by the repository, which sees the losses of the earlier items.
+func (s *TransactionService) prepareBatchUpdate(userID uint64, t *transaction.Transaction, change balanceChange,
+	wallets map[batchWalletKey]*user.Wallet, rounds map[roundKey]*round.Round) (user.BalanceUpdate, error) {
+	key := batchWalletKey{userID, t.Currency}
+
+	w, ok := wallets[key]
+	if !ok {
+		var err error
+		if w, err = s.currentWallet(userID, t.Currency); err != nil {
+			return user.BalanceUpdate{}, err
+		}
+		wallets[key] = w
+	}
+	update, err := change(w)
+	if err != nil {
+		return user.BalanceUpdate{}, err
+	}
+	if err := s.completeUpdate(&update, t); err != nil {
+		return user.BalanceUpdate{}, err
+	}
+
+	// The round is booked last, once nothing else can reject the item.
+	if err := s.checkRound(t, rounds); err != nil {
+		return user.BalanceUpdate{}, err
+	}
+
+	w.BonusBalance = w.BonusBalance.Add(w.BonusDelta(update.Delta, update.Allocation))
+	w.Balance = w.Balance.Add(update.Delta)
+	return update, nil
+}
+
+// abortBatch marks every item of a failed atomic batch that has no error of its own as aborted.
+func abortBatch(results []BatchItemResult) []BatchItemResult {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = BatchItemResult{Err: ErrBatchAborted}
+		}
+	}
+	return results
+}
+
+// isItemError reports whether err is the failure of a single item rather than of the batch.
+func isItemError(err error) bool {
+	var appErr *appErrors.AppError
+	return errors.As(err, &appErr)
+}
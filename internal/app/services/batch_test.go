@@ -0,0 +1,119 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+func batchItem(userID uint64, state transaction.Type, transactionID string, amount int64) services.BatchItem {
+	return services.BatchItem{UserID: userID, Transaction: &transaction.Transaction{
+		TransactionID: transactionID,
+		SourceType:    "game",
+		State:         state,
+		Amount:        decimal.NewFromInt(amount),
+	}}
+}
+
+func TestTransactionService_ProcessBatch_BestEffort(t *testing.T) {
+	svc, repos := newTestService(withBalances(map[uint64]int64{1: 10}))
+	applied := 0
+	repos.users.AtomicUpdateBalanceAndCreateTransactionFunc = func(userID uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		applied++
+		return decimal.NewFromInt(10).Add(update.Delta), nil
+	}
+
+	results, err := svc.ProcessBatch(services.BatchBestEffort, []services.BatchItem{
+		batchItem(1, "win", "b-1", 5),
+		batchItem(1, "lose", "b-2", 50),
+		batchItem(3, "win", "b-3", 5),
+		batchItem(1, "lose", "b-4", 5),
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 4) {
+		assert.NoError(t, results[0].Err)
+		assert.True(t, appErrors.IsInsufficientBalanceError(results[1].Err), "got %v", results[1].Err)
+		assert.True(t, appErrors.IsNotFoundError(results[2].Err), "got %v", results[2].Err)
+		assert.NoError(t, results[3].Err)
+	}
+	assert.Equal(t, 2, applied)
+}
+
+func TestTransactionService_ProcessBatch_Atomic(t *testing.T) {
+	svc, repos := newTestService(withBalances(map[uint64]int64{1: 0, 2: 10}))
+	var applied []user.BatchUpdate
+	repos.users.ApplyBatchFunc = func(updates []user.BatchUpdate) error {
+		applied = updates
+		return nil
+	}
+
+	// The loss of user 1 is covered by the win earlier in the batch.
+	results, err := svc.ProcessBatch(services.BatchAtomic, []services.BatchItem{
+		batchItem(1, "win", "a-1", 20),
+		batchItem(1, "lose", "a-2", 15),
+		batchItem(2, "lose", "a-3", 10),
+		batchItem(1, "win", "a-1", 20),
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, applied, 3) {
+		assert.Equal(t, decimal.NewFromInt(20), applied[0].Update.Delta)
+		assert.Equal(t, decimal.NewFromInt(-15), applied[1].Update.Delta)
+		assert.Equal(t, uint64(2), applied[2].UserID)
+		assert.NotNil(t, applied[2].Update.Entry)
+	}
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, results[i].Err)
+		assert.False(t, results[i].Result.IdempotentReplay)
+	}
+	assert.NoError(t, results[3].Err)
+	assert.True(t, results[3].Result.IdempotentReplay, "a repeated item replays the first one")
+	assert.Same(t, results[0].Result.Transaction, results[3].Result.Transaction)
+}
+
+func TestTransactionService_ProcessBatch_AtomicFailure(t *testing.T) {
+	t.Run("item rejected before applying", func(t *testing.T) {
+		svc, repos := newTestService(withBalances(map[uint64]int64{1: 10}))
+		repos.users.ApplyBatchFunc = func(updates []user.BatchUpdate) error {
+			t.Fatal("nothing should be applied")
+			return nil
+		}
+
+		results, err := svc.ProcessBatch(services.BatchAtomic, []services.BatchItem{
+			batchItem(1, "lose", "f-1", 6),
+			batchItem(1, "lose", "f-2", 6),
+			batchItem(1, "win", "f-1", 7),
+		})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results[0].Err, services.ErrBatchAborted)
+		assert.True(t, appErrors.IsInsufficientBalanceError(results[1].Err), "got %v", results[1].Err)
+		assert.True(t, appErrors.IsConflictError(results[2].Err), "reused transactionId with another payload")
+	})
+
+	t.Run("item rejected by the repository", func(t *testing.T) {
+		svc, repos := newTestService(withBalances(map[uint64]int64{1: 10}))
+		repos.users.ApplyBatchFunc = func(updates []user.BatchUpdate) error {
+			return &user.BatchError{Index: 1, Err: appErrors.NewInsufficientBalanceError("insufficient balance: balance remains 2.00 EUR")}
+		}
+
+		results, err := svc.ProcessBatch(services.BatchAtomic, []services.BatchItem{
+			batchItem(1, "lose", "r-1", 5),
+			batchItem(1, "lose", "r-2", 5),
+		})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results[0].Err, services.ErrBatchAborted)
+		assert.True(t, appErrors.IsInsufficientBalanceError(results[1].Err))
+	})
+
+	t.Run("invalid batch", func(t *testing.T) {
+		svc, _ := newTestService(withBalances(map[uint64]int64{1: 10}))
+		_, err := svc.ProcessBatch(services.BatchAtomic, nil)
+		assert.True(t, appErrors.IsValidationError(err))
+		_, err = svc.ProcessBatch("sometimes", []services.BatchItem{batchItem(1, "win", "i-1", 1)})
+		assert.True(t, appErrors.IsValidationError(err))
+	})
+}
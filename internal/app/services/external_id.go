@@ -0,0 +1,141 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// providerNamePattern restricts provider names to lowercase identifiers that are safe in URLs.
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+const maxExternalIDLength = 255
+
+// WithAutoProvisioning creates a user, with an empty wallet in the default currency, the first time
+// a provider refers to a player ID that is not mapped yet. Without it, unknown player IDs are not found.
+func WithAutoProvisioning() Option {
+	return func(s *TransactionService) {
+		s.autoProvision = true
+	}
+}
+
+// ResolveExternalUser returns the ID of the user the provider's player ID is mapped to, provisioning
+// the user if auto-provisioning is enabled and the player ID is not mapped yet.
+func (s *TransactionService) ResolveExternalUser(provider, externalID string) (uint64, error) {
+	if err := validateExternalID(provider, externalID); err != nil {
+		return 0, err
+	}
+	link, err := s.findExternalID(provider, externalID)
+	if err != nil {
+		return 0, err
+	}
+	if link != nil {
+		return link.UserID, nil
+	}
+	if !s.autoProvision {
+		return 0, appErrors.NewNotFoundError(fmt.Sprintf("no user is mapped to player %s of provider %s", externalID, provider))
+	}
+
+	link = &user.ExternalID{Provider: provider, ExternalID: externalID}
+	u, err := s.userRepo.ProvisionExternalUser(link, s.defaultCurrency)
+	if appErrors.IsAlreadyProcessedError(err) {
+		// A concurrent request provisioned the player first; use the user it created.
+		link, err = s.findExternalID(provider, externalID)
+		if err != nil {
+			return 0, err
+		}
+		if link == nil {
+			return 0, fmt.Errorf("player %s/%s reported as mapped but not found", provider, externalID)
+		}
+		return link.UserID, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to provision user for player %s/%s: %w", provider, externalID, err)
+	}
+
+	log.Printf("User %d provisioned for player %s of provider %s", u.ID, externalID, provider)
+	return u.ID, nil
+}
+
+// LinkExternalID maps the provider's player ID to an existing user. Linking a player ID to the user
+// it is already mapped to returns the existing mapping; mapping it to another user is a conflict.
+func (s *TransactionService) LinkExternalID(userID uint64, provider, externalID string) (*user.ExternalID, error) {
+	if err := validateExternalID(provider, externalID); err != nil {
+		return nil, err
+	}
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.findExternalID(provider, externalID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return linkedExternalID(existing, userID)
+	}
+
+	link := &user.ExternalID{Provider: provider, ExternalID: externalID, UserID: userID}
+	if err := s.userRepo.LinkExternalID(link); err != nil {
+		if appErrors.IsConflictError(err) {
+			// Mapped concurrently since the check above.
+			existing, findErr := s.findExternalID(provider, externalID)
+			if findErr != nil {
+				return nil, findErr
+			}
+			if existing != nil {
+				return linkedExternalID(existing, userID)
+			}
+			return nil, err
+		}
+		if appErrors.IsNotFoundError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to map player %s/%s: %w", provider, externalID, err)
+	}
+
+	log.Printf("Player %s of provider %s mapped to user %d", externalID, provider, userID)
+	return link, nil
+}
+
+// ListExternalIDs returns the player IDs mapped to the user.
+func (s *TransactionService) ListExternalIDs(userID uint64) ([]user.ExternalID, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+	return s.userRepo.ListExternalIDs(userID)
+}
+
+func linkedExternalID(existing *user.ExternalID, userID uint64) (*user.ExternalID, error) {
+	if existing.UserID != userID {
+		return nil, appErrors.NewConflictError(fmt.Sprintf("player %s of provider %s is already mapped to another user",
+			existing.ExternalID, existing.Provider))
+	}
+	return existing, nil
+}
+
+func validateExternalID(provider, externalID string) error {
+	if !providerNamePattern.MatchString(provider) {
+		return appErrors.NewValidationError(fmt.Sprintf("invalid provider %q: must be 1 to 50 lowercase letters, digits, '-' or '_'", provider))
+	}
+	if externalID == "" || len(externalID) > maxExternalIDLength {
+		return appErrors.NewValidationError(fmt.Sprintf("player ID must be 1 to %d characters long", maxExternalIDLength))
+	}
+	return nil
+}
+
+// findExternalID returns the mapping of the provider's player ID, or nil if there is none.
+func (s *TransactionService) findExternalID(provider, externalID string) (*user.ExternalID, error) {
+	link, err := s.userRepo.GetByExternalID(provider, externalID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player %s/%s: %w", provider, externalID, err)
+	}
+	return link, nil
+}
@@ -0,0 +1,114 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+func TestTransactionService_ResolveExternalUser(t *testing.T) {
+	t.Run("mapped player", func(t *testing.T) {
+		mockUserRepo := &mocks.MockUserRepository{}
+		svc := services.NewTransactionService(mockUserRepo, &mocks.MockTransactionRepository{})
+		mockUserRepo.GetByExternalIDFunc = func(provider, externalID string) (*user.ExternalID, error) {
+			return &user.ExternalID{Provider: provider, ExternalID: externalID, UserID: 7}, nil
+		}
+
+		userID, err := svc.ResolveExternalUser("acme", "p-1")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(7), userID)
+	})
+
+	t.Run("unknown player without auto-provisioning", func(t *testing.T) {
+		mockUserRepo := &mocks.MockUserRepository{}
+		svc := services.NewTransactionService(mockUserRepo, &mocks.MockTransactionRepository{})
+		mockUserRepo.GetByExternalIDFunc = func(provider, externalID string) (*user.ExternalID, error) {
+			return nil, sql.ErrNoRows
+		}
+
+		_, err := svc.ResolveExternalUser("acme", "p-1")
+		assert.True(t, appErrors.IsNotFoundError(err))
+	})
+
+	t.Run("unknown player is provisioned", func(t *testing.T) {
+		mockUserRepo := &mocks.MockUserRepository{}
+		svc := services.NewTransactionService(mockUserRepo, &mocks.MockTransactionRepository{},
+			services.WithDefaultCurrency("USD"), services.WithAutoProvisioning())
+		mockUserRepo.GetByExternalIDFunc = func(provider, externalID string) (*user.ExternalID, error) {
+			return nil, sql.ErrNoRows
+		}
+		mockUserRepo.ProvisionExternalUserFunc = func(link *user.ExternalID, code string) (*user.User, error) {
+			assert.Equal(t, "acme", link.Provider)
+			assert.Equal(t, "p-1", link.ExternalID)
+			assert.Equal(t, "USD", code)
+			link.UserID = 8
+			return &user.User{ID: 8, Status: user.StatusActive}, nil
+		}
+
+		userID, err := svc.ResolveExternalUser("acme", "p-1")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(8), userID)
+	})
+
+	t.Run("concurrently provisioned player", func(t *testing.T) {
+		mockUserRepo := &mocks.MockUserRepository{}
+		svc := services.NewTransactionService(mockUserRepo, &mocks.MockTransactionRepository{}, services.WithAutoProvisioning())
+		lookups := 0
+		mockUserRepo.GetByExternalIDFunc = func(provider, externalID string) (*user.ExternalID, error) {
+			lookups++
+			if lookups == 1 {
+				return nil, sql.ErrNoRows
+			}
+			return &user.ExternalID{Provider: provider, ExternalID: externalID, UserID: 9}, nil
+		}
+		mockUserRepo.ProvisionExternalUserFunc = func(link *user.ExternalID, code string) (*user.User, error) {
+			return nil, appErrors.NewAlreadyProcessedError("external ID acme/p-1 has already been mapped")
+		}
+
+		userID, err := svc.ResolveExternalUser("acme", "p-1")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(9), userID)
+	})
+
+	t.Run("invalid provider", func(t *testing.T) {
+		svc := services.NewTransactionService(&mocks.MockUserRepository{}, &mocks.MockTransactionRepository{})
+		_, err := svc.ResolveExternalUser("Acme Games", "p-1")
+		assert.True(t, appErrors.IsValidationError(err))
+		_, err = svc.ResolveExternalUser("acme", "")
+		assert.True(t, appErrors.IsValidationError(err))
+	})
+}
+
+func TestTransactionService_LinkExternalID(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	svc := services.NewTransactionService(mockUserRepo, &mocks.MockTransactionRepository{})
+	mockUserRepo.GetByIDFunc = activeUser
+
+	var mapped *user.ExternalID
+	mockUserRepo.GetByExternalIDFunc = func(provider, externalID string) (*user.ExternalID, error) {
+		if mapped == nil {
+			return nil, sql.ErrNoRows
+		}
+		return mapped, nil
+	}
+	mockUserRepo.LinkExternalIDFunc = func(link *user.ExternalID) error {
+		mapped = link
+		return nil
+	}
+
+	link, err := svc.LinkExternalID(1, "acme", "p-1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), link.UserID)
+
+	link, err = svc.LinkExternalID(1, "acme", "p-1")
+	assert.NoError(t, err, "linking again to the same user is idempotent")
+	assert.Equal(t, mapped, link)
+
+	_, err = svc.LinkExternalID(2, "acme", "p-1")
+	assert.True(t, appErrors.IsConflictError(err))
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/hold"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+const (
+	DefaultHoldTTL = 15 * time.Minute
+	MaxHoldTTL     = 24 * time.Hour
+)
+
+// captureIDPrefix is used to derive the idempotency key of a capture when the caller does not supply one.
+const captureIDPrefix = "capture:"
+
+// holdSweepBatchSize bounds how many expired holds are released per query.
+const holdSweepBatchSize = 100
+
+// WithHolds enables fund reservations. Holds placed without a TTL expire after defaultTTL;
+// longer TTLs than maxTTL are refused.
+func WithHolds(repo hold.Repository, defaultTTL, maxTTL time.Duration) Option {
+	return func(s *TransactionService) {
+		s.holdRepo = repo
+		s.defaultHoldTTL = defaultTTL
+		s.maxHoldTTL = maxTTL
+	}
+}
+
+// HoldResult describes the outcome of PlaceHold.
+type HoldResult struct {
+	Hold *hold.Hold
+	// IdempotentReplay is true when the request matched an already placed holdId.
+	IdempotentReplay bool
+}
+
+// PlaceHold reserves req.Amount of the user's available balance until the hold is captured,
+// released or expires after ttl (the default TTL when zero). Placing the same holdId again
+// replays the stored hold.
+func (s *TransactionService) PlaceHold(userID uint64, req *hold.Hold, ttl time.Duration) (*HoldResult, error) {
+	if s.holdRepo == nil {
+		return nil, appErrors.NewValidationError("holds are not available")
+	}
+	req.UserID = userID
+	if req.Currency == "" {
+		req.Currency = s.defaultCurrency
+	}
+	if err := validateAmount(req.Amount, req.Currency); err != nil {
+		return nil, err
+	}
+	if ttl == 0 {
+		ttl = s.defaultHoldTTL
+	}
+	if ttl < 0 || ttl > s.maxHoldTTL {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("hold TTL must be positive and at most %s", s.maxHoldTTL))
+	}
+
+	existing, err := s.findHold(req.SourceType, req.HoldID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return replayHold(existing, req)
+	}
+
+	// A hold is captured as a loss, so the source must accept one of its amount. Holds reserve
+	// available funds whether or not the source checks balances.
+	capture := &transaction.Transaction{SourceType: req.SourceType, State: transaction.TypeLose, Currency: req.Currency, Amount: req.Amount}
+	if _, err := s.checkSource(capture); err != nil {
+		return nil, err
+	}
+	u, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatus(u, true); err != nil {
+		return nil, err
+	}
+	// The held amount is committed as a loss when the hold is placed, so that is when the loss limits
+	// are checked; capturing the hold does not check them again.
+	lossLimits, err := s.limitsInForce(userID, limit.KindLoss, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Status = hold.StatusActive
+	req.ExpiresAt = time.Now().Add(ttl)
+	if err := s.holdRepo.Place(req, lossLimits); err != nil {
+		if appErrors.IsAlreadyProcessedError(err) {
+			// A concurrent request with the same holdId won the race after our pre-check.
+			existing, findErr := s.findHold(req.SourceType, req.HoldID)
+			if findErr != nil {
+				return nil, findErr
+			}
+			if existing == nil {
+				return nil, fmt.Errorf("hold %s reported as placed but not found", req.HoldID)
+			}
+			return replayHold(existing, req)
+		}
+		if appErrors.IsNotFoundError(err) || appErrors.IsValidationError(err) || appErrors.IsLimitExceededError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to place hold: %w", err)
+	}
+
+	log.Printf("User %d placed hold %s/%s of %s %s until %s", userID, req.SourceType, req.HoldID,
+		currency.Format(req.Currency, req.Amount), req.Currency, req.ExpiresAt.Format(time.RFC3339))
+	return &HoldResult{Hold: req}, nil
+}
+
+func replayHold(existing, req *hold.Hold) (*HoldResult, error) {
+	if !existing.MatchesRequest(req) {
+		return nil, appErrors.NewConflictError("hold with this ID has already been placed with a different payload")
+	}
+	return &HoldResult{Hold: existing, IdempotentReplay: true}, nil
+}
+
+// GetHold returns the user's hold with the given external ID.
+func (s *TransactionService) GetHold(userID uint64, sourceType, holdID string) (*hold.Hold, error) {
+	if s.holdRepo == nil {
+		return nil, appErrors.NewValidationError("holds are not available")
+	}
+	h, err := s.findHold(sourceType, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if h == nil || h.UserID != userID {
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("hold with ID %s not found for user %d", holdID, userID))
+	}
+	return h, nil
+}
+
+// CaptureHold settles the user's hold with a "lose" transaction for the held amount, if the user's
+// account may still be debited. transactionID is the idempotency key of that transaction; it is
+// derived from the hold when empty, so that repeating the capture replays the stored result.
+func (s *TransactionService) CaptureHold(userID uint64, sourceType, holdID, transactionID string) (*ProcessResult, error) {
+	h, err := s.GetHold(userID, sourceType, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	if transactionID == "" {
+		transactionID = captureIDPrefix + h.HoldID
+	}
+	capture := &transaction.Transaction{
+		UserID:        h.UserID,
+		TransactionID: transactionID,
+		SourceType:    h.SourceType,
+		State:         transaction.TypeLose,
+		Currency:      h.Currency,
+		Amount:        h.Amount,
+	}
+	capture.RequestHash = capture.Fingerprint()
+
+	existing, err := s.findProcessedTransaction(capture.SourceType, capture.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return s.replay(existing, capture)
+	}
+	if h.Status != hold.StatusActive {
+		return nil, appErrors.NewConflictError(fmt.Sprintf("hold %s is already %s", h.HoldID, h.Status))
+	}
+	// The account may have been restricted since the hold was placed; releasing it stays allowed.
+	u, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatus(u, true); err != nil {
+		return nil, err
+	}
+
+	delta := h.Amount.Neg()
+	update := user.BalanceUpdate{
+		Currency:    h.Currency,
+		Delta:       delta,
+		Allocation:  user.Allocation{DebitOrder: s.debitOrder},
+		Transaction: capture,
+	}
+	update.Entry, err = journalEntryFor(capture, delta)
+	if err != nil {
+		return nil, err
+	}
+
+	newBalance, err := s.holdRepo.Capture(h.ID, update)
+	if err != nil {
+		if appErrors.IsAlreadyProcessedError(err) {
+			existing, findErr := s.findProcessedTransaction(capture.SourceType, capture.TransactionID)
+			if findErr != nil {
+				return nil, findErr
+			}
+			if existing == nil {
+				return nil, fmt.Errorf("transaction %s reported as processed but not found", capture.TransactionID)
+			}
+			return s.replay(existing, capture)
+		}
+		if appErrors.IsNotFoundError(err) || appErrors.IsValidationError(err) || appErrors.IsConflictError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to capture hold %s: %w", h.HoldID, err)
+	}
+
+	log.Printf("User %d captured hold %s/%s, %s balance updated to %s", userID, h.SourceType, h.HoldID,
+		h.Currency, currency.Format(h.Currency, newBalance))
+	return &ProcessResult{Transaction: capture}, nil
+}
+
+// ReleaseHold frees the user's hold without moving any money. Releasing a hold that was already
+// released or has expired returns it unchanged.
+func (s *TransactionService) ReleaseHold(userID uint64, sourceType, holdID string) (*hold.Hold, error) {
+	h, err := s.GetHold(userID, sourceType, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if h.Status == hold.StatusReleased || h.Status == hold.StatusExpired {
+		return h, nil
+	}
+	if h.Status != hold.StatusActive {
+		return nil, appErrors.NewConflictError(fmt.Sprintf("hold %s is already %s", h.HoldID, h.Status))
+	}
+
+	released, err := s.holdRepo.Release(h.ID, hold.StatusReleased)
+	if appErrors.IsConflictError(err) {
+		// The hold changed since it was read; report its current state.
+		return s.ReleaseHold(userID, sourceType, holdID)
+	}
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to release hold %s: %w", h.HoldID, err)
+	}
+	released.Status = hold.StatusReleased
+	return released, nil
+}
+
+// ExpireHolds releases every active hold whose TTL has passed at now and returns how many were expired.
+func (s *TransactionService) ExpireHolds(now time.Time) (int, error) {
+	if s.holdRepo == nil {
+		return 0, nil
+	}
+	expired := 0
+	for {
+		batch, err := s.holdRepo.ListExpired(now, holdSweepBatchSize)
+		if err != nil {
+			return expired, err
+		}
+		for _, h := range batch {
+			_, err := s.holdRepo.Release(h.ID, hold.StatusExpired)
+			if appErrors.IsConflictError(err) {
+				continue // Captured or released since it was listed
+			}
+			if err != nil {
+				return expired, fmt.Errorf("failed to expire hold %s: %w", h.HoldID, err)
+			}
+			expired++
+		}
+		if len(batch) < holdSweepBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// RunHoldSweeper expires holds every interval until ctx is done.
+func (s *TransactionService) RunHoldSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			expired, err := s.ExpireHolds(now)
+			if err != nil {
+				log.Printf("Failed to expire holds: %v", err)
+			}
+			if expired > 0 {
+				log.Printf("Expired %d holds", expired)
+			}
+		}
+	}
+}
+
+// findHold returns the hold with the given external ID from the given source, or nil if there is none.
+func (s *TransactionService) findHold(sourceType, holdID string) (*hold.Hold, error) {
+	h, err := s.holdRepo.GetByHoldID(sourceType, holdID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+	return h, nil
+}
@@ -0,0 +1,191 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/hold"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// holdRepo returns a hold repository that has stored the given holds.
+func holdRepo(holds ...*hold.Hold) *mocks.MockHoldRepository {
+	mockHoldRepo := &mocks.MockHoldRepository{}
+	mockHoldRepo.GetByHoldIDFunc = func(sourceType, holdID string) (*hold.Hold, error) {
+		for _, h := range holds {
+			if h.SourceType == sourceType && h.HoldID == holdID {
+				return h, nil
+			}
+		}
+		return nil, sql.ErrNoRows
+	}
+	return mockHoldRepo
+}
+
+func TestTransactionService_PlaceHold(t *testing.T) {
+	mockHoldRepo := holdRepo()
+	svc, _ := newTestService(withOptions(services.WithHolds(mockHoldRepo, time.Minute, time.Hour)))
+
+	mockHoldRepo.PlaceFunc = func(h *hold.Hold, lossLimits []limit.Limit) error {
+		assert.Equal(t, uint64(1), h.UserID)
+		assert.Equal(t, "EUR", h.Currency)
+		assert.Equal(t, hold.StatusActive, h.Status)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), h.ExpiresAt, 5*time.Second)
+		h.ID = 7
+		return nil
+	}
+
+	result, err := svc.PlaceHold(1, &hold.Hold{HoldID: "bet-1", SourceType: "game", Amount: decimal.NewFromFloat(5.00)}, 0)
+	assert.NoError(t, err)
+	assert.False(t, result.IdempotentReplay)
+	assert.Equal(t, uint64(7), result.Hold.ID)
+
+	_, err = svc.PlaceHold(1, &hold.Hold{HoldID: "bet-2", SourceType: "game", Amount: decimal.NewFromFloat(5.00)}, 2*time.Hour)
+	assert.True(t, appErrors.IsValidationError(err))
+}
+
+func TestTransactionService_PlaceHold_LossLimits(t *testing.T) {
+	mockHoldRepo := holdRepo()
+	mockLimitRepo := &mocks.MockLimitRepository{}
+	mockLimitRepo.ListByUserFunc = func(userID uint64) ([]limit.Limit, error) {
+		return []limit.Limit{
+			{ID: 1, UserID: userID, Kind: limit.KindLoss, Currency: "EUR", Period: limit.PeriodDaily, Amount: decimal.NewFromInt(50)},
+			{ID: 2, UserID: userID, Kind: limit.KindDeposit, Currency: "EUR", Period: limit.PeriodDaily, Amount: decimal.NewFromInt(50)},
+			{ID: 3, UserID: userID, Kind: limit.KindLoss, Currency: "USD", Period: limit.PeriodDaily, Amount: decimal.NewFromInt(50)},
+		}, nil
+	}
+	svc, _ := newTestService(withOptions(services.WithHolds(mockHoldRepo, time.Minute, time.Hour), services.WithLimits(mockLimitRepo, time.Hour)))
+
+	// The repository checks the limits against the locked wallet when it places the hold.
+	mockHoldRepo.PlaceFunc = func(h *hold.Hold, lossLimits []limit.Limit) error {
+		if assert.Len(t, lossLimits, 1) {
+			assert.Equal(t, uint64(1), lossLimits[0].ID)
+		}
+		return appErrors.NewLimitExceededError("daily loss limit of 50.00 EUR exceeded: 50.00 EUR lost in the last 24 hours")
+	}
+
+	_, err := svc.PlaceHold(1, &hold.Hold{HoldID: "bet-1", SourceType: "game", Amount: decimal.NewFromInt(5)}, 0)
+	assert.True(t, appErrors.IsLimitExceededError(err), "got %v", err)
+}
+
+func TestTransactionService_PlaceHold_Replay(t *testing.T) {
+	stored := &hold.Hold{ID: 3, UserID: 1, HoldID: "bet-1", SourceType: "game", Currency: "EUR", Amount: decimal.NewFromFloat(5.00), Status: hold.StatusActive}
+	mockHoldRepo := holdRepo(stored)
+	svc, _ := newTestService(withOptions(services.WithHolds(mockHoldRepo, time.Minute, time.Hour)))
+	mockHoldRepo.PlaceFunc = func(h *hold.Hold, lossLimits []limit.Limit) error {
+		t.Fatal("PlaceFunc should not be called for a replayed hold")
+		return nil
+	}
+
+	result, err := svc.PlaceHold(1, &hold.Hold{HoldID: "bet-1", SourceType: "game", Amount: decimal.NewFromFloat(5.00)}, 0)
+	assert.NoError(t, err)
+	assert.True(t, result.IdempotentReplay)
+	assert.Equal(t, uint64(3), result.Hold.ID)
+
+	_, err = svc.PlaceHold(1, &hold.Hold{HoldID: "bet-1", SourceType: "game", Amount: decimal.NewFromFloat(6.00)}, 0)
+	assert.True(t, appErrors.IsConflictError(err))
+}
+
+func TestTransactionService_CaptureHold(t *testing.T) {
+	active := &hold.Hold{ID: 4, UserID: 1, HoldID: "bet-1", SourceType: "game", Currency: "EUR", Amount: decimal.NewFromFloat(5.00), Status: hold.StatusActive}
+	mockHoldRepo := holdRepo(active)
+	svc, _ := newTestService(withOptions(services.WithHolds(mockHoldRepo, time.Minute, time.Hour)))
+
+	mockHoldRepo.CaptureFunc = func(id uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		assert.Equal(t, uint64(4), id)
+		assert.True(t, update.Delta.Equal(decimal.NewFromFloat(-5.00)))
+		assert.Equal(t, transaction.TypeLose, update.Transaction.State)
+		assert.Equal(t, "capture:bet-1", update.Transaction.TransactionID)
+		assert.NoError(t, update.Entry.Validate())
+		return decimal.NewFromFloat(15.00), nil
+	}
+
+	result, err := svc.CaptureHold(1, "game", "bet-1", "")
+	assert.NoError(t, err)
+	assert.False(t, result.IdempotentReplay)
+
+	_, err = svc.CaptureHold(2, "game", "bet-1", "")
+	assert.True(t, appErrors.IsNotFoundError(err))
+}
+
+func TestTransactionService_CaptureHold_AccountRestricted(t *testing.T) {
+	active := &hold.Hold{ID: 4, UserID: 1, HoldID: "bet-1", SourceType: "game", Currency: "EUR", Amount: decimal.NewFromFloat(5.00), Status: hold.StatusActive}
+	mockHoldRepo := holdRepo(active)
+	svc, _ := newTestService(withUsers(user.User{ID: 1, Status: user.StatusFrozen}),
+		withOptions(services.WithHolds(mockHoldRepo, time.Minute, time.Hour)))
+	mockHoldRepo.CaptureFunc = func(id uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		t.Fatal("CaptureFunc should not be called for a frozen account")
+		return decimal.Decimal{}, nil
+	}
+	released := false
+	mockHoldRepo.ReleaseFunc = func(id uint64, status hold.Status) (*hold.Hold, error) {
+		released = true
+		return active, nil
+	}
+
+	_, err := svc.CaptureHold(1, "game", "bet-1", "")
+	assert.True(t, appErrors.IsAccountRestrictedError(err), "got %v", err)
+
+	_, err = svc.ReleaseHold(1, "game", "bet-1")
+	assert.NoError(t, err, "releasing frees the funds and stays allowed")
+	assert.True(t, released)
+}
+
+func TestTransactionService_ReleaseHold(t *testing.T) {
+	active := &hold.Hold{ID: 5, UserID: 1, HoldID: "bet-1", SourceType: "game", Currency: "EUR", Amount: decimal.NewFromFloat(5.00), Status: hold.StatusActive}
+	captured := &hold.Hold{ID: 6, UserID: 1, HoldID: "bet-2", SourceType: "game", Currency: "EUR", Amount: decimal.NewFromFloat(5.00), Status: hold.StatusCaptured}
+	expired := &hold.Hold{ID: 8, UserID: 1, HoldID: "bet-3", SourceType: "game", Currency: "EUR", Amount: decimal.NewFromFloat(5.00), Status: hold.StatusExpired}
+	mockHoldRepo := holdRepo(active, captured, expired)
+	svc, _ := newTestService(withOptions(services.WithHolds(mockHoldRepo, time.Minute, time.Hour)))
+
+	calls := 0
+	mockHoldRepo.ReleaseFunc = func(id uint64, status hold.Status) (*hold.Hold, error) {
+		calls++
+		assert.Equal(t, uint64(5), id)
+		assert.Equal(t, hold.StatusReleased, status)
+		released := *active
+		return &released, nil
+	}
+
+	result, err := svc.ReleaseHold(1, "game", "bet-1")
+	assert.NoError(t, err)
+	assert.Equal(t, hold.StatusReleased, result.Status)
+
+	_, err = svc.ReleaseHold(1, "game", "bet-2")
+	assert.True(t, appErrors.IsConflictError(err))
+
+	result, err = svc.ReleaseHold(1, "game", "bet-3")
+	assert.NoError(t, err)
+	assert.Equal(t, hold.StatusExpired, result.Status)
+	assert.Equal(t, 1, calls)
+}
+
+func TestTransactionService_ExpireHolds(t *testing.T) {
+	mockHoldRepo := holdRepo()
+	svc, _ := newTestService(withOptions(services.WithHolds(mockHoldRepo, time.Minute, time.Hour)))
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	mockHoldRepo.ListExpiredFunc = func(at time.Time, limit int) ([]hold.Hold, error) {
+		assert.Equal(t, now, at)
+		return []hold.Hold{{ID: 1, HoldID: "a"}, {ID: 2, HoldID: "b"}}, nil
+	}
+	mockHoldRepo.ReleaseFunc = func(id uint64, status hold.Status) (*hold.Hold, error) {
+		assert.Equal(t, hold.StatusExpired, status)
+		if id == 2 {
+			return nil, appErrors.NewConflictError("hold b is already captured")
+		}
+		return &hold.Hold{ID: id}, nil
+	}
+
+	expired, err := svc.ExpireHolds(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+}
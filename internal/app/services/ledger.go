@@ -0,0 +1,72 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/ledger"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// LedgerService audits wallet balances against the double-entry ledger written by TransactionService.
+type LedgerService struct {
+	ledgerRepo ledger.Repository
+}
+
+func NewLedgerService(ledgerRepo ledger.Repository) *LedgerService {
+	return &LedgerService{ledgerRepo: ledgerRepo}
+}
+
+// LedgerReport is the result of a full ledger audit.
+type LedgerReport struct {
+	// UnbalancedEntries lists journal entries whose postings do not sum to zero.
+	UnbalancedEntries []ledger.UnbalancedEntry
+	// MismatchedWallets lists wallets whose stored balance differs from their ledger balance.
+	MismatchedWallets []ledger.Reconciliation
+}
+
+// Consistent reports whether the audit found no discrepancies.
+func (r *LedgerReport) Consistent() bool {
+	return len(r.UnbalancedEntries) == 0 && len(r.MismatchedWallets) == 0
+}
+
+// VerifyUserBalance compares the stored balance of each of the user's wallets with the sum of the
+// postings on the wallet's account.
+func (s *LedgerService) VerifyUserBalance(userID uint64) ([]ledger.Reconciliation, error) {
+	reconciliations, err := s.ledgerRepo.ReconcileUser(userID)
+	if err == sql.ErrNoRows {
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("user with ID %d not found", userID))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify user balance: %w", err)
+	}
+	for _, r := range reconciliations {
+		if !r.Consistent() {
+			log.Printf("Ledger mismatch for user %d: stored %s balance %s, ledger balance %s", userID, r.Currency,
+				currency.Format(r.Currency, r.Balance), currency.Format(r.Currency, r.LedgerBalance))
+		}
+	}
+	return reconciliations, nil
+}
+
+// Audit checks the whole ledger: every journal entry must balance and every wallet's stored balance
+// must equal their ledger balance.
+func (s *LedgerService) Audit() (*LedgerReport, error) {
+	unbalanced, err := s.ledgerRepo.UnbalancedEntries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to audit ledger: %w", err)
+	}
+	mismatched, err := s.ledgerRepo.MismatchedWallets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to audit ledger: %w", err)
+	}
+
+	report := &LedgerReport{UnbalancedEntries: unbalanced, MismatchedWallets: mismatched}
+	if !report.Consistent() {
+		log.Printf("Ledger audit found %d unbalanced journal entries and %d wallets with mismatched balances",
+			len(unbalanced), len(mismatched))
+	}
+	return report, nil
+}
@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/ledger"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+func TestLedgerService_VerifyUserBalance(t *testing.T) {
+	mockLedgerRepo := &mocks.MockLedgerRepository{}
+	svc := services.NewLedgerService(mockLedgerRepo)
+
+	mockLedgerRepo.ReconcileUserFunc = func(userID uint64) ([]ledger.Reconciliation, error) {
+		if userID != 1 {
+			return nil, sql.ErrNoRows
+		}
+		return []ledger.Reconciliation{
+			{UserID: 1, Currency: "EUR", Balance: decimal.NewFromFloat(10.00), LedgerBalance: decimal.NewFromFloat(7.50)},
+			{UserID: 1, Currency: "USD", Balance: decimal.NewFromFloat(2.00), LedgerBalance: decimal.NewFromFloat(2.00)},
+		}, nil
+	}
+
+	reconciliations, err := svc.VerifyUserBalance(1)
+	assert.NoError(t, err)
+	assert.Len(t, reconciliations, 2)
+	assert.False(t, reconciliations[0].Consistent())
+	assert.True(t, reconciliations[1].Consistent())
+
+	_, err = svc.VerifyUserBalance(2)
+	assert.True(t, appErrors.IsNotFoundError(err))
+}
+
+func TestLedgerService_Audit(t *testing.T) {
+	mockLedgerRepo := &mocks.MockLedgerRepository{}
+	svc := services.NewLedgerService(mockLedgerRepo)
+
+	mockLedgerRepo.UnbalancedEntriesFunc = func() ([]ledger.UnbalancedEntry, error) {
+		return nil, nil
+	}
+	mockLedgerRepo.MismatchedWalletsFunc = func() ([]ledger.Reconciliation, error) {
+		return nil, nil
+	}
+
+	report, err := svc.Audit()
+	assert.NoError(t, err)
+	assert.True(t, report.Consistent())
+
+	mockLedgerRepo.UnbalancedEntriesFunc = func() ([]ledger.UnbalancedEntry, error) {
+		return []ledger.UnbalancedEntry{{JournalEntryID: 4, Sum: decimal.NewFromFloat(0.01)}}, nil
+	}
+
+	report, err = svc.Audit()
+	assert.NoError(t, err)
+	assert.False(t, report.Consistent())
+	assert.Len(t, report.UnbalancedEntries, 1)
+}
+
+func TestLedger_TransferBalances(t *testing.T) {
+	entry, err := ledger.Transfer("win game/txn-1", ledger.HouseAccount("game", "EUR"), ledger.UserAccount(1, "EUR"), decimal.NewFromFloat(3.25))
+	assert.NoError(t, err)
+	assert.NoError(t, entry.Validate())
+	assert.Len(t, entry.Accounts, 2)
+
+	_, err = ledger.Transfer("empty", ledger.HouseAccount("game", "EUR"), ledger.UserAccount(1, "EUR"), decimal.Zero)
+	assert.Error(t, err)
+
+	_, err = ledger.Transfer("cross-currency", ledger.HouseAccount("game", "USD"), ledger.UserAccount(1, "EUR"), decimal.NewFromFloat(1.00))
+	assert.Error(t, err)
+
+	entry.Postings[0].Amount = decimal.NewFromFloat(-3.00)
+	assert.Error(t, entry.Validate())
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// DefaultLimitCooldown is how long raising or removing a loss or deposit limit takes to apply, unless configured otherwise.
+const DefaultLimitCooldown = 24 * time.Hour
+
+// WithLimits enables responsible-gaming loss and deposit limits. Raising or removing a limit applies
+// only after cooldown.
+func WithLimits(repo limit.Repository, cooldown time.Duration) Option {
+	return func(s *TransactionService) {
+		s.limitRepo = repo
+		s.limitCooldown = cooldown
+	}
+}
+
+// GetLimits returns the user's loss and deposit limits, with any raise or removal whose cooling-off
+// period has passed applied.
+func (s *TransactionService) GetLimits(userID uint64) ([]limit.Limit, error) {
+	if s.limitRepo == nil {
+		return nil, appErrors.NewValidationError("limits are not available")
+	}
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+	limits, err := s.limitRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	current := make([]limit.Limit, 0, len(limits))
+	for i := range limits {
+		l, err := s.settleLimit(&limits[i], now)
+		if err != nil {
+			return nil, err
+		}
+		if l != nil {
+			current = append(current, *l)
+		}
+	}
+	return current, nil
+}
+
+// SetLimit sets the user's limit of the kind for the currency and period. A new or lower limit
+// applies immediately and cancels any scheduled raise; a higher one is scheduled to apply after the
+// cooling-off period, and the current limit stays in force until then.
+func (s *TransactionService) SetLimit(userID uint64, kind limit.Kind, code string, period limit.Period, amount decimal.Decimal) (*limit.Limit, error) {
+	if s.limitRepo == nil {
+		return nil, appErrors.NewValidationError("limits are not available")
+	}
+	if code == "" {
+		code = s.defaultCurrency
+	}
+	if !limit.ValidKind(kind) {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("invalid limit kind %q", kind))
+	}
+	if err := validateAmount(amount, code); err != nil {
+		return nil, err
+	}
+	if !amount.IsPositive() {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("%s limit must be positive", kind))
+	}
+	if !limit.ValidPeriod(period) {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("invalid %s limit period %q", kind, period))
+	}
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	l, err := s.findLimit(userID, kind, code, period, now)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case l == nil:
+		l = &limit.Limit{UserID: userID, Kind: kind, Currency: code, Period: period, Amount: amount}
+	case amount.LessThanOrEqual(l.Amount):
+		l.Amount = amount
+		l.ClearPending()
+	case l.PendingAmount.Valid && l.PendingAmount.Decimal.Equal(amount):
+		// Repeating a scheduled raise does not restart its cooling-off period.
+	default:
+		from := now.Add(s.limitCooldown)
+		l.ClearPending()
+		l.PendingAmount = decimal.NewNullDecimal(amount)
+		l.PendingFrom = &from
+	}
+	if err := s.limitRepo.Save(l); err != nil {
+		return nil, err
+	}
+
+	log.Printf("User %d set %s %s %s limit to %s", userID, period, code, kind, currency.Format(code, amount))
+	return s.settleLimit(l, now)
+}
+
+// RemoveLimit schedules the removal of the user's limit of the kind for the currency and period
+// after the cooling-off period. It returns the limit that stays in force until then, or nil if it
+// was removed immediately.
+func (s *TransactionService) RemoveLimit(userID uint64, kind limit.Kind, code string, period limit.Period) (*limit.Limit, error) {
+	if s.limitRepo == nil {
+		return nil, appErrors.NewValidationError("limits are not available")
+	}
+	if code == "" {
+		code = s.defaultCurrency
+	}
+	if !limit.ValidKind(kind) {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("invalid limit kind %q", kind))
+	}
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	l, err := s.findLimit(userID, kind, code, period, now)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("user %d has no %s %s %s limit", userID, period, code, kind))
+	}
+	if !l.PendingRemoval {
+		from := now.Add(s.limitCooldown)
+		l.ClearPending()
+		l.PendingRemoval = true
+		l.PendingFrom = &from
+		if err := s.limitRepo.Save(l); err != nil {
+			return nil, err
+		}
+		log.Printf("User %d scheduled removal of %s %s %s limit for %s", userID, period, code, kind, from.Format(time.RFC3339))
+	}
+	return s.settleLimit(l, now)
+}
+
+// limitsInForce returns the user's limits of the kind in the currency as they stand now. A
+// transaction counting towards them carries them in its balance update, and the repository checks
+// them against the locked wallet, so that concurrent transactions cannot overshoot a limit.
+func (s *TransactionService) limitsInForce(userID uint64, kind limit.Kind, code string) ([]limit.Limit, error) {
+	if s.limitRepo == nil {
+		return nil, nil
+	}
+	limits, err := s.limitRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var inForce []limit.Limit
+	for _, l := range limits {
+		if l.Kind == kind && l.Currency == code && l.Settle(now) {
+			inForce = append(inForce, l)
+		}
+	}
+	return inForce, nil
+}
+
+// findLimit returns the user's limit of the kind for the currency and period as it stands at now,
+// or nil if there is none.
+func (s *TransactionService) findLimit(userID uint64, kind limit.Kind, code string, period limit.Period, now time.Time) (*limit.Limit, error) {
+	l, err := s.limitRepo.Get(userID, kind, code, period)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s limit: %w", kind, err)
+	}
+	return s.settleLimit(l, now)
+}
+
+// settleLimit persists a pending change whose cooling-off period has passed at now. It returns
+// nil if the change removed the limit.
+func (s *TransactionService) settleLimit(l *limit.Limit, now time.Time) (*limit.Limit, error) {
+	if !l.Pending() || now.Before(*l.PendingFrom) {
+		return l, nil
+	}
+	if !l.Settle(now) {
+		if err := s.limitRepo.Delete(l.ID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if err := s.limitRepo.Save(l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
@@ -0,0 +1,242 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// limitRepo returns a limit repository that keeps limits in memory, keyed by period.
+func limitRepo(limits map[limit.Period]*limit.Limit) *mocks.MockLimitRepository {
+	mockLimitRepo := &mocks.MockLimitRepository{}
+	mockLimitRepo.ListByUserFunc = func(userID uint64) ([]limit.Limit, error) {
+		var list []limit.Limit
+		for _, p := range limit.Periods {
+			if l := limits[p]; l != nil {
+				list = append(list, *l)
+			}
+		}
+		return list, nil
+	}
+	mockLimitRepo.GetFunc = func(userID uint64, kind limit.Kind, code string, period limit.Period) (*limit.Limit, error) {
+		if l := limits[period]; l != nil && l.Kind == kind && l.Currency == code {
+			stored := *l
+			return &stored, nil
+		}
+		return nil, sql.ErrNoRows
+	}
+	mockLimitRepo.SaveFunc = func(l *limit.Limit) error {
+		stored := *l
+		limits[l.Period] = &stored
+		return nil
+	}
+	mockLimitRepo.DeleteFunc = func(id uint64) error {
+		for p, l := range limits {
+			if l.ID == id {
+				delete(limits, p)
+			}
+		}
+		return nil
+	}
+	return mockLimitRepo
+}
+
+func TestTransactionService_ProcessTransaction_LossLimit(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	limits := map[limit.Period]*limit.Limit{
+		limit.PeriodDaily: {ID: 1, UserID: 1, Kind: limit.KindLoss, Currency: "EUR", Period: limit.PeriodDaily, Amount: decimal.NewFromInt(50)},
+		limit.PeriodWeekly: {ID: 2, UserID: 1, Kind: limit.KindLoss, Currency: "EUR", Period: limit.PeriodWeekly, Amount: decimal.NewFromInt(100),
+			PendingAmount: decimal.NewNullDecimal(decimal.NewFromInt(150)), PendingFrom: &past},
+	}
+	svc, repos := newTestService(withOptions(services.WithLimits(limitRepo(limits), time.Hour)))
+
+	repos.users.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: id, Currency: code, Balance: decimal.NewFromInt(500)}, nil
+	}
+	// The repository checks the limits against the locked wallet; here 20 EUR were lost in the last
+	// day and 70 EUR in the last week.
+	var checked []limit.Limit
+	repos.users.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		checked = update.Limits
+		for _, l := range update.Limits {
+			lost := decimal.NewFromInt(20)
+			if l.Period == limit.PeriodWeekly {
+				lost = decimal.NewFromInt(70)
+			}
+			if err := l.Check(lost, update.Transaction.Amount); err != nil {
+				return decimal.Decimal{}, appErrors.NewLimitExceededError(err.Error())
+			}
+		}
+		return decimal.NewFromInt(500).Add(update.Delta), nil
+	}
+
+	_, err := svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "txn-1", SourceType: "game", State: "lose", Amount: decimal.NewFromInt(30)})
+	assert.NoError(t, err)
+	if assert.Len(t, checked, 2) {
+		assert.True(t, checked[1].Amount.Equal(decimal.NewFromInt(150)), "a due raise applies to the stake")
+	}
+
+	_, err = svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "txn-2", SourceType: "game", State: "lose", Amount: decimal.RequireFromString("30.01")})
+	assert.True(t, appErrors.IsLimitExceededError(err))
+	assert.EqualError(t, err, "daily loss limit of 50.00 EUR exceeded: 20.00 EUR lost in the last 24 hours")
+
+	limits[limit.PeriodDaily].Amount = decimal.NewFromInt(1000)
+	_, err = svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "txn-3", SourceType: "game", State: "lose", Amount: decimal.NewFromInt(81)})
+	assert.EqualError(t, err, "weekly loss limit of 150.00 EUR exceeded: 70.00 EUR lost in the last 7 days")
+
+	// Limits apply to losses in their own currency only, and never to wins.
+	_, err = svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "txn-4", SourceType: "game", State: "lose", Currency: "USD", Amount: decimal.NewFromInt(31)})
+	assert.NoError(t, err)
+	assert.Empty(t, checked)
+	_, err = svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "txn-5", SourceType: "game", State: "win", Amount: decimal.NewFromInt(31)})
+	assert.NoError(t, err)
+	assert.Empty(t, checked)
+}
+
+func TestTransactionService_ProcessTransaction_DepositLimit(t *testing.T) {
+	limits := map[limit.Period]*limit.Limit{
+		limit.PeriodDaily:  {ID: 1, UserID: 1, Kind: limit.KindDeposit, Currency: "EUR", Period: limit.PeriodDaily, Amount: decimal.NewFromInt(100)},
+		limit.PeriodWeekly: {ID: 2, UserID: 1, Kind: limit.KindLoss, Currency: "EUR", Period: limit.PeriodWeekly, Amount: decimal.NewFromInt(50)},
+	}
+	svc, repos := newTestService(withOptions(services.WithLimits(limitRepo(limits), time.Hour)))
+
+	repos.users.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: id, Currency: code, Balance: decimal.NewFromInt(500)}, nil
+	}
+	// The repository checks the limits against the locked wallet; here 80 EUR were deposited in the last day.
+	var checked []limit.Limit
+	repos.users.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		checked = update.Limits
+		for _, l := range update.Limits {
+			if err := l.Check(decimal.NewFromInt(80), update.Transaction.Amount); err != nil {
+				return decimal.Decimal{}, appErrors.NewLimitExceededError(err.Error())
+			}
+		}
+		return decimal.NewFromInt(500).Add(update.Delta), nil
+	}
+
+	_, err := svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "dep-1", SourceType: "payment", State: "deposit", Amount: decimal.NewFromInt(20)})
+	assert.NoError(t, err)
+	if assert.Len(t, checked, 1) {
+		assert.Equal(t, limit.KindDeposit, checked[0].Kind, "deposits are subject to deposit limits only")
+	}
+
+	_, err = svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "dep-2", SourceType: "payment", State: "deposit", Amount: decimal.RequireFromString("20.01")})
+	assert.True(t, appErrors.IsLimitExceededError(err))
+	assert.EqualError(t, err, "daily deposit limit of 100.00 EUR exceeded: 80.00 EUR deposited in the last 24 hours")
+
+	// Withdrawals count towards neither kind.
+	_, err = svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "wd-1", SourceType: "payment", State: "withdrawal", Amount: decimal.NewFromInt(20)})
+	assert.NoError(t, err)
+	assert.Empty(t, checked)
+}
+
+func TestTransactionService_SetLimit_DepositCooldown(t *testing.T) {
+	limits := map[limit.Period]*limit.Limit{}
+	svc, _ := newTestService(withOptions(services.WithLimits(limitRepo(limits), time.Hour)))
+
+	l, err := svc.SetLimit(1, limit.KindDeposit, "", limit.PeriodWeekly, decimal.NewFromInt(100))
+	assert.NoError(t, err)
+	assert.Equal(t, limit.KindDeposit, l.Kind)
+	assert.True(t, l.Amount.Equal(decimal.NewFromInt(100)), "a new limit applies immediately")
+
+	l, err = svc.SetLimit(1, limit.KindDeposit, "", limit.PeriodWeekly, decimal.NewFromInt(300))
+	assert.NoError(t, err)
+	assert.True(t, l.Amount.Equal(decimal.NewFromInt(100)), "a raise waits for the cooldown")
+	assert.True(t, l.PendingAmount.Decimal.Equal(decimal.NewFromInt(300)))
+
+	l, err = svc.SetLimit(1, limit.KindDeposit, "", limit.PeriodWeekly, decimal.NewFromInt(50))
+	assert.NoError(t, err)
+	assert.True(t, l.Amount.Equal(decimal.NewFromInt(50)), "lowering applies immediately")
+	assert.False(t, l.Pending())
+
+	_, err = svc.RemoveLimit(1, limit.KindLoss, "", limit.PeriodWeekly)
+	assert.True(t, appErrors.IsNotFoundError(err), "limits of different kinds are separate")
+	_, err = svc.SetLimit(1, limit.Kind("wager"), "", limit.PeriodWeekly, decimal.NewFromInt(50))
+	assert.True(t, appErrors.IsValidationError(err))
+}
+
+func TestTransactionService_SetLimit_Cooldown(t *testing.T) {
+	limits := map[limit.Period]*limit.Limit{}
+	svc, _ := newTestService(withOptions(services.WithLimits(limitRepo(limits), time.Hour)))
+
+	l, err := svc.SetLimit(1, limit.KindLoss, "", limit.PeriodDaily, decimal.NewFromInt(100))
+	assert.NoError(t, err)
+	assert.True(t, l.Amount.Equal(decimal.NewFromInt(100)), "a new limit applies immediately")
+	assert.False(t, l.Pending())
+
+	l, err = svc.SetLimit(1, limit.KindLoss, "", limit.PeriodDaily, decimal.NewFromInt(200))
+	assert.NoError(t, err)
+	assert.True(t, l.Amount.Equal(decimal.NewFromInt(100)), "a raise waits for the cooldown")
+	assert.True(t, l.PendingAmount.Decimal.Equal(decimal.NewFromInt(200)))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *l.PendingFrom, 5*time.Second)
+
+	// Asking for the same raise again keeps the original cooldown.
+	pendingFrom := *l.PendingFrom
+	l, err = svc.SetLimit(1, limit.KindLoss, "", limit.PeriodDaily, decimal.NewFromInt(200))
+	assert.NoError(t, err)
+	assert.Equal(t, pendingFrom, *l.PendingFrom)
+
+	l, err = svc.SetLimit(1, limit.KindLoss, "", limit.PeriodDaily, decimal.NewFromInt(80))
+	assert.NoError(t, err)
+	assert.True(t, l.Amount.Equal(decimal.NewFromInt(80)), "lowering applies immediately")
+	assert.False(t, l.Pending(), "lowering cancels the scheduled raise")
+
+	_, err = svc.SetLimit(1, limit.KindLoss, "", limit.Period("yearly"), decimal.NewFromInt(80))
+	assert.True(t, appErrors.IsValidationError(err))
+	_, err = svc.SetLimit(1, limit.KindLoss, "", limit.PeriodDaily, decimal.Zero)
+	assert.True(t, appErrors.IsValidationError(err))
+}
+
+func TestTransactionService_GetLimits_AppliesDueChanges(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	limits := map[limit.Period]*limit.Limit{
+		limit.PeriodDaily: {ID: 1, UserID: 1, Kind: limit.KindLoss, Currency: "EUR", Period: limit.PeriodDaily, Amount: decimal.NewFromInt(50),
+			PendingAmount: decimal.NewNullDecimal(decimal.NewFromInt(75)), PendingFrom: &past},
+		limit.PeriodWeekly: {ID: 2, UserID: 1, Kind: limit.KindLoss, Currency: "EUR", Period: limit.PeriodWeekly, Amount: decimal.NewFromInt(100),
+			PendingRemoval: true, PendingFrom: &past},
+		limit.PeriodMonthly: {ID: 3, UserID: 1, Kind: limit.KindLoss, Currency: "EUR", Period: limit.PeriodMonthly, Amount: decimal.NewFromInt(300),
+			PendingAmount: decimal.NewNullDecimal(decimal.NewFromInt(400)), PendingFrom: &future},
+	}
+	svc, _ := newTestService(withOptions(services.WithLimits(limitRepo(limits), time.Hour)))
+
+	current, err := svc.GetLimits(1)
+	assert.NoError(t, err)
+	if assert.Len(t, current, 2) {
+		assert.Equal(t, limit.PeriodDaily, current[0].Period)
+		assert.True(t, current[0].Amount.Equal(decimal.NewFromInt(75)))
+		assert.False(t, current[0].Pending())
+		assert.Equal(t, limit.PeriodMonthly, current[1].Period)
+		assert.True(t, current[1].Amount.Equal(decimal.NewFromInt(300)))
+		assert.True(t, current[1].Pending())
+	}
+	assert.NotContains(t, limits, limit.PeriodWeekly, "a due removal deletes the limit")
+	assert.False(t, limits[limit.PeriodDaily].Pending(), "a due raise is persisted")
+}
+
+func TestTransactionService_RemoveLimit(t *testing.T) {
+	limits := map[limit.Period]*limit.Limit{
+		limit.PeriodDaily: {ID: 1, UserID: 1, Kind: limit.KindLoss, Currency: "EUR", Period: limit.PeriodDaily, Amount: decimal.NewFromInt(50)},
+	}
+	svc, _ := newTestService(withOptions(services.WithLimits(limitRepo(limits), time.Hour)))
+
+	l, err := svc.RemoveLimit(1, limit.KindLoss, "", limit.PeriodDaily)
+	assert.NoError(t, err)
+	if assert.NotNil(t, l) {
+		assert.True(t, l.PendingRemoval)
+		assert.True(t, l.Amount.Equal(decimal.NewFromInt(50)), "the limit stays in force during the cooldown")
+	}
+
+	_, err = svc.RemoveLimit(1, limit.KindLoss, "", limit.PeriodWeekly)
+	assert.True(t, appErrors.IsNotFoundError(err))
+}
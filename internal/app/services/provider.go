@@ -0,0 +1,210 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zaynkorai/enlabs/internal/domain/provider"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/transfer"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// DefaultProviderAuthWindow is how far the timestamp of a signed request may be from the server's clock.
+const DefaultProviderAuthWindow = 5 * time.Minute
+
+// WithProviders enables provider credentials managed through repo. Signed requests are accepted
+// only if their timestamp is within window of the server's clock, and only once.
+func WithProviders(repo provider.Repository, window time.Duration) Option {
+	return func(s *TransactionService) {
+		s.providerRepo = repo
+		s.providerAuthWindow = window
+	}
+}
+
+// SignedRequest is a request to authenticate and the signature headers it was sent with.
+type SignedRequest struct {
+	KeyID     string
+	Timestamp string // Unix time in seconds
+	Signature string // Hex-encoded HMAC-SHA256, see provider.Sign
+	Method    string
+	Path      string // Including the query string
+	Body      []byte
+}
+
+// AuthenticateProvider verifies the signature of a request and returns the provider whose key
+// signed it. Every failure is reported as the same kind of error, so that callers cannot tell an
+// unknown key from a wrong signature.
+func (s *TransactionService) AuthenticateProvider(req SignedRequest) (*provider.Provider, error) {
+	if s.providerRepo == nil {
+		return nil, appErrors.NewValidationError("provider authentication is not available")
+	}
+	if req.KeyID == "" || req.Timestamp == "" || req.Signature == "" {
+		return nil, appErrors.NewUnauthorizedError("missing signature headers")
+	}
+	unix, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return nil, appErrors.NewUnauthorizedError("invalid request timestamp")
+	}
+	if skew := time.Since(time.Unix(unix, 0)); skew > s.providerAuthWindow || skew < -s.providerAuthWindow {
+		return nil, appErrors.NewUnauthorizedError(fmt.Sprintf("request timestamp is more than %s away from the server time", s.providerAuthWindow))
+	}
+
+	key, err := s.providerRepo.GetKey(req.KeyID)
+	if err == sql.ErrNoRows {
+		return nil, appErrors.NewUnauthorizedError("invalid signature")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider key %s: %w", req.KeyID, err)
+	}
+	if !key.Verify(req.Method, req.Path, req.Timestamp, req.Body, req.Signature) {
+		return nil, appErrors.NewUnauthorizedError("invalid signature")
+	}
+
+	p, err := s.providerRepo.Get(key.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider %s: %w", key.Provider, err)
+	}
+	if !p.Enabled {
+		return nil, appErrors.NewUnauthorizedError(fmt.Sprintf("provider %s is disabled", p.Name))
+	}
+
+	// A request is accepted while its timestamp is within the window on either side of the server's
+	// clock, so its signature has to be remembered for twice the window.
+	now := time.Now()
+	sig := &provider.Signature{KeyID: key.ID, Signature: strings.ToLower(req.Signature), SeenAt: now}
+	recorded, err := s.providerRepo.RecordSignature(sig, now.Add(-2*s.providerAuthWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record signature of key %s: %w", key.ID, err)
+	}
+	if !recorded {
+		return nil, appErrors.NewUnauthorizedError("request has already been received")
+	}
+	return p, nil
+}
+
+// ProviderServesUser reports whether the provider has a player mapped to the user or has sent
+// transactions for the user with one of its Source-Types.
+func (s *TransactionService) ProviderServesUser(p *provider.Provider, userID uint64) (bool, error) {
+	links, err := s.userRepo.ListExternalIDs(userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list external IDs of user %d: %w", userID, err)
+	}
+	for _, link := range links {
+		if link.Provider == p.Name {
+			return true, nil
+		}
+	}
+	if len(p.SourceTypes) == 0 {
+		return false, nil
+	}
+	transactions, err := s.transactionRepo.ListByUser(userID, transaction.ListFilter{SourceTypes: p.SourceTypes, Limit: 1})
+	if err != nil {
+		return false, fmt.Errorf("failed to list transactions of user %d: %w", userID, err)
+	}
+	return len(transactions) > 0, nil
+}
+
+// ListProviders returns every provider with its settings.
+func (s *TransactionService) ListProviders() ([]provider.Provider, error) {
+	if s.providerRepo == nil {
+		return nil, appErrors.NewValidationError("provider authentication is not available")
+	}
+	providers, err := s.providerRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list providers: %w", err)
+	}
+	return providers, nil
+}
+
+// GetProvider returns the settings of a provider and its active keys, oldest first. The keys'
+// secrets are not part of the result.
+func (s *TransactionService) GetProvider(name string) (*provider.Provider, []provider.Key, error) {
+	if s.providerRepo == nil {
+		return nil, nil, appErrors.NewValidationError("provider authentication is not available")
+	}
+	p, err := s.providerRepo.Get(name)
+	if err == sql.ErrNoRows {
+		return nil, nil, appErrors.NewNotFoundError(fmt.Sprintf("provider %s not found", name))
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get provider %s: %w", name, err)
+	}
+	keys, err := s.providerRepo.ListKeys(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list keys of provider %s: %w", name, err)
+	}
+	for i := range keys {
+		keys[i].Secret = ""
+	}
+	return p, keys, nil
+}
+
+// SaveProvider creates a provider or replaces the settings of an existing one. Every Source-Type
+// the provider is bound to must be registered.
+func (s *TransactionService) SaveProvider(p *provider.Provider) error {
+	if s.providerRepo == nil {
+		return appErrors.NewValidationError("provider authentication is not available")
+	}
+	if err := p.Validate(); err != nil {
+		return appErrors.NewValidationError(err.Error())
+	}
+	for _, sourceType := range p.SourceTypes {
+		if sourceType == transfer.SourceType {
+			return appErrors.NewValidationError(fmt.Sprintf("Source-Type %s is reserved for transfers", transfer.SourceType))
+		}
+		if _, err := s.GetSource(sourceType); err != nil {
+			if appErrors.IsNotFoundError(err) {
+				return appErrors.NewValidationError(fmt.Sprintf("unknown Source-Type %q", sourceType))
+			}
+			return err
+		}
+	}
+	if err := s.providerRepo.Save(p); err != nil {
+		return fmt.Errorf("failed to save provider %s: %w", p.Name, err)
+	}
+
+	log.Printf("Provider %s saved: enabled %t, Source-Types %v", p.Name, p.Enabled, p.SourceTypes)
+	return nil
+}
+
+// RotateProviderKey creates a new signing key for the provider and returns it with its secret,
+// which is not shown again. The key it replaces stays valid until the next rotation or until it is
+// revoked, so that the provider can switch keys without rejected requests; older keys are retired.
+func (s *TransactionService) RotateProviderKey(name string) (*provider.Key, error) {
+	if _, _, err := s.GetProvider(name); err != nil {
+		return nil, err
+	}
+	key, err := provider.NewKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.providerRepo.AddKey(key, provider.MaxActiveKeys); err != nil {
+		return nil, fmt.Errorf("failed to add key of provider %s: %w", name, err)
+	}
+
+	log.Printf("Provider %s rotated to key %s", name, key.ID)
+	return key, nil
+}
+
+// RevokeProviderKey deletes a key of the provider, e.g. once the provider signs with its new key
+// or if the key leaked.
+func (s *TransactionService) RevokeProviderKey(name, keyID string) error {
+	if s.providerRepo == nil {
+		return appErrors.NewValidationError("provider authentication is not available")
+	}
+	err := s.providerRepo.DeleteKey(name, keyID)
+	if err == sql.ErrNoRows {
+		return appErrors.NewNotFoundError(fmt.Sprintf("key %s of provider %s not found", keyID, name))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete key %s of provider %s: %w", keyID, name, err)
+	}
+
+	log.Printf("Provider %s key %s revoked", name, keyID)
+	return nil
+}
@@ -0,0 +1,231 @@
+package services_test
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/provider"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// providerRepo returns a provider repository that has stored the given providers and keys.
+func providerRepo(providers []provider.Provider, keys ...provider.Key) *mocks.MockProviderRepository {
+	mockProviderRepo := &mocks.MockProviderRepository{}
+	mockProviderRepo.GetFunc = func(name string) (*provider.Provider, error) {
+		for _, p := range providers {
+			if p.Name == name {
+				return &p, nil
+			}
+		}
+		return nil, sql.ErrNoRows
+	}
+	mockProviderRepo.GetKeyFunc = func(id string) (*provider.Key, error) {
+		for _, key := range keys {
+			if key.ID == id {
+				return &key, nil
+			}
+		}
+		return nil, sql.ErrNoRows
+	}
+	mockProviderRepo.ListKeysFunc = func(name string) ([]provider.Key, error) {
+		var own []provider.Key
+		for _, key := range keys {
+			if key.Provider == name {
+				own = append(own, key)
+			}
+		}
+		return own, nil
+	}
+	seen := map[provider.Signature]bool{}
+	mockProviderRepo.RecordSignatureFunc = func(sig *provider.Signature, expired time.Time) (bool, error) {
+		entry := provider.Signature{KeyID: sig.KeyID, Signature: sig.Signature}
+		if seen[entry] {
+			return false, nil
+		}
+		seen[entry] = true
+		return true, nil
+	}
+	return mockProviderRepo
+}
+
+// signedRequest returns a transaction request signed with the key at the given time.
+func signedRequest(key provider.Key, at time.Time) services.SignedRequest {
+	body := []byte(`{"state":"win","amount":"1.00","transactionId":"t-1"}`)
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return services.SignedRequest{
+		KeyID:     key.ID,
+		Timestamp: timestamp,
+		Signature: provider.Sign(key.Secret, "POST", "/user/1/transaction", timestamp, body),
+		Method:    "POST",
+		Path:      "/user/1/transaction",
+		Body:      body,
+	}
+}
+
+func TestTransactionService_AuthenticateProvider(t *testing.T) {
+	providers := []provider.Provider{
+		{Name: "acme", Enabled: true, SourceTypes: provider.Names{"game"}},
+		{Name: "retired", SourceTypes: provider.Names{"game"}},
+	}
+	previous := provider.Key{ID: "pk_previous", Provider: "acme", Secret: "previous-secret"}
+	current := provider.Key{ID: "pk_current", Provider: "acme", Secret: "current-secret"}
+	retired := provider.Key{ID: "pk_retired", Provider: "retired", Secret: "retired-secret"}
+	now := time.Now()
+
+	tampered := signedRequest(current, now)
+	tampered.Body = []byte(`{"state":"win","amount":"1000.00","transactionId":"t-1"}`)
+	wrongKey := signedRequest(current, now)
+	wrongKey.KeyID = previous.ID
+	unknownKey := signedRequest(provider.Key{ID: "pk_unknown", Secret: "current-secret"}, now)
+	unsigned := signedRequest(current, now)
+	unsigned.Signature = ""
+
+	tests := []struct {
+		name string
+		req  services.SignedRequest
+		ok   bool
+	}{
+		{"current key", signedRequest(current, now), true},
+		{"previous key during rotation", signedRequest(previous, now), true},
+		{"clock skew within the window", signedRequest(current, now.Add(30*time.Second)), true},
+		{"expired timestamp", signedRequest(current, now.Add(-2*time.Minute)), false},
+		{"future timestamp", signedRequest(current, now.Add(2*time.Minute)), false},
+		{"tampered body", tampered, false},
+		{"signature of another key", wrongKey, false},
+		{"unknown key", unknownKey, false},
+		{"missing signature", unsigned, false},
+		{"disabled provider", signedRequest(retired, now), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(withOptions(services.WithProviders(providerRepo(providers, previous, current, retired), time.Minute)))
+			p, err := svc.AuthenticateProvider(tt.req)
+			if tt.ok {
+				assert.NoError(t, err)
+				assert.Equal(t, "acme", p.Name)
+			} else {
+				assert.True(t, appErrors.IsUnauthorizedError(err), "got %v", err)
+			}
+		})
+	}
+}
+
+func TestTransactionService_AuthenticateProvider_Replay(t *testing.T) {
+	providers := []provider.Provider{{Name: "acme", Enabled: true, SourceTypes: provider.Names{"game"}}}
+	current := provider.Key{ID: "pk_current", Provider: "acme", Secret: "current-secret"}
+	svc, _ := newTestService(withOptions(services.WithProviders(providerRepo(providers, current), time.Minute)))
+	now := time.Now()
+
+	req := signedRequest(current, now)
+	_, err := svc.AuthenticateProvider(req)
+	assert.NoError(t, err)
+
+	_, err = svc.AuthenticateProvider(req)
+	assert.True(t, appErrors.IsUnauthorizedError(err), "a repeated request is rejected, got %v", err)
+	upper := req
+	upper.Signature = strings.ToUpper(req.Signature)
+	_, err = svc.AuthenticateProvider(upper)
+	assert.True(t, appErrors.IsUnauthorizedError(err), "a repeated request is rejected in any case, got %v", err)
+
+	_, err = svc.AuthenticateProvider(signedRequest(current, now.Add(time.Second)))
+	assert.NoError(t, err, "a retry signed with a new timestamp is accepted")
+}
+
+func TestTransactionService_ProviderServesUser(t *testing.T) {
+	acme := &provider.Provider{Name: "acme", Enabled: true, SourceTypes: provider.Names{"game"}}
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	mockUserRepo.ListExternalIDsFunc = func(userID uint64) ([]user.ExternalID, error) {
+		if userID == 1 {
+			return []user.ExternalID{{UserID: 1, Provider: "acme", ExternalID: "p-1"}}, nil
+		}
+		return []user.ExternalID{{UserID: userID, Provider: "other", ExternalID: "p-1"}}, nil
+	}
+	mockTransactionRepo.ListByUserFunc = func(userID uint64, filter transaction.ListFilter) ([]transaction.Transaction, error) {
+		assert.Equal(t, []string{"game"}, filter.SourceTypes)
+		if userID == 2 {
+			return []transaction.Transaction{{UserID: 2, SourceType: "game"}}, nil
+		}
+		return nil, nil
+	}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	for userID, want := range map[uint64]bool{1: true, 2: true, 3: false} {
+		serves, err := svc.ProviderServesUser(acme, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, want, serves, "user %d", userID)
+	}
+}
+
+func TestTransactionService_SaveProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider provider.Provider
+		valid    bool
+	}{
+		{"built-in Source-Types", provider.Provider{Name: "acme", Enabled: true, SourceTypes: provider.Names{"game", "payment"}}, true},
+		{"no Source-Types", provider.Provider{Name: "acme"}, true},
+		{"invalid name", provider.Provider{Name: "Acme Games", SourceTypes: provider.Names{"game"}}, false},
+		{"unknown Source-Type", provider.Provider{Name: "acme", SourceTypes: provider.Names{"lottery"}}, false},
+		{"transfer Source-Type", provider.Provider{Name: "acme", SourceTypes: provider.Names{"transfer"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProviderRepo := providerRepo(nil)
+			svc, _ := newTestService(withOptions(services.WithProviders(mockProviderRepo, time.Minute)))
+			saved := false
+			mockProviderRepo.SaveFunc = func(p *provider.Provider) error {
+				saved = true
+				return nil
+			}
+
+			err := svc.SaveProvider(&tt.provider)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, appErrors.IsValidationError(err), "got %v", err)
+			}
+			assert.Equal(t, tt.valid, saved)
+		})
+	}
+}
+
+func TestTransactionService_RotateProviderKey(t *testing.T) {
+	mockProviderRepo := providerRepo([]provider.Provider{{Name: "acme", Enabled: true}})
+	svc, _ := newTestService(withOptions(services.WithProviders(mockProviderRepo, time.Minute)))
+	var added *provider.Key
+	mockProviderRepo.AddKeyFunc = func(key *provider.Key, keep int) error {
+		added = key
+		assert.Equal(t, provider.MaxActiveKeys, keep)
+		return nil
+	}
+
+	key, err := svc.RotateProviderKey("acme")
+	assert.NoError(t, err)
+	assert.Same(t, added, key)
+	assert.Equal(t, "acme", key.Provider)
+	assert.Len(t, key.Secret, 64)
+
+	_, err = svc.RotateProviderKey("unknown")
+	assert.True(t, appErrors.IsNotFoundError(err), "got %v", err)
+}
+
+func TestTransactionService_GetProviderHidesSecrets(t *testing.T) {
+	svc, _ := newTestService(withOptions(services.WithProviders(providerRepo([]provider.Provider{{Name: "acme", Enabled: true}},
+		provider.Key{ID: "pk_current", Provider: "acme", Secret: "current-secret"}), time.Minute)))
+
+	_, keys, err := svc.GetProvider("acme")
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 1) {
+		assert.Equal(t, "pk_current", keys[0].ID)
+		assert.Empty(t, keys[0].Secret)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// reversalIDPrefix is used to derive the idempotency key of a reversal when the caller does not supply one.
+const reversalIDPrefix = "reversal:"
+
+// ReversalRequest describes a rollback of a previously processed transaction.
+type ReversalRequest struct {
+	// SourceType and OriginalTransactionID identify the transaction to reverse.
+	SourceType            string
+	OriginalTransactionID string
+	// TransactionID is the idempotency key of the reversal itself. When empty, it is derived from
+	// the original transaction so that repeating the request is still idempotent.
+	TransactionID string
+	// UserID, when non-zero, requires the original transaction to belong to this user.
+	UserID uint64
+	// Amount, when set, must match the original amount as it was sent, before any currency
+	// conversion; only full reversals are supported.
+	Amount *decimal.Decimal
+	// Force allows the reversal even if it drives the balance negative. Reversals from sources
+	// without the balance check are always forced.
+	Force bool
+}
+
+// ReverseTransaction records a compensating "cancel" entry linked to the original transaction and
+// applies the inverse balance change atomically. A transaction can be reversed at most once;
+// repeating the same reversal replays the stored result.
+func (s *TransactionService) ReverseTransaction(req ReversalRequest) (*ProcessResult, error) {
+	original, err := s.GetTransaction(req.SourceType, req.OriginalTransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserID != 0 && original.UserID != req.UserID {
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("transaction with ID %s not found for user %d", req.OriginalTransactionID, req.UserID))
+	}
+
+	reversalID := req.TransactionID
+	if reversalID == "" {
+		reversalID = reversalIDPrefix + original.TransactionID
+	}
+	reversal := &transaction.Transaction{
+		UserID:        original.UserID,
+		TransactionID: reversalID,
+		SourceType:    original.SourceType,
+		State:         transaction.TypeCancel,
+		Currency:      original.Currency,
+		Amount:        original.Amount,
+		ReversesID:    &original.ID,
+		Bucket:        original.Bucket,
+		RoundID:       original.RoundID,
+		GameID:        original.GameID,
+		// A converted transaction is reversed at the rate it was booked with.
+		OriginalAmount:   original.OriginalAmount,
+		OriginalCurrency: original.OriginalCurrency,
+		ExchangeRate:     original.ExchangeRate,
+		RateTimestamp:    original.RateTimestamp,
+	}
+	reversal.RequestHash = reversal.Fingerprint()
+
+	existing, err := s.findProcessedTransaction(reversal.SourceType, reversal.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return s.replay(existing, reversal)
+	}
+	src, err := s.checkSource(reversal)
+	if err != nil {
+		return nil, err
+	}
+	force := req.Force || !src.CheckBalance
+
+	info, ok := transaction.LookupType(original.State)
+	if !ok || info.Internal {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("transactions in state %q cannot be reversed", original.State))
+	}
+	if info.Direction == transaction.DirectionReversal {
+		return nil, appErrors.NewValidationError("a reversal cannot itself be reversed")
+	}
+	if amount, code := original.RequestedAmount(); req.Amount != nil && !req.Amount.Equal(amount) {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("reversal amount %s does not match original amount %s %s",
+			req.Amount.String(), currency.Format(code, amount), code))
+	}
+
+	delta := original.Amount.Mul(decimal.NewFromInt(-int64(info.Direction.Sign())))
+	u, err := s.getUser(original.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatus(u, delta.IsNegative()); err != nil {
+		return nil, err
+	}
+
+	// The reversal moves exactly what the original moved on each sub-balance.
+	allocation := user.Allocation{Bonus: decimal.NewNullDecimal(original.BonusAmount)}
+	return s.applyBalanceChange(original.UserID, reversal, func(w *user.Wallet) (user.BalanceUpdate, error) {
+		if !force {
+			if w.Spendable().Add(delta).IsNegative() {
+				return user.BalanceUpdate{}, appErrors.NewInsufficientBalanceError(fmt.Sprintf("insufficient balance: available balance remains %s %s, reversal requires force",
+					currency.Format(w.Currency, w.Available()), w.Currency))
+			}
+			if overdrawn := w.Overdrawn(delta, w.BonusDelta(delta, allocation)); overdrawn != "" {
+				return user.BalanceUpdate{}, w.InsufficientBucketError(overdrawn)
+			}
+		}
+		return user.BalanceUpdate{Delta: delta, Allocation: allocation, AllowNegative: force}, nil
+	})
+}
@@ -0,0 +1,211 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+func newReversalMocks(original *transaction.Transaction, balance decimal.Decimal) (*mocks.MockUserRepository, *mocks.MockTransactionRepository) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		if transactionID == original.TransactionID {
+			return original, nil
+		}
+		return nil, sql.ErrNoRows
+	}
+	mockUserRepo.GetByIDFunc = activeUser
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: id, Currency: code, Balance: balance}, nil
+	}
+	return mockUserRepo, mockTransactionRepo
+}
+
+func TestTransactionService_ReverseTransaction_Win(t *testing.T) {
+	original := &transaction.Transaction{ID: 10, UserID: 1, TransactionID: "txn-win", SourceType: "game", State: "win", Amount: decimal.NewFromFloat(15.00)}
+	mockUserRepo, mockTransactionRepo := newReversalMocks(original, decimal.NewFromFloat(20.00))
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		assert.Equal(t, uint64(1), uid)
+		assert.True(t, update.Delta.Equal(decimal.NewFromFloat(-15.00)))
+		assert.False(t, update.AllowNegative)
+		assert.Equal(t, transaction.TypeCancel, update.Transaction.State)
+		assert.Equal(t, "reversal:txn-win", update.Transaction.TransactionID)
+		assert.Equal(t, "game", update.Transaction.SourceType)
+		assert.Equal(t, uint64(10), *update.Transaction.ReversesID)
+		return decimal.NewFromFloat(5.00), nil
+	}
+
+	result, err := svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-win"})
+	assert.NoError(t, err)
+	assert.False(t, result.IdempotentReplay)
+}
+
+func TestTransactionService_ReverseTransaction_Lose(t *testing.T) {
+	original := &transaction.Transaction{ID: 11, UserID: 1, TransactionID: "txn-lose", SourceType: "game", State: "lose", Amount: decimal.NewFromFloat(4.00)}
+	mockUserRepo, mockTransactionRepo := newReversalMocks(original, decimal.Zero)
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		assert.True(t, update.Delta.Equal(decimal.NewFromFloat(4.00)))
+		assert.Equal(t, "round-void-1", update.Transaction.TransactionID)
+		return decimal.NewFromFloat(4.00), nil
+	}
+
+	_, err := svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-lose", TransactionID: "round-void-1"})
+	assert.NoError(t, err)
+}
+
+func TestTransactionService_ReverseTransaction_Fee(t *testing.T) {
+	original := &transaction.Transaction{ID: 13, UserID: 1, TransactionID: "txn-fee", SourceType: "payment", State: transaction.TypeFee, Amount: decimal.NewFromFloat(1.50)}
+	mockUserRepo, mockTransactionRepo := newReversalMocks(original, decimal.Zero)
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		assert.True(t, update.Delta.Equal(decimal.NewFromFloat(1.50)), "a reversed debit is credited back")
+		return decimal.NewFromFloat(1.50), nil
+	}
+
+	_, err := svc.ReverseTransaction(services.ReversalRequest{SourceType: "payment", OriginalTransactionID: "txn-fee"})
+	assert.NoError(t, err)
+}
+
+func TestTransactionService_ReverseTransaction_WouldGoNegative(t *testing.T) {
+	original := &transaction.Transaction{ID: 12, UserID: 1, TransactionID: "txn-win", SourceType: "game", State: "win", Amount: decimal.NewFromFloat(15.00)}
+	mockUserRepo, mockTransactionRepo := newReversalMocks(original, decimal.NewFromFloat(10.00))
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	calls := 0
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		calls++
+		assert.True(t, update.AllowNegative)
+		return decimal.NewFromFloat(-5.00), nil
+	}
+
+	_, err := svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-win"})
+	assert.Error(t, err)
+	assert.True(t, appErrors.IsValidationError(err))
+	assert.Equal(t, 0, calls)
+
+	_, err = svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-win", Force: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestTransactionService_ReverseTransaction_Replay(t *testing.T) {
+	original := &transaction.Transaction{ID: 13, UserID: 1, TransactionID: "txn-win", SourceType: "game", State: "win", Amount: decimal.NewFromFloat(15.00)}
+	stored := &transaction.Transaction{ID: 14, UserID: 1, TransactionID: "reversal:txn-win", SourceType: "game", State: "cancel", Amount: decimal.NewFromFloat(15.00), ReversesID: &original.ID}
+
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		switch transactionID {
+		case original.TransactionID:
+			return original, nil
+		case stored.TransactionID:
+			return stored, nil
+		}
+		return nil, sql.ErrNoRows
+	}
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		t.Fatal("AtomicUpdateBalanceAndCreateTransactionFunc should not be called for a replayed reversal")
+		return decimal.Decimal{}, nil
+	}
+
+	result, err := svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-win"})
+	assert.NoError(t, err)
+	assert.True(t, result.IdempotentReplay)
+	assert.Equal(t, uint64(14), result.Transaction.ID)
+}
+
+func TestTransactionService_ReverseTransaction_AlreadyReversed(t *testing.T) {
+	original := &transaction.Transaction{ID: 15, UserID: 1, TransactionID: "txn-win", SourceType: "game", State: "win", Amount: decimal.NewFromFloat(1.00)}
+	mockUserRepo, mockTransactionRepo := newReversalMocks(original, decimal.NewFromFloat(10.00))
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		return decimal.Decimal{}, appErrors.NewConflictError("transaction has already been reversed")
+	}
+
+	_, err := svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-win", TransactionID: "second-reversal"})
+	assert.Error(t, err)
+	assert.True(t, appErrors.IsConflictError(err))
+}
+
+func TestTransactionService_ReverseTransaction_Rejections(t *testing.T) {
+	cancelled := &transaction.Transaction{ID: 16, UserID: 1, TransactionID: "txn-cancel", SourceType: "game", State: "cancel", Amount: decimal.NewFromFloat(1.00)}
+	mockUserRepo, mockTransactionRepo := newReversalMocks(cancelled, decimal.NewFromFloat(10.00))
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	_, err := svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-cancel"})
+	assert.True(t, appErrors.IsValidationError(err))
+
+	_, err = svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-cancel", UserID: 2})
+	assert.True(t, appErrors.IsNotFoundError(err))
+
+	_, err = svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "missing"})
+	assert.True(t, appErrors.IsNotFoundError(err))
+
+	win := &transaction.Transaction{ID: 17, UserID: 1, TransactionID: "txn-win", SourceType: "game", State: "win", Amount: decimal.NewFromFloat(1.00)}
+	mockUserRepo, mockTransactionRepo = newReversalMocks(win, decimal.NewFromFloat(10.00))
+	svc = services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	partial := decimal.NewFromFloat(0.50)
+	_, err = svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-win", Amount: &partial})
+	assert.True(t, appErrors.IsValidationError(err))
+}
+
+func TestTransactionService_ReverseTransaction_ConvertedUsesBookedRate(t *testing.T) {
+	asOf := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	original := &transaction.Transaction{
+		ID: 20, UserID: 1, TransactionID: "txn-usd", SourceType: "game", State: "win", Currency: "EUR", Amount: decimal.NewFromFloat(9.14),
+		OriginalAmount: decimal.NewNullDecimal(decimal.NewFromInt(10)), OriginalCurrency: "USD",
+		ExchangeRate: decimal.NewNullDecimal(decimal.RequireFromString("0.9137")), RateTimestamp: &asOf,
+	}
+	mockUserRepo, mockTransactionRepo := newReversalMocks(original, decimal.NewFromFloat(20.00))
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		assert.Equal(t, "EUR", update.Currency)
+		assert.True(t, update.Delta.Equal(decimal.NewFromFloat(-9.14)))
+		assert.Equal(t, "USD", update.Transaction.OriginalCurrency)
+		assert.True(t, update.Transaction.ExchangeRate.Decimal.Equal(original.ExchangeRate.Decimal))
+		return decimal.NewFromFloat(10.86), nil
+	}
+
+	// The provider cancels with the amount it originally sent.
+	amount := decimal.NewFromInt(10)
+	_, err := svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-usd", Amount: &amount})
+	assert.NoError(t, err)
+
+	converted := decimal.NewFromFloat(9.14)
+	_, err = svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-usd", Amount: &converted})
+	assert.True(t, appErrors.IsValidationError(err))
+}
+
+func TestTransactionService_ReverseTransaction_UndoesBonusSplit(t *testing.T) {
+	original := &transaction.Transaction{ID: 21, UserID: 1, TransactionID: "txn-split", SourceType: "game", State: "lose", Amount: decimal.NewFromFloat(12.00), BonusAmount: decimal.NewFromFloat(4.00)}
+	mockUserRepo, mockTransactionRepo := newReversalMocks(original, decimal.NewFromFloat(8.00))
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		wallet := user.Wallet{Currency: "EUR", Balance: decimal.NewFromFloat(8.00)}
+		assert.True(t, wallet.BonusDelta(update.Delta, update.Allocation).Equal(decimal.NewFromFloat(4.00)))
+		return decimal.NewFromFloat(20.00), nil
+	}
+
+	_, err := svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-split"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/zaynkorai/enlabs/internal/domain/round"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// WithRounds enables tracking the game rounds transactions name. In strict mode, payouts for rounds
+// no stake was placed in and stakes or payouts for settled or cancelled rounds are rejected.
+func WithRounds(repo round.Repository, strict bool) Option {
+	return func(s *TransactionService) {
+		s.roundRepo = repo
+		s.strictRounds = strict
+	}
+}
+
+// RoundResult describes a round and the transactions booked on it.
+type RoundResult struct {
+	Round *round.Round
+	// Transactions are in the order they were applied.
+	Transactions []transaction.Transaction
+}
+
+// GetRound looks up a round by the provider's external round ID. sourceType may be empty, in
+// which case the ID must be unambiguous across sources.
+func (s *TransactionService) GetRound(sourceType, roundID string) (*RoundResult, error) {
+	if s.roundRepo == nil {
+		return nil, appErrors.NewValidationError("rounds are not available")
+	}
+	r, err := s.roundRepo.GetByRoundID(sourceType, roundID)
+	if err == sql.ErrNoRows {
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("round with ID %s not found", roundID))
+	}
+	if appErrors.IsConflictError(err) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get round: %w", err)
+	}
+
+	transactions, err := s.transactionRepo.ListByRound(r.SourceType, r.RoundID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions of round %s: %w", roundID, err)
+	}
+	return &RoundResult{Round: r, Transactions: transactions}, nil
+}
+
+type roundKey struct {
+	sourceType string
+	roundID    string
+}
+
+// checkRound checks that t can be booked on its round. rounds, if not nil, holds the rounds as the
+// earlier items of a batch leave them and is updated with the round as t leaves it. The repository
+// re-checks against the locked round.
+func (s *TransactionService) checkRound(t *transaction.Transaction, rounds map[roundKey]*round.Round) error {
+	if t.RoundID == "" {
+		return nil
+	}
+	key := roundKey{t.SourceType, t.RoundID}
+	current, ok := rounds[key]
+	if !ok {
+		var err error
+		current, err = s.roundRepo.GetByRoundID(t.SourceType, t.RoundID)
+		if err == sql.ErrNoRows {
+			current = nil
+		} else if err != nil {
+			return fmt.Errorf("failed to get round: %w", err)
+		}
+	}
+
+	next, err := round.Book(current, t, s.strictRounds)
+	if err != nil {
+		if errors.Is(err, round.ErrUnknownRound) {
+			return appErrors.NewValidationError(err.Error())
+		}
+		return appErrors.NewConflictError(err.Error())
+	}
+	if rounds != nil {
+		rounds[key] = next
+	}
+	return nil
+}
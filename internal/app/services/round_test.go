@@ -0,0 +1,165 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/round"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+func roundTransaction(state transaction.Type, transactionID, roundID string, amount int64) *transaction.Transaction {
+	return &transaction.Transaction{
+		TransactionID: transactionID,
+		SourceType:    "game",
+		State:         state,
+		Amount:        decimal.NewFromInt(amount),
+		RoundID:       roundID,
+	}
+}
+
+// roundRepo returns a round repository that has stored the given rounds.
+func roundRepo(rounds ...*round.Round) *mocks.MockRoundRepository {
+	mockRoundRepo := &mocks.MockRoundRepository{}
+	mockRoundRepo.GetByRoundIDFunc = func(sourceType, roundID string) (*round.Round, error) {
+		for _, r := range rounds {
+			if r.SourceType == sourceType && r.RoundID == roundID {
+				return r, nil
+			}
+		}
+		return nil, sql.ErrNoRows
+	}
+	return mockRoundRepo
+}
+
+func TestTransactionService_ProcessTransaction_Rounds(t *testing.T) {
+	open := &round.Round{ID: 1, RoundID: "open-1", SourceType: "game", UserID: 1, Currency: "EUR", Status: round.StatusOpen}
+	settled := &round.Round{ID: 2, RoundID: "settled-1", SourceType: "game", UserID: 1, Currency: "EUR", Status: round.StatusSettled}
+	foreign := &round.Round{ID: 3, RoundID: "foreign-1", SourceType: "game", UserID: 2, Currency: "EUR", Status: round.StatusOpen}
+
+	tests := []struct {
+		name   string
+		strict bool
+		t      *transaction.Transaction
+		check  func(error) bool
+	}{
+		{"stake opens a round", true, roundTransaction("lose", "t-1", "new-1", 5), nil},
+		{"payout settles an open round", true, roundTransaction("win", "t-2", "open-1", 5), nil},
+		{"strict payout for unknown round", true, roundTransaction("win", "t-3", "new-2", 5), appErrors.IsValidationError},
+		{"lenient payout for unknown round", false, roundTransaction("win", "t-4", "new-3", 5), nil},
+		{"strict payout for settled round", true, roundTransaction("win", "t-5", "settled-1", 5), appErrors.IsConflictError},
+		{"lenient payout for settled round", false, roundTransaction("win", "t-6", "settled-1", 5), nil},
+		{"round of another user", false, roundTransaction("lose", "t-7", "foreign-1", 5), appErrors.IsConflictError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(withOptions(services.WithRounds(roundRepo(open, settled, foreign), tt.strict)))
+			_, err := svc.ProcessTransaction(1, tt.t)
+			if tt.check == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, tt.check(err), "got %v", err)
+			}
+		})
+	}
+}
+
+func TestTransactionService_ProcessTransaction_RoundPassesStrictMode(t *testing.T) {
+	svc, repos := newTestService(withOptions(services.WithRounds(roundRepo(), true)))
+	repos.users.AtomicUpdateBalanceAndCreateTransactionFunc = func(userID uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		assert.True(t, update.StrictRounds, "the repository re-checks the round in strict mode")
+		assert.Equal(t, "r-1", update.Transaction.RoundID)
+		return decimal.NewFromInt(5), nil
+	}
+
+	_, err := svc.ProcessTransaction(1, roundTransaction("lose", "t-1", "r-1", 5))
+	assert.NoError(t, err)
+
+	_, err = svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "t-2", SourceType: "game", State: "win", Amount: decimal.NewFromInt(1), GameID: "slots"})
+	assert.True(t, appErrors.IsValidationError(err), "a gameId requires a roundId")
+}
+
+func TestTransactionService_ProcessBatch_AtomicRound(t *testing.T) {
+	svc, repos := newTestService(withOptions(services.WithRounds(roundRepo(), true)))
+	repos.users.ApplyBatchFunc = func(updates []user.BatchUpdate) error {
+		return nil
+	}
+
+	// The payout is accepted in strict mode because the stake earlier in the batch opens the round.
+	results, err := svc.ProcessBatch(services.BatchAtomic, []services.BatchItem{
+		{UserID: 1, Transaction: roundTransaction("lose", "b-1", "r-1", 5)},
+		{UserID: 1, Transaction: roundTransaction("win", "b-2", "r-1", 8)},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+
+	results, err = svc.ProcessBatch(services.BatchAtomic, []services.BatchItem{
+		{UserID: 1, Transaction: roundTransaction("lose", "b-3", "r-2", 5)},
+		{UserID: 1, Transaction: roundTransaction("win", "b-4", "r-2", 8)},
+		{UserID: 1, Transaction: roundTransaction("win", "b-5", "r-2", 8)},
+	})
+	assert.NoError(t, err)
+	assert.True(t, appErrors.IsConflictError(results[2].Err), "the round was settled by the previous item, got %v", results[2].Err)
+}
+
+func TestTransactionService_GetRound(t *testing.T) {
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	mockRoundRepo := &mocks.MockRoundRepository{}
+	stored := &round.Round{ID: 1, RoundID: "r-1", SourceType: "game", UserID: 1, Currency: "EUR", Status: round.StatusSettled}
+	mockRoundRepo.GetByRoundIDFunc = func(sourceType, roundID string) (*round.Round, error) {
+		if roundID == stored.RoundID && (sourceType == "" || sourceType == stored.SourceType) {
+			return stored, nil
+		}
+		return nil, sql.ErrNoRows
+	}
+	mockTransactionRepo.ListByRoundFunc = func(sourceType, roundID string) ([]transaction.Transaction, error) {
+		assert.Equal(t, "game", sourceType)
+		return []transaction.Transaction{{ID: 1, State: "lose"}, {ID: 2, State: "win"}}, nil
+	}
+	svc := services.NewTransactionService(&mocks.MockUserRepository{}, mockTransactionRepo, services.WithRounds(mockRoundRepo, false))
+
+	result, err := svc.GetRound("", "r-1")
+	assert.NoError(t, err)
+	assert.Same(t, stored, result.Round)
+	assert.Len(t, result.Transactions, 2)
+
+	_, err = svc.GetRound("payment", "r-1")
+	assert.True(t, appErrors.IsNotFoundError(err))
+
+	_, err = services.NewTransactionService(&mocks.MockUserRepository{}, mockTransactionRepo).GetRound("", "r-1")
+	assert.True(t, appErrors.IsValidationError(err), "rounds are not configured")
+}
+
+func TestBook(t *testing.T) {
+	r, err := round.Book(nil, &transaction.Transaction{UserID: 1, Currency: "EUR", SourceType: "game", RoundID: "r-1", GameID: "slots", State: "lose", Amount: decimal.NewFromInt(2)}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, round.StatusOpen, r.Status)
+	assert.Equal(t, "slots", r.GameID)
+
+	r, err = round.Book(r, &transaction.Transaction{UserID: 1, Currency: "EUR", RoundID: "r-1", State: "lose", Amount: decimal.NewFromInt(3)}, true)
+	assert.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(5).Equal(r.StakeAmount))
+
+	settled, err := round.Book(r, &transaction.Transaction{UserID: 1, Currency: "EUR", RoundID: "r-1", State: "win", Amount: decimal.NewFromInt(7)}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, round.StatusSettled, settled.Status)
+	assert.Equal(t, round.StatusOpen, r.Status, "the booked round is left unchanged")
+
+	_, err = round.Book(settled, &transaction.Transaction{UserID: 1, Currency: "EUR", RoundID: "r-1", GameID: "poker", State: "lose", Amount: decimal.NewFromInt(1)}, false)
+	assert.ErrorIs(t, err, round.ErrRoundMismatch)
+
+	cancelled, err := round.Book(settled, &transaction.Transaction{UserID: 1, Currency: "EUR", RoundID: "r-1", State: "cancel", Amount: decimal.NewFromInt(7)}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, round.StatusCancelled, cancelled.Status)
+
+	untracked, err := round.Book(nil, &transaction.Transaction{UserID: 1, Currency: "EUR", RoundID: "r-2", State: "cancel", Amount: decimal.NewFromInt(1)}, true)
+	assert.NoError(t, err)
+	assert.Nil(t, untracked)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/source"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/transfer"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// WithSources makes the Source-Types and their settings manageable through repo. Without it the
+// built-in sources apply with their default settings.
+func WithSources(repo source.Repository) Option {
+	return func(s *TransactionService) {
+		s.sourceRepo = repo
+	}
+}
+
+// ListSources returns every Source-Type with its settings.
+func (s *TransactionService) ListSources() ([]source.Source, error) {
+	if s.sourceRepo == nil {
+		return source.Defaults(), nil
+	}
+	sources, err := s.sourceRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+	return sources, nil
+}
+
+// GetSource returns the settings of a Source-Type, whether it is enabled or not.
+func (s *TransactionService) GetSource(name string) (*source.Source, error) {
+	if s.sourceRepo == nil {
+		for _, src := range source.Defaults() {
+			if src.Name == name {
+				return &src, nil
+			}
+		}
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("Source-Type %s not found", name))
+	}
+	src, err := s.sourceRepo.Get(name)
+	if err == sql.ErrNoRows {
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("Source-Type %s not found", name))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source %s: %w", name, err)
+	}
+	return src, nil
+}
+
+// SaveSource onboards a Source-Type or replaces the settings of an existing one. The new settings
+// apply to the transactions processed from then on.
+func (s *TransactionService) SaveSource(src *source.Source) error {
+	if s.sourceRepo == nil {
+		return appErrors.NewValidationError("source settings are not available")
+	}
+	if src.Name == transfer.SourceType {
+		return appErrors.NewValidationError(fmt.Sprintf("Source-Type %s is reserved for transfers", transfer.SourceType))
+	}
+	if src.MaxAmount.Valid && src.MaxAmountCurrency == "" {
+		src.MaxAmountCurrency = s.defaultCurrency
+	}
+	if err := src.Validate(); err != nil {
+		return appErrors.NewValidationError(err.Error())
+	}
+	if err := s.sourceRepo.Save(src); err != nil {
+		return fmt.Errorf("failed to save source %s: %w", src.Name, err)
+	}
+
+	log.Printf("Source-Type %s saved: enabled %t, states %v, max amount %s, balance check %t",
+		src.Name, src.Enabled, src.States, formatMaxAmount(src), src.CheckBalance)
+	return nil
+}
+
+// checkSource checks that the source of t is enabled and accepts the transaction's type and amount,
+// and returns the source's settings.
+func (s *TransactionService) checkSource(t *transaction.Transaction) (*source.Source, error) {
+	src, err := s.GetSource(t.SourceType)
+	if appErrors.IsNotFoundError(err) {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("unknown Source-Type %q", t.SourceType))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !src.Enabled {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("Source-Type %s is disabled", src.Name))
+	}
+	if !src.Allows(t.State) {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("%s transactions are not accepted from Source-Type %s", t.State, src.Name))
+	}
+	// Reversals are not capped: they move back exactly what an accepted transaction moved.
+	if src.MaxAmount.Valid && t.ReversesID == nil {
+		amount, code := t.RequestedAmount()
+		capped := amount
+		if code != src.MaxAmountCurrency {
+			rate, err := s.rate(code, src.MaxAmountCurrency)
+			if err != nil {
+				return nil, err
+			}
+			capped = rate.Convert(amount)
+		}
+		if capped.GreaterThan(src.MaxAmount.Decimal) {
+			return nil, appErrors.NewValidationError(fmt.Sprintf("amount %s %s exceeds the maximum of %s for Source-Type %s",
+				currency.Format(code, amount), code, formatMaxAmount(src), src.Name))
+		}
+	}
+	return src, nil
+}
+
+func formatMaxAmount(src *source.Source) string {
+	if !src.MaxAmount.Valid {
+		return "none"
+	}
+	return currency.Format(src.MaxAmountCurrency, src.MaxAmount.Decimal) + " " + src.MaxAmountCurrency
+}
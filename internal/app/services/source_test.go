@@ -0,0 +1,167 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/source"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// sourceRepo returns a Source-Type repository that has stored the given sources.
+func sourceRepo(sources ...source.Source) *mocks.MockSourceRepository {
+	mockSourceRepo := &mocks.MockSourceRepository{}
+	mockSourceRepo.GetFunc = func(name string) (*source.Source, error) {
+		for _, src := range sources {
+			if src.Name == name {
+				return &src, nil
+			}
+		}
+		return nil, sql.ErrNoRows
+	}
+	return mockSourceRepo
+}
+
+func sourceTransaction(sourceType string, state transaction.Type, amount string) *transaction.Transaction {
+	return &transaction.Transaction{
+		TransactionID: "src-1",
+		SourceType:    sourceType,
+		State:         state,
+		Amount:        decimal.RequireFromString(amount),
+	}
+}
+
+// foreignTransaction is a crypto deposit into the wallet of the amount's currency.
+func foreignTransaction(code, amount string) *transaction.Transaction {
+	t := sourceTransaction("crypto", transaction.TypeDeposit, amount)
+	t.Currency = code
+	return t
+}
+
+func TestTransactionService_ProcessTransaction_Sources(t *testing.T) {
+	crypto := source.Source{
+		Name:              "crypto",
+		Enabled:           true,
+		States:            source.States{transaction.TypeDeposit, transaction.TypeWithdrawal},
+		MaxAmount:         decimal.NewNullDecimal(decimal.NewFromInt(20)),
+		MaxAmountCurrency: "EUR",
+		CheckBalance:      true,
+	}
+	paused := source.Source{Name: "paused", States: source.States{transaction.TypeWin}, CheckBalance: true}
+
+	tests := []struct {
+		name  string
+		t     *transaction.Transaction
+		check func(error) bool
+	}{
+		{"onboarded source", sourceTransaction("crypto", transaction.TypeDeposit, "5.00"), nil},
+		{"amount at the cap", sourceTransaction("crypto", transaction.TypeDeposit, "20.00"), nil},
+		{"amount above the cap", sourceTransaction("crypto", transaction.TypeDeposit, "20.01"), appErrors.IsValidationError},
+		{"converted amount below the cap", foreignTransaction("USD", "22.00"), nil},
+		{"converted amount above the cap", foreignTransaction("USD", "22.30"), appErrors.IsValidationError},
+		{"amount without a rate to the cap's currency", foreignTransaction("GBP", "1.00"), appErrors.IsValidationError},
+		{"type not allowed", sourceTransaction("crypto", transaction.TypeWin, "5.00"), appErrors.IsValidationError},
+		{"disabled source", sourceTransaction("paused", transaction.TypeWin, "5.00"), appErrors.IsValidationError},
+		{"unknown source", sourceTransaction("lottery", transaction.TypeWin, "5.00"), appErrors.IsValidationError},
+		{"balance check applies", sourceTransaction("crypto", transaction.TypeWithdrawal, "15.00"), appErrors.IsInsufficientBalanceError},
+	}
+	mockRates := &mocks.MockRateProvider{}
+	mockRates.RateFunc = func(from, to string) (currency.Rate, error) {
+		if from == "USD" && to == "EUR" {
+			return currency.Rate{From: from, To: to, Value: decimal.RequireFromString("0.9")}, nil
+		}
+		return currency.Rate{}, currency.ErrRateNotFound
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(withOptions(services.WithSources(sourceRepo(crypto, paused)), services.WithRateProvider(mockRates)))
+			_, err := svc.ProcessTransaction(1, tt.t)
+			if tt.check == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, tt.check(err), "got %v", err)
+			}
+		})
+	}
+}
+
+func TestTransactionService_ProcessTransaction_SourceWithoutBalanceCheck(t *testing.T) {
+	chargebacks := source.Source{Name: "chargebacks", Enabled: true, States: source.States{transaction.TypeWithdrawal}}
+	svc, repos := newTestService(withOptions(services.WithSources(sourceRepo(chargebacks))))
+	var applied user.BalanceUpdate
+	repos.users.AtomicUpdateBalanceAndCreateTransactionFunc = func(userID uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		applied = update
+		return decimal.NewFromInt(10).Add(update.Delta), nil
+	}
+
+	_, err := svc.ProcessTransaction(1, sourceTransaction("chargebacks", transaction.TypeWithdrawal, "15.00"))
+	assert.NoError(t, err)
+	assert.True(t, applied.AllowNegative)
+	assert.True(t, decimal.NewFromInt(-15).Equal(applied.Delta))
+}
+
+func TestTransactionService_SaveSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   source.Source
+		valid bool
+	}{
+		{"new source", source.Source{Name: "crypto", Enabled: true, States: source.States{transaction.TypeDeposit}}, true},
+		{"capped source", source.Source{Name: "sportsbook_2", States: source.States{transaction.TypeLose}, MaxAmount: decimal.NewNullDecimal(decimal.NewFromInt(100))}, true},
+		{"reserved name", source.Source{Name: "transfer", Enabled: true}, false},
+		{"invalid name", source.Source{Name: "Crypto Pay", Enabled: true}, false},
+		{"internal state", source.Source{Name: "crypto", States: source.States{transaction.TypeDebit}}, false},
+		{"unknown state", source.Source{Name: "crypto", States: source.States{"jackpot"}}, false},
+		{"non-positive cap", source.Source{Name: "crypto", MaxAmount: decimal.NewNullDecimal(decimal.Zero)}, false},
+		{"cap in another currency", source.Source{Name: "crypto", MaxAmount: decimal.NewNullDecimal(decimal.NewFromInt(100)), MaxAmountCurrency: "USD"}, true},
+		{"cap in an unknown currency", source.Source{Name: "crypto", MaxAmount: decimal.NewNullDecimal(decimal.NewFromInt(100)), MaxAmountCurrency: "XYZ"}, false},
+		{"currency without a cap", source.Source{Name: "crypto", MaxAmountCurrency: "USD"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSourceRepo := sourceRepo()
+			svc, _ := newTestService(withOptions(services.WithSources(mockSourceRepo)))
+			saved := false
+			mockSourceRepo.SaveFunc = func(s *source.Source) error {
+				saved = true
+				return nil
+			}
+
+			err := svc.SaveSource(&tt.src)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, appErrors.IsValidationError(err), "got %v", err)
+			}
+			assert.Equal(t, tt.valid, saved)
+			if tt.valid && tt.src.MaxAmount.Valid {
+				assert.NotEmpty(t, tt.src.MaxAmountCurrency, "a cap without a currency is in the default currency")
+			}
+		})
+	}
+}
+
+func TestTransactionService_SourcesDefault(t *testing.T) {
+	svc := services.NewTransactionService(&mocks.MockUserRepository{}, &mocks.MockTransactionRepository{})
+
+	sources, err := svc.ListSources()
+	assert.NoError(t, err)
+	assert.Len(t, sources, len(source.Builtin))
+
+	payment, err := svc.GetSource("payment")
+	assert.NoError(t, err)
+	assert.True(t, payment.Allows(transaction.TypeDeposit))
+	assert.False(t, payment.Allows(transaction.TypeBonusGrant))
+
+	_, err = svc.GetSource("transfer")
+	assert.True(t, appErrors.IsNotFoundError(err), "got %v", err)
+	err = svc.SaveSource(&source.Source{Name: "crypto", Enabled: true})
+	assert.True(t, appErrors.IsValidationError(err), "got %v", err)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// StatusRequest describes an admin change of a user's account status.
+type StatusRequest struct {
+	Status user.Status
+	// Until is the end of a self-exclusion; it is required for, and only allowed with, a self-exclusion.
+	Until *time.Time
+	// Reason is recorded in the audit trail and must not be empty.
+	Reason string
+}
+
+// SetUserStatus changes the user's account status if the status machine allows it and records the
+// change with its reason in the audit trail.
+func (s *TransactionService) SetUserStatus(userID uint64, req StatusRequest) (*user.User, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, appErrors.NewValidationError("a reason is required to change the account status")
+	}
+	if !user.ValidStatus(req.Status) {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("unknown account status %q", req.Status))
+	}
+	now := time.Now()
+	if req.Status == user.StatusSelfExcluded {
+		if req.Until == nil || !req.Until.After(now) {
+			return nil, appErrors.NewValidationError("a self-exclusion needs an end date in the future")
+		}
+	} else if req.Until != nil {
+		return nil, appErrors.NewValidationError("an end date is only allowed for a self-exclusion")
+	}
+
+	u, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.CheckTransition(req.Status, req.Until, now); err != nil {
+		return nil, appErrors.NewConflictError(err.Error())
+	}
+
+	change := &user.StatusChange{
+		UserID:            userID,
+		FromStatus:        u.Status,
+		ToStatus:          req.Status,
+		SelfExcludedUntil: req.Until,
+		Reason:            reason,
+	}
+	updated, err := s.userRepo.ChangeStatus(change)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) || appErrors.IsConflictError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to change account status: %w", err)
+	}
+
+	log.Printf("User %d account status changed from %s to %s: %s", userID, u.CurrentStatus(now), req.Status, reason)
+	return updated, nil
+}
+
+// GetUserStatus returns the user together with the audit trail of their status changes, oldest first.
+func (s *TransactionService) GetUserStatus(userID uint64) (*user.User, []user.StatusChange, error) {
+	u, err := s.getUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := s.userRepo.ListStatusChanges(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, changes, nil
+}
+
+// checkAccountStatus rejects a debit, or a credit if debit is false, that the user's status does not allow.
+func checkAccountStatus(u *user.User, debit bool) error {
+	now := time.Now()
+	if debit && !u.CanDebit(now) || !debit && !u.CanCredit(now) {
+		direction := "credited"
+		if debit {
+			direction = "debited"
+		}
+		return appErrors.NewAccountRestrictedError(fmt.Sprintf("account of user %d is %s and cannot be %s",
+			u.ID, strings.ReplaceAll(string(u.CurrentStatus(now)), "_", "-"), direction))
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+func TestTransactionService_ProcessTransaction_AccountStatus(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		user      user.User
+		winErr    bool
+		loseErr   bool
+		errSubstr string
+	}{
+		{name: "active", user: user.User{Status: user.StatusActive}},
+		{name: "frozen", user: user.User{Status: user.StatusFrozen}, winErr: true, loseErr: true, errSubstr: "is frozen"},
+		{name: "closed", user: user.User{Status: user.StatusClosed}, winErr: true, loseErr: true, errSubstr: "is closed"},
+		{name: "self-excluded", user: user.User{Status: user.StatusSelfExcluded, SelfExcludedUntil: &future}, loseErr: true, errSubstr: "is self-excluded and cannot be debited"},
+		{name: "self-exclusion ended", user: user.User{Status: user.StatusSelfExcluded, SelfExcludedUntil: &past}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := &mocks.MockUserRepository{}
+			mockTransactionRepo := &mocks.MockTransactionRepository{}
+			svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+			mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+				u := tt.user
+				u.ID = id
+				return &u, nil
+			}
+			mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+				return &user.Wallet{UserID: id, Currency: code, Balance: decimal.NewFromInt(100)}, nil
+			}
+			mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+				return nil, sql.ErrNoRows
+			}
+			mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+				return decimal.NewFromInt(100).Add(update.Delta), nil
+			}
+
+			_, err := svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "txn-win", SourceType: "game", State: "win", Amount: decimal.NewFromInt(10)})
+			assert.Equal(t, tt.winErr, appErrors.IsAccountRestrictedError(err), "win: %v", err)
+			_, err = svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "txn-lose", SourceType: "game", State: "lose", Amount: decimal.NewFromInt(10)})
+			assert.Equal(t, tt.loseErr, appErrors.IsAccountRestrictedError(err), "lose: %v", err)
+			if tt.loseErr {
+				assert.Contains(t, err.Error(), tt.errSubstr)
+			}
+		})
+	}
+}
+
+func TestTransactionService_ReverseTransaction_AccountStatus(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name         string
+		user         user.User
+		winReversal  bool // reversing a win debits the account
+		loseReversal bool // reversing a loss credits it
+	}{
+		{name: "active", user: user.User{Status: user.StatusActive}},
+		{name: "frozen", user: user.User{Status: user.StatusFrozen}, winReversal: true, loseReversal: true},
+		{name: "closed", user: user.User{Status: user.StatusClosed}, winReversal: true, loseReversal: true},
+		{name: "self-excluded", user: user.User{Status: user.StatusSelfExcluded, SelfExcludedUntil: &future}, winReversal: true},
+	}
+
+	originals := map[string]*transaction.Transaction{
+		"txn-win":  {ID: 1, UserID: 1, TransactionID: "txn-win", SourceType: "game", State: "win", Amount: decimal.NewFromInt(10)},
+		"txn-lose": {ID: 2, UserID: 1, TransactionID: "txn-lose", SourceType: "game", State: "lose", Amount: decimal.NewFromInt(10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := &mocks.MockUserRepository{}
+			mockTransactionRepo := &mocks.MockTransactionRepository{}
+			svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+			mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+				u := tt.user
+				u.ID = id
+				return &u, nil
+			}
+			mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+				return &user.Wallet{UserID: id, Currency: code, Balance: decimal.NewFromInt(100)}, nil
+			}
+			mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+				if original, ok := originals[transactionID]; ok {
+					return original, nil
+				}
+				return nil, sql.ErrNoRows
+			}
+			calls := 0
+			mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+				calls++
+				return decimal.NewFromInt(100).Add(update.Delta), nil
+			}
+
+			_, err := svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-win"})
+			assert.Equal(t, tt.winReversal, appErrors.IsAccountRestrictedError(err), "win reversal: %v", err)
+			_, err = svc.ReverseTransaction(services.ReversalRequest{SourceType: "game", OriginalTransactionID: "txn-lose"})
+			assert.Equal(t, tt.loseReversal, appErrors.IsAccountRestrictedError(err), "lose reversal: %v", err)
+
+			wantCalls := 2
+			for _, restricted := range []bool{tt.winReversal, tt.loseReversal} {
+				if restricted {
+					wantCalls--
+				}
+			}
+			assert.Equal(t, wantCalls, calls, "restricted reversals must not move money")
+		})
+	}
+}
+
+func TestTransactionService_ProcessTransaction_Optimistic_RetryChecksStatus(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo, services.WithLockingStrategy(services.LockingOptimistic, 3))
+
+	status := user.StatusActive
+	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+		return &user.User{ID: id, Status: status}, nil
+	}
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: id, Currency: code, Balance: decimal.NewFromInt(100)}, nil
+	}
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+	attempts := 0
+	mockUserRepo.UpdateBalanceIfVersionMatchesFunc = func(uid uint64, expectedVersion uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		attempts++
+		// The account is frozen between the status check and the update, bumping the wallet version.
+		status = user.StatusFrozen
+		return decimal.Decimal{}, appErrors.NewVersionConflictError("conflict")
+	}
+
+	_, err := svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "txn-lose", SourceType: "game", State: "lose", Amount: decimal.NewFromInt(10)})
+	assert.True(t, appErrors.IsAccountRestrictedError(err), "got %v", err)
+	assert.Equal(t, 1, attempts, "the retry must not update the frozen account")
+}
+
+func TestTransactionService_SetUserStatus(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour)
+	later := future.Add(24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		from    user.User
+		req     services.StatusRequest
+		wantErr func(error) bool
+	}{
+		{name: "freeze", from: user.User{Status: user.StatusActive}, req: services.StatusRequest{Status: user.StatusFrozen, Reason: "chargeback"}},
+		{name: "unfreeze", from: user.User{Status: user.StatusFrozen}, req: services.StatusRequest{Status: user.StatusActive, Reason: "resolved"}},
+		{name: "self-exclude", from: user.User{Status: user.StatusActive}, req: services.StatusRequest{Status: user.StatusSelfExcluded, Until: &future, Reason: "user request"}},
+		{name: "extend self-exclusion", from: user.User{Status: user.StatusSelfExcluded, SelfExcludedUntil: &future}, req: services.StatusRequest{Status: user.StatusSelfExcluded, Until: &later, Reason: "user request"}},
+		{name: "shorten self-exclusion", from: user.User{Status: user.StatusSelfExcluded, SelfExcludedUntil: &later}, req: services.StatusRequest{Status: user.StatusSelfExcluded, Until: &future, Reason: "user request"}, wantErr: appErrors.IsConflictError},
+		{name: "lift self-exclusion early", from: user.User{Status: user.StatusSelfExcluded, SelfExcludedUntil: &future}, req: services.StatusRequest{Status: user.StatusActive, Reason: "user request"}, wantErr: appErrors.IsConflictError},
+		{name: "reopen closed", from: user.User{Status: user.StatusClosed}, req: services.StatusRequest{Status: user.StatusActive, Reason: "mistake"}, wantErr: appErrors.IsConflictError},
+		{name: "missing reason", from: user.User{Status: user.StatusActive}, req: services.StatusRequest{Status: user.StatusFrozen, Reason: "  "}, wantErr: appErrors.IsValidationError},
+		{name: "self-exclusion without end", from: user.User{Status: user.StatusActive}, req: services.StatusRequest{Status: user.StatusSelfExcluded, Reason: "user request"}, wantErr: appErrors.IsValidationError},
+		{name: "end date without self-exclusion", from: user.User{Status: user.StatusActive}, req: services.StatusRequest{Status: user.StatusFrozen, Until: &future, Reason: "fraud"}, wantErr: appErrors.IsValidationError},
+		{name: "unknown status", from: user.User{Status: user.StatusActive}, req: services.StatusRequest{Status: "suspended", Reason: "fraud"}, wantErr: appErrors.IsValidationError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := &mocks.MockUserRepository{}
+			svc := services.NewTransactionService(mockUserRepo, &mocks.MockTransactionRepository{})
+
+			mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+				u := tt.from
+				u.ID = id
+				return &u, nil
+			}
+			var recorded *user.StatusChange
+			mockUserRepo.ChangeStatusFunc = func(change *user.StatusChange) (*user.User, error) {
+				recorded = change
+				return &user.User{ID: change.UserID, Status: change.ToStatus, SelfExcludedUntil: change.SelfExcludedUntil}, nil
+			}
+
+			u, err := svc.SetUserStatus(1, tt.req)
+			if tt.wantErr != nil {
+				assert.True(t, tt.wantErr(err), "unexpected error: %v", err)
+				assert.Nil(t, recorded)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.req.Status, u.Status)
+			if assert.NotNil(t, recorded) {
+				assert.Equal(t, tt.from.Status, recorded.FromStatus)
+				assert.Equal(t, tt.req.Reason, recorded.Reason)
+			}
+		})
+	}
+}
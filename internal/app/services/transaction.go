@@ -2,77 +2,697 @@ package services
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"sync/atomic"
+	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/hold"
+	"github.com/zaynkorai/enlabs/internal/domain/ledger"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	"github.com/zaynkorai/enlabs/internal/domain/provider"
+	"github.com/zaynkorai/enlabs/internal/domain/round"
+	"github.com/zaynkorai/enlabs/internal/domain/source"
 	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/transfer"
 	"github.com/zaynkorai/enlabs/internal/domain/user"
 	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
 )
 
+// LockingStrategy controls how concurrent balance updates for the same user are serialized.
+type LockingStrategy string
+
+const (
+	// LockingPessimistic locks the user row with SELECT ... FOR UPDATE for the duration of the update.
+	LockingPessimistic LockingStrategy = "pessimistic"
+	// LockingOptimistic updates the balance only if the user's version is unchanged, retrying on conflict.
+	LockingOptimistic LockingStrategy = "optimistic"
+)
+
+const defaultOptimisticMaxRetries = 5
+
+// DefaultCurrency is used for transactions that do not name a currency, unless configured otherwise.
+const DefaultCurrency = "EUR"
+
+const (
+	DefaultHistoryPageSize = 50
+	MaxHistoryPageSize     = 100
+)
+
+const (
+	chainCheckBatchSize = 500
+	// chainCheckBalanceReads bounds how often the stored balance is re-read while new transactions
+	// keep arriving during a chain check.
+	chainCheckBalanceReads = 3
+)
+
 type TransactionService struct {
 	userRepo        user.Repository
 	transactionRepo transaction.Repository
+
+	lockingStrategy LockingStrategy
+	maxRetries      int
+	defaultCurrency string
+	rateProvider    currency.RateProvider
+	debitOrder      user.DebitOrder
+
+	holdRepo       hold.Repository
+	defaultHoldTTL time.Duration
+	maxHoldTTL     time.Duration
+
+	limitRepo     limit.Repository
+	limitCooldown time.Duration
+
+	autoProvision bool
+
+	transferRepo transfer.Repository
+
+	roundRepo    round.Repository
+	strictRounds bool
+
+	sourceRepo source.Repository
+
+	providerRepo       provider.Repository
+	providerAuthWindow time.Duration
+
+	versionConflicts atomic.Uint64
+	retriesExhausted atomic.Uint64
 }
 
-func NewTransactionService(userRepo user.Repository, transactionRepo transaction.Repository) *TransactionService {
-	return &TransactionService{
+// Option configures optional behaviour of the TransactionService.
+type Option func(*TransactionService)
+
+// WithLockingStrategy selects the concurrency control used for balance updates.
+// maxRetries bounds how many times the whole flow is retried after an optimistic version conflict
+// and is ignored for the pessimistic strategy.
+func WithLockingStrategy(strategy LockingStrategy, maxRetries int) Option {
+	return func(s *TransactionService) {
+		s.lockingStrategy = strategy
+		s.maxRetries = maxRetries
+	}
+}
+
+// WithDefaultCurrency sets the currency assumed for transactions that do not name one.
+func WithDefaultCurrency(code string) Option {
+	return func(s *TransactionService) {
+		s.defaultCurrency = code
+	}
+}
+
+// WithRateProvider enables conversion of transactions sent in a currency other than the wallet's.
+func WithRateProvider(provider currency.RateProvider) Option {
+	return func(s *TransactionService) {
+		s.rateProvider = provider
+	}
+}
+
+// WithDebitOrder selects which sub-balance, real or bonus money, losses are paid from first.
+func WithDebitOrder(order user.DebitOrder) Option {
+	return func(s *TransactionService) {
+		s.debitOrder = order
+	}
+}
+
+func NewTransactionService(userRepo user.Repository, transactionRepo transaction.Repository, opts ...Option) *TransactionService {
+	s := &TransactionService{
 		userRepo:        userRepo,
 		transactionRepo: transactionRepo,
+		lockingStrategy: LockingPessimistic,
+		maxRetries:      defaultOptimisticMaxRetries,
+		defaultCurrency: DefaultCurrency,
+		debitOrder:      user.DebitRealFirst,
+		defaultHoldTTL:  DefaultHoldTTL,
+		maxHoldTTL:      MaxHoldTTL,
+		limitCooldown:   DefaultLimitCooldown,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *TransactionService) ProcessTransaction(userID uint64, reqTransaction *transaction.Transaction) error {
-	user, err := s.userRepo.GetByID(userID)
-	if err == sql.ErrNoRows {
-		return appErrors.NewNotFoundError(fmt.Sprintf("user with ID %d not found", userID))
+// ProcessResult describes the outcome of ProcessTransaction.
+type ProcessResult struct {
+	// Transaction is the stored record. For replays it is the transaction that was originally processed.
+	Transaction *transaction.Transaction
+	// IdempotentReplay is true when the request matched an already processed transactionId,
+	// in which case the balance was left untouched.
+	IdempotentReplay bool
+}
+
+func (s *TransactionService) ProcessTransaction(userID uint64, reqTransaction *transaction.Transaction) (*ProcessResult, error) {
+	replay, change, err := s.prepareTransaction(userID, reqTransaction)
+	if err != nil || replay != nil {
+		return replay, err
+	}
+	if err := s.checkRound(reqTransaction, nil); err != nil {
+		return nil, err
+	}
+	return s.applyBalanceChange(userID, reqTransaction, change)
+}
+
+// prepareTransaction validates the request and answers replays of already processed transactions.
+// Otherwise it checks that the account may be credited or debited, converts the amount if needed and
+// returns the balance change to apply.
+func (s *TransactionService) prepareTransaction(userID uint64, reqTransaction *transaction.Transaction) (*ProcessResult, balanceChange, error) {
+	reqTransaction.UserID = userID
+	info, err := requestType(reqTransaction)
+	if err != nil {
+		return nil, nil, err
 	}
+	if reqTransaction.Currency == "" {
+		reqTransaction.Currency = s.defaultCurrency
+	}
+	if !currency.Valid(reqTransaction.Currency) {
+		return nil, nil, appErrors.NewValidationError(fmt.Sprintf("unsupported currency %q", reqTransaction.Currency))
+	}
+	if err := validateAmount(reqTransaction.RequestedAmount()); err != nil {
+		return nil, nil, err
+	}
+	bucket := user.Bucket(reqTransaction.Bucket)
+	if bucket != "" && bucket != user.BucketReal && bucket != user.BucketBonus {
+		return nil, nil, appErrors.NewValidationError(fmt.Sprintf("invalid balance bucket %q", reqTransaction.Bucket))
+	}
+	if info.Bucket != "" {
+		if bucket != "" && bucket != user.Bucket(info.Bucket) {
+			return nil, nil, appErrors.NewValidationError(fmt.Sprintf("%s transactions are always booked on the %s balance", info.Type, info.Bucket))
+		}
+		bucket = user.Bucket(info.Bucket)
+		reqTransaction.Bucket = info.Bucket
+	}
+	if reqTransaction.RoundID == "" && reqTransaction.GameID != "" {
+		return nil, nil, appErrors.NewValidationError("gameId requires a roundId")
+	}
+	if reqTransaction.RoundID != "" && !info.Wager {
+		return nil, nil, appErrors.NewValidationError(fmt.Sprintf("%s transactions cannot belong to a game round", info.Type))
+	}
+	if reqTransaction.RoundID != "" && s.roundRepo == nil {
+		return nil, nil, appErrors.NewValidationError("rounds are not available")
+	}
+	reqTransaction.RequestHash = reqTransaction.Fingerprint()
+
+	// Replays are answered from the stored record before anything else, so that a retried request
+	// gets the original outcome even if the balance has changed since.
+	existing, err := s.findProcessedTransaction(reqTransaction.SourceType, reqTransaction.TransactionID)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
+		return nil, nil, err
+	}
+	if existing != nil {
+		replay, err := s.replay(existing, reqTransaction)
+		return replay, nil, err
 	}
 
-	var newBalance decimal.Decimal
-	switch reqTransaction.State {
-	case "win":
-		newBalance = user.Balance.Add(reqTransaction.Amount)
-	case "lose":
-		// Pre-check if balance would go negative.
-		// This client-side check prevents unnecessary database transactions for invalid requests.
-		if user.Balance.LessThan(reqTransaction.Amount) {
-			return appErrors.NewValidationError("insufficient balance")
+	src, err := s.checkSource(reqTransaction)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := s.getUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkAccountStatus(u, info.Direction == transaction.DirectionDebit); err != nil {
+		return nil, nil, err
+	}
+
+	if reqTransaction.Converted() {
+		if err := s.convert(reqTransaction); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	allocation := user.Allocation{Bucket: bucket, DebitOrder: s.debitOrder}
+	var change balanceChange
+	switch info.Direction {
+	case transaction.DirectionCredit:
+		change = func(w *user.Wallet) (user.BalanceUpdate, error) {
+			return user.BalanceUpdate{Delta: reqTransaction.Amount, Allocation: allocation}, nil
+		}
+	case transaction.DirectionDebit:
+		change = func(w *user.Wallet) (user.BalanceUpdate, error) {
+			delta := reqTransaction.Amount.Neg()
+			if !src.CheckBalance {
+				return user.BalanceUpdate{Delta: delta, Allocation: allocation, AllowNegative: true}, nil
+			}
+			// Pre-check if balance would go negative.
+			// This client-side check prevents unnecessary database transactions for invalid requests;
+			// the authoritative check happens against the locked row inside the repository.
+			if w.Spendable().LessThan(reqTransaction.Amount) {
+				return user.BalanceUpdate{}, w.InsufficientBalanceError()
+			}
+			if overdrawn := w.Overdrawn(delta, w.BonusDelta(delta, allocation)); overdrawn != "" {
+				return user.BalanceUpdate{}, w.InsufficientBucketError(overdrawn)
+			}
+			return user.BalanceUpdate{Delta: delta, Allocation: allocation}, nil
+		}
+	}
+
+	return nil, change, nil
+}
+
+// requestType looks up the type of a requested transaction and checks that it may be requested.
+// Reversals are requested through ReverseTransaction instead.
+func requestType(t *transaction.Transaction) (transaction.TypeInfo, error) {
+	info, ok := transaction.LookupType(t.State)
+	if !ok || info.Internal {
+		return transaction.TypeInfo{}, appErrors.NewValidationError(fmt.Sprintf("invalid transaction state %q", t.State))
+	}
+	if info.Direction == transaction.DirectionReversal {
+		return transaction.TypeInfo{}, appErrors.NewValidationError(fmt.Sprintf("%s transactions must reference the transaction they reverse", info.Type))
+	}
+	return info, nil
+}
+
+// balanceChange computes the update to apply given the freshly read wallet, or rejects the request.
+// It may be invoked several times for one request when optimistic locking retries.
+type balanceChange func(w *user.Wallet) (user.BalanceUpdate, error)
+
+// convert sets the transaction's amount to its original amount expressed in the wallet currency,
+// recording the rate that was applied.
+func (s *TransactionService) convert(t *transaction.Transaction) error {
+	rate, err := s.rate(t.OriginalCurrency, t.Currency)
+	if err != nil {
+		return err
+	}
+
+	t.Amount = rate.Convert(t.OriginalAmount.Decimal)
+	if !t.Amount.IsPositive() {
+		return appErrors.NewValidationError(fmt.Sprintf("amount %s %s is less than the smallest %s unit after conversion",
+			currency.Format(t.OriginalCurrency, t.OriginalAmount.Decimal), t.OriginalCurrency, t.Currency))
+	}
+	t.ExchangeRate = decimal.NewNullDecimal(rate.Value)
+	asOf := rate.AsOf
+	t.RateTimestamp = &asOf
+	return nil
+}
+
+// rate returns the current exchange rate from one currency into another.
+func (s *TransactionService) rate(from, to string) (currency.Rate, error) {
+	if s.rateProvider == nil {
+		return currency.Rate{}, appErrors.NewValidationError("currency conversion is not available")
+	}
+	rate, err := s.rateProvider.Rate(from, to)
+	if errors.Is(err, currency.ErrRateNotFound) {
+		return currency.Rate{}, appErrors.NewValidationError(fmt.Sprintf("no exchange rate from %s to %s", from, to))
+	}
+	if err != nil {
+		return currency.Rate{}, fmt.Errorf("failed to get exchange rate from %s to %s: %w", from, to, err)
+	}
+	return rate, nil
+}
+
+// validateAmount checks that the currency is supported and the amount fits its minor units.
+func validateAmount(amount decimal.Decimal, code string) error {
+	if !currency.Valid(code) {
+		return appErrors.NewValidationError(fmt.Sprintf("unsupported currency %q", code))
+	}
+	if err := currency.CheckAmount(code, amount); err != nil {
+		return appErrors.NewValidationError(err.Error())
+	}
+	return nil
+}
+
+// applyBalanceChange records reqTransaction and applies the balance change to the wallet in the
+// transaction's currency using the configured locking strategy, retrying the whole
+// read-compute-write flow on optimistic version conflicts. The caller checks the account status;
+// a retry checks it again, since a status change also bumps the wallet version.
+func (s *TransactionService) applyBalanceChange(userID uint64, reqTransaction *transaction.Transaction, change balanceChange) (*ProcessResult, error) {
+	if s.lockingStrategy != LockingOptimistic {
+		return s.applyBalanceChangeOnce(userID, reqTransaction, change, false)
+	}
+
+	for attempt := 0; ; attempt++ {
+		result, err := s.applyBalanceChangeOnce(userID, reqTransaction, change, attempt > 0)
+		if !appErrors.IsVersionConflictError(err) {
+			return result, err
 		}
-		newBalance = user.Balance.Sub(reqTransaction.Amount)
-	default:
-		return appErrors.NewValidationError("invalid transaction state")
+
+		conflicts := s.versionConflicts.Add(1)
+		if attempt >= s.maxRetries {
+			exhausted := s.retriesExhausted.Add(1)
+			log.Printf("Optimistic lock retries exhausted for user %d, transaction %s after %d attempts (total conflicts: %d, total exhausted: %d)",
+				userID, reqTransaction.TransactionID, attempt+1, conflicts, exhausted)
+			return nil, appErrors.NewConflictError("balance was modified concurrently, please retry")
+		}
+
+		log.Printf("Version conflict for user %d, transaction %s (retry %d/%d, total conflicts: %d)",
+			userID, reqTransaction.TransactionID, attempt+1, s.maxRetries, conflicts)
+		// The failed attempt was rolled back, so the record must be inserted afresh.
+		reqTransaction.ID = 0
+	}
+}
+
+func (s *TransactionService) applyBalanceChangeOnce(userID uint64, reqTransaction *transaction.Transaction, change balanceChange, checkStatus bool) (*ProcessResult, error) {
+	wallet, err := s.currentWallet(userID, reqTransaction.Currency)
+	if err != nil {
+		return nil, err
 	}
 
-	err = s.userRepo.AtomicUpdateBalanceAndCreateTransaction(user.ID, newBalance, reqTransaction)
+	update, err := change(wallet)
+	if err != nil {
+		return nil, err
+	}
+	if checkStatus {
+		u, err := s.getUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkAccountStatus(u, update.Delta.IsNegative()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.completeUpdate(&update, reqTransaction); err != nil {
+		return nil, err
+	}
+
+	var newBalance decimal.Decimal
+	if s.lockingStrategy == LockingOptimistic {
+		newBalance, err = s.userRepo.UpdateBalanceIfVersionMatches(userID, wallet.Version, update)
+	} else {
+		newBalance, err = s.userRepo.AtomicUpdateBalanceAndCreateTransaction(userID, update)
+	}
 	if err != nil {
 		if appErrors.IsAlreadyProcessedError(err) {
+			// A concurrent request with the same transactionId won the race after our pre-check.
+			existing, findErr := s.findProcessedTransaction(reqTransaction.SourceType, reqTransaction.TransactionID)
+			if findErr != nil {
+				return nil, findErr
+			}
+			if existing == nil {
+				return nil, fmt.Errorf("transaction %s reported as processed but not found", reqTransaction.TransactionID)
+			}
+			return s.replay(existing, reqTransaction)
+		}
+		if appErrors.IsNotFoundError(err) || appErrors.IsValidationError(err) || appErrors.IsConflictError(err) ||
+			appErrors.IsVersionConflictError(err) || appErrors.IsLimitExceededError(err) {
 
-			log.Printf("Transaction ID %s for user %d already processed. Skipping balance update.", reqTransaction.TransactionID, userID)
-			return nil // Return nil to indicate success to the caller (HTTP handler)
+			return nil, err
 		}
-		if appErrors.IsNotFoundError(err) {
 
+		return nil, fmt.Errorf("failed to update user balance and record transaction atomically: %w", err)
+	}
+
+	log.Printf("User %d %s balance updated to %s for transaction %s", userID, reqTransaction.Currency,
+		currency.Format(reqTransaction.Currency, newBalance), reqTransaction.TransactionID)
+	return &ProcessResult{Transaction: reqTransaction}, nil
+}
+
+// completeUpdate attaches reqTransaction and the journal entry recording the update to it, and the
+// limits it is subject to, such as loss limits if it is a stake.
+func (s *TransactionService) completeUpdate(update *user.BalanceUpdate, reqTransaction *transaction.Transaction) error {
+	update.Currency = reqTransaction.Currency
+	update.Transaction = reqTransaction
+	update.StrictRounds = s.strictRounds
+	if kind, ok := limit.KindOf(reqTransaction.State); ok {
+		limits, err := s.limitsInForce(reqTransaction.UserID, kind, reqTransaction.Currency)
+		if err != nil {
 			return err
 		}
+		update.Limits = limits
+	}
+	entry, err := journalEntryFor(reqTransaction, update.Delta)
+	if err != nil {
+		return err
+	}
+	update.Entry = entry
+	return nil
+}
+
+// journalEntryFor builds the ledger entry recording a balance change of delta for t: wins and
+// credits move funds from the source's house account to the user, losses and debits the other way.
+func journalEntryFor(t *transaction.Transaction, delta decimal.Decimal) (*ledger.JournalEntry, error) {
+	userAccount := ledger.UserAccount(t.UserID, t.Currency)
+	houseAccount := ledger.HouseAccount(t.SourceType, t.Currency)
+	description := fmt.Sprintf("%s %s/%s", t.State, t.SourceType, t.TransactionID)
 
-		return fmt.Errorf("failed to update user balance and record transaction atomically: %w", err)
+	if delta.IsNegative() {
+		return ledger.Transfer(description, userAccount, houseAccount, delta.Neg())
 	}
+	return ledger.Transfer(description, houseAccount, userAccount, delta)
+}
 
-	log.Printf("User %d balance updated to %s for transaction %s", userID, newBalance.StringFixed(2), reqTransaction.TransactionID)
-	return nil
+// replay answers a request whose transactionId was already processed. The stored result is returned
+// only if the request carries the same payload; a differing payload under a reused ID is rejected,
+// since it usually points to a provider bug or tampering.
+func (s *TransactionService) replay(existing *transaction.Transaction, reqTransaction *transaction.Transaction) (*ProcessResult, error) {
+	if !existing.MatchesRequest(reqTransaction) {
+		log.Printf("Transaction ID %s replayed for user %d with a different payload (stored user %d, %s %s %s via %s; got %s %s %s via %s)",
+			reqTransaction.TransactionID, reqTransaction.UserID, existing.UserID,
+			existing.State, currency.Format(existing.Currency, existing.Amount), existing.Currency, existing.SourceType,
+			reqTransaction.State, currency.Format(reqTransaction.Currency, reqTransaction.Amount), reqTransaction.Currency, reqTransaction.SourceType)
+		return nil, appErrors.NewConflictError("transaction with this ID has already been processed with a different payload")
+	}
+
+	log.Printf("Transaction ID %s for user %d already processed. Replaying stored result.", reqTransaction.TransactionID, reqTransaction.UserID)
+	return &ProcessResult{Transaction: existing, IdempotentReplay: true}, nil
 }
 
-func (s *TransactionService) GetUserBalance(userID uint64) (*user.User, error) {
-	user, err := s.userRepo.GetByID(userID)
+// findProcessedTransaction returns the stored transaction with the given external ID from the given
+// source, or nil if there is none.
+func (s *TransactionService) findProcessedTransaction(sourceType, transactionID string) (*transaction.Transaction, error) {
+	existing, err := s.transactionRepo.GetByTransactionID(sourceType, transactionID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for existing transaction: %w", err)
+	}
+	return existing, nil
+}
+
+// getUser returns the user or a not found error.
+func (s *TransactionService) getUser(userID uint64) (*user.User, error) {
+	u, err := s.userRepo.GetByID(userID)
 	if err == sql.ErrNoRows {
 		return nil, appErrors.NewNotFoundError(fmt.Sprintf("user with ID %d not found", userID))
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return u, nil
+}
+
+// currentWallet returns the user's wallet in the currency. A wallet that has not been opened yet
+// is returned empty at version 0; it is opened by the first balance update.
+func (s *TransactionService) currentWallet(userID uint64, code string) (*user.Wallet, error) {
+	w, err := s.userRepo.GetWallet(userID, code)
+	if err == sql.ErrNoRows {
+		if _, err := s.getUser(userID); err != nil {
+			return nil, err
+		}
+		return &user.Wallet{UserID: userID, Currency: code}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return w, nil
+}
+
+// GetUserBalance returns all wallets of the user, ordered by currency.
+func (s *TransactionService) GetUserBalance(userID uint64) ([]user.Wallet, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+	wallets, err := s.userRepo.ListWallets(userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user balance: %w", err)
 	}
-	return user, nil
+	return wallets, nil
+}
+
+// SetCreditLimit lets the user's wallet in the currency (the default currency when empty) be drawn
+// down to -limit. A zero limit restores the default floor of zero. Lowering the limit below what is
+// already drawn blocks further debits but does not change the balance.
+func (s *TransactionService) SetCreditLimit(userID uint64, code string, limit decimal.Decimal) (*user.Wallet, error) {
+	if code == "" {
+		code = s.defaultCurrency
+	}
+	if err := validateAmount(limit, code); err != nil {
+		return nil, err
+	}
+	if limit.IsNegative() {
+		return nil, appErrors.NewValidationError("credit limit must not be negative")
+	}
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	w, err := s.userRepo.SetCreditLimit(userID, code, limit)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to set credit limit: %w", err)
+	}
+	log.Printf("User %d %s credit limit set to %s", userID, code, currency.Format(code, limit))
+	return w, nil
+}
+
+// ListUserTransactions returns one page of the user's transaction history, newest first,
+// together with the cursor for the next page (empty when there are no more rows).
+func (s *TransactionService) ListUserTransactions(userID uint64, filter transaction.ListFilter) ([]transaction.Transaction, string, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, "", err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultHistoryPageSize
+	}
+	if filter.Limit > MaxHistoryPageSize {
+		filter.Limit = MaxHistoryPageSize
+	}
+	pageSize := filter.Limit
+	// Fetch one extra row to find out whether another page exists.
+	filter.Limit++
+
+	transactions, err := s.transactionRepo.ListByUser(userID, filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	var nextCursor string
+	if len(transactions) > pageSize {
+		transactions = transactions[:pageSize]
+		last := transactions[pageSize-1]
+		nextCursor = transaction.Cursor{ProcessedAt: last.ProcessedAt, ID: last.ID}.Encode()
+	}
+	return transactions, nextCursor, nil
+}
+
+// GetTransaction looks up a stored transaction by the provider's external transaction ID.
+// sourceType may be empty, in which case the ID must be unambiguous across sources.
+func (s *TransactionService) GetTransaction(sourceType, transactionID string) (*transaction.Transaction, error) {
+	t, err := s.transactionRepo.GetByTransactionID(sourceType, transactionID)
+	if err == sql.ErrNoRows {
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("transaction with ID %s not found", transactionID))
+	}
+	if appErrors.IsConflictError(err) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	return t, nil
+}
+
+// GetUserTransaction is like GetTransaction but only finds transactions belonging to the given user.
+func (s *TransactionService) GetUserTransaction(userID uint64, sourceType, transactionID string) (*transaction.Transaction, error) {
+	t, err := s.GetTransaction(sourceType, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if t.UserID != userID {
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("transaction with ID %s not found for user %d", transactionID, userID))
+	}
+	return t, nil
+}
+
+// ChainReport is the result of checking a user's balance timeline.
+type ChainReport struct {
+	UserID  uint64
+	Checked int
+	Breaks  []transaction.ChainBreak
+	Wallets []WalletChain
+}
+
+// WalletChain compares the end of a wallet's chain with its stored balance.
+type WalletChain struct {
+	Currency string
+	// Balance is the stored balance the last snapshot is compared with. It is not set if the
+	// balance kept changing while the check ran.
+	Balance          decimal.NullDecimal
+	LastBalanceAfter decimal.NullDecimal
+}
+
+// Consistent reports whether the last snapshot of the wallet matches its balance.
+func (w WalletChain) Consistent() bool {
+	if w.Balance.Valid && w.LastBalanceAfter.Valid {
+		return w.Balance.Decimal.Equal(w.LastBalanceAfter.Decimal)
+	}
+	return true
+}
+
+// Consistent reports whether every row chains to the next and every wallet ends at its balance.
+func (r *ChainReport) Consistent() bool {
+	if len(r.Breaks) > 0 {
+		return false
+	}
+	for _, w := range r.Wallets {
+		if !w.Consistent() {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyTransactionChain walks the user's transactions in the order they were applied and checks,
+// per wallet, that each row's balance before equals the previous row's balance after, that each row
+// moved the balance by its amount, and that the last row ends at the wallet's current balance.
+func (s *TransactionService) VerifyTransactionChain(userID uint64) (*ChainReport, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	checker := transaction.NewChainChecker()
+	var lastID uint64
+	if _, err := s.feedChainChecker(userID, checker, &lastID); err != nil {
+		return nil, err
+	}
+
+	var wallets []user.Wallet
+	stable := false
+	for read := 0; read < chainCheckBalanceReads && !stable; read++ {
+		var err error
+		wallets, err = s.userRepo.ListWallets(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list wallets: %w", err)
+		}
+		fed, err := s.feedChainChecker(userID, checker, &lastID)
+		if err != nil {
+			return nil, err
+		}
+		stable = fed == 0
+	}
+
+	report := &ChainReport{
+		UserID:  userID,
+		Checked: checker.Checked,
+		Breaks:  checker.Breaks,
+		Wallets: make([]WalletChain, 0, len(wallets)),
+	}
+	for _, w := range wallets {
+		chain := WalletChain{Currency: w.Currency, LastBalanceAfter: checker.LastBalance(w.Currency)}
+		if stable {
+			chain.Balance = decimal.NewNullDecimal(w.Balance)
+		}
+		report.Wallets = append(report.Wallets, chain)
+	}
+	if !report.Consistent() {
+		log.Printf("Balance chain of user %d is inconsistent: %d breaks in %d checked transactions", userID, len(report.Breaks), report.Checked)
+	}
+	return report, nil
+}
+
+// feedChainChecker adds all of the user's transactions after *lastID to the checker, advancing
+// *lastID, and returns how many were added.
+func (s *TransactionService) feedChainChecker(userID uint64, checker *transaction.ChainChecker, lastID *uint64) (int, error) {
+	fed := 0
+	for {
+		batch, err := s.transactionRepo.ListByUserInApplyOrder(userID, *lastID, chainCheckBatchSize)
+		if err != nil {
+			return fed, fmt.Errorf("failed to list transactions: %w", err)
+		}
+		for _, t := range batch {
+			checker.Add(t)
+		}
+		fed += len(batch)
+		if len(batch) > 0 {
+			*lastID = batch[len(batch)-1].ID
+		}
+		if len(batch) < chainCheckBatchSize {
+			return fed, nil
+		}
+	}
 }
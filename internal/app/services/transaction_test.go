@@ -2,91 +2,210 @@ package services_test
 
 import (
 	"database/sql"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
 	"github.com/zaynkorai/enlabs/internal/domain/transaction"
 	"github.com/zaynkorai/enlabs/internal/domain/user"
 	"github.com/zaynkorai/enlabs/internal/mocks"
 	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
 )
 
+// activeUser is a GetByIDFunc for tests that only need the user to exist.
+func activeUser(id uint64) (*user.User, error) {
+	return &user.User{ID: id}, nil
+}
+
+// testRepos are the mock repositories behind a service built by newTestService, and what they hold.
+type testRepos struct {
+	users        *mocks.MockUserRepository
+	transactions *mocks.MockTransactionRepository
+
+	accounts  map[uint64]user.User // nil if every user exists and is active
+	balances  map[uint64]int64     // nil if every user holds 10 in each currency
+	processed []*transaction.Transaction
+	options   []services.Option
+}
+
+// testOption adjusts the repositories of newTestService, or the service's options, before the
+// service is built.
+type testOption func(r *testRepos)
+
+// withUsers makes only the given users exist, with their status.
+func withUsers(users ...user.User) testOption {
+	return func(r *testRepos) {
+		r.accounts = make(map[uint64]user.User, len(users))
+		for _, u := range users {
+			r.accounts[u.ID] = u
+		}
+	}
+}
+
+// withBalances gives only the users in balances a wallet in each currency, holding their balance.
+// Unless withUsers is given, the other users do not exist either.
+func withBalances(balances map[uint64]int64) testOption {
+	return func(r *testRepos) {
+		r.balances = balances
+	}
+}
+
+// withTransactions makes the transactions look processed already.
+func withTransactions(transactions ...*transaction.Transaction) testOption {
+	return func(r *testRepos) {
+		r.processed = append(r.processed, transactions...)
+	}
+}
+
+// withOptions passes options, such as the repositories of a feature, to the service.
+func withOptions(options ...services.Option) testOption {
+	return func(r *testRepos) {
+		r.options = append(r.options, options...)
+	}
+}
+
+// newTestService returns a service over mock repositories in which every user is active and holds
+// 10 in each currency, no transaction has been processed yet and balance updates succeed. The
+// options change this; tests may still replace the mocks' functions afterwards.
+func newTestService(opts ...testOption) (*services.TransactionService, *testRepos) {
+	r := &testRepos{users: &mocks.MockUserRepository{}, transactions: &mocks.MockTransactionRepository{}}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	balance := func(userID uint64) (decimal.Decimal, bool) {
+		if r.balances == nil {
+			return decimal.NewFromInt(10), true
+		}
+		b, ok := r.balances[userID]
+		return decimal.NewFromInt(b), ok
+	}
+	r.users.GetByIDFunc = func(id uint64) (*user.User, error) {
+		if r.accounts != nil {
+			if u, ok := r.accounts[id]; ok {
+				return &u, nil
+			}
+			return nil, sql.ErrNoRows
+		}
+		if _, ok := balance(id); !ok {
+			return nil, sql.ErrNoRows
+		}
+		return activeUser(id)
+	}
+	r.users.GetWalletFunc = func(userID uint64, code string) (*user.Wallet, error) {
+		b, ok := balance(userID)
+		if !ok {
+			return nil, sql.ErrNoRows
+		}
+		return &user.Wallet{UserID: userID, Currency: code, Balance: b}, nil
+	}
+	r.users.AtomicUpdateBalanceAndCreateTransactionFunc = func(userID uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		b, _ := balance(userID)
+		return b.Add(update.Delta), nil
+	}
+	r.transactions.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		for _, t := range r.processed {
+			if t.SourceType == sourceType && t.TransactionID == transactionID {
+				return t, nil
+			}
+		}
+		return nil, sql.ErrNoRows
+	}
+
+	return services.NewTransactionService(r.users, r.transactions, r.options...), r
+}
+
 func TestTransactionService_ProcessTransaction_Win(t *testing.T) {
 	mockUserRepo := &mocks.MockUserRepository{}
 	mockTransactionRepo := &mocks.MockTransactionRepository{}
 	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
 
 	userID := uint64(1)
 	initialBalance := decimal.NewFromFloat(100.00)
 	winAmount := decimal.NewFromFloat(10.50)
 	expectedBalance := initialBalance.Add(winAmount)
 
-	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
 		if id == userID {
-			return &user.User{ID: userID, Balance: initialBalance}, nil
+			return &user.Wallet{UserID: userID, Currency: code, Balance: initialBalance}, nil
 		}
 		return nil, sql.ErrNoRows
 	}
 
-	mockTransactionRepo.GetByTransactionIDFunc = func(transactionID string) (*transaction.Transaction, error) {
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
 		return nil, sql.ErrNoRows // No existing transaction
 	}
 
 	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(
 		uid uint64,
-		newBalance decimal.Decimal,
-		newTxn *transaction.Transaction,
-	) error {
+		update user.BalanceUpdate,
+	) (decimal.Decimal, error) {
 		assert.Equal(t, userID, uid)
-		assert.True(t, newBalance.Equal(expectedBalance))
-		assert.Equal(t, "win", newTxn.State)
-		assert.True(t, newTxn.Amount.Equal(winAmount))
-		return nil
+		assert.True(t, update.Delta.Equal(winAmount))
+		assert.Equal(t, transaction.TypeWin, update.Transaction.State)
+		assert.True(t, update.Transaction.Amount.Equal(winAmount))
+		assert.NoError(t, update.Entry.Validate())
+		assert.Equal(t, "house:game:EUR", update.Entry.Postings[0].AccountCode)
+		assert.True(t, update.Entry.Postings[0].Amount.Equal(winAmount.Neg()))
+		assert.Equal(t, "user:1:EUR", update.Entry.Postings[1].AccountCode)
+		assert.True(t, update.Entry.Postings[1].Amount.Equal(winAmount))
+		return expectedBalance, nil
 	}
 
 	reqTransaction := &transaction.Transaction{
 		TransactionID: "txn-win-1",
 		State:         "win",
 		Amount:        winAmount,
+		SourceType:    "game",
 	}
 
-	err := svc.ProcessTransaction(userID, reqTransaction)
+	result, err := svc.ProcessTransaction(userID, reqTransaction)
 	assert.NoError(t, err)
+	assert.False(t, result.IdempotentReplay)
+	assert.Same(t, reqTransaction, result.Transaction)
 }
 
 func TestTransactionService_ProcessTransaction_Lose_SufficientBalance(t *testing.T) {
 	mockUserRepo := &mocks.MockUserRepository{}
 	mockTransactionRepo := &mocks.MockTransactionRepository{}
 	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
 
 	userID := uint64(1)
 	initialBalance := decimal.NewFromFloat(100.00)
 	loseAmount := decimal.NewFromFloat(10.50)
 	expectedBalance := initialBalance.Sub(loseAmount)
 
-	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
 		if id == userID {
-			return &user.User{ID: userID, Balance: initialBalance}, nil
+			return &user.Wallet{UserID: userID, Currency: code, Balance: initialBalance}, nil
 		}
 		return nil, sql.ErrNoRows
 	}
 
-	mockTransactionRepo.GetByTransactionIDFunc = func(transactionID string) (*transaction.Transaction, error) {
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
 		return nil, sql.ErrNoRows // No existing transaction
 	}
 
 	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(
 		uid uint64,
-		newBalance decimal.Decimal,
-		newTxn *transaction.Transaction,
-	) error {
+		update user.BalanceUpdate,
+	) (decimal.Decimal, error) {
 		assert.Equal(t, userID, uid)
-		assert.True(t, newBalance.Equal(expectedBalance))
-		assert.Equal(t, "lose", newTxn.State)
-		assert.True(t, newTxn.Amount.Equal(loseAmount))
-		return nil
+		assert.True(t, update.Delta.Equal(loseAmount.Neg()))
+		assert.Equal(t, transaction.TypeLose, update.Transaction.State)
+		assert.True(t, update.Transaction.Amount.Equal(loseAmount))
+		assert.NoError(t, update.Entry.Validate())
+		assert.Equal(t, "user:1:EUR", update.Entry.Postings[0].AccountCode)
+		assert.True(t, update.Entry.Postings[0].Amount.Equal(loseAmount.Neg()))
+		assert.Equal(t, "house:game:EUR", update.Entry.Postings[1].AccountCode)
+		assert.True(t, update.Entry.Postings[1].Amount.Equal(loseAmount))
+		return expectedBalance, nil
 	}
 
 	reqTransaction := &transaction.Transaction{
@@ -96,7 +215,7 @@ func TestTransactionService_ProcessTransaction_Lose_SufficientBalance(t *testing
 		SourceType:    "game",
 	}
 
-	err := svc.ProcessTransaction(userID, reqTransaction)
+	_, err := svc.ProcessTransaction(userID, reqTransaction)
 	assert.NoError(t, err)
 }
 
@@ -104,30 +223,30 @@ func TestTransactionService_ProcessTransaction_Lose_InsufficientBalance(t *testi
 	mockUserRepo := &mocks.MockUserRepository{}
 	mockTransactionRepo := &mocks.MockTransactionRepository{}
 	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
 
 	userID := uint64(1)
 	initialBalance := decimal.NewFromFloat(5.00)
 	loseAmount := decimal.NewFromFloat(10.50) // More than initial balance
 
-	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
 		if id == userID {
-			return &user.User{ID: userID, Balance: initialBalance}, nil
+			return &user.Wallet{UserID: userID, Currency: code, Balance: initialBalance}, nil
 		}
 		return nil, sql.ErrNoRows
 	}
 
-	mockTransactionRepo.GetByTransactionIDFunc = func(transactionID string) (*transaction.Transaction, error) {
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
 		return nil, sql.ErrNoRows // No existing transaction
 	}
 
 	// AtomicUpdateBalanceAndCreateTransactionFunc should not be called in this case
 	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(
 		uid uint64,
-		newBalance decimal.Decimal,
-		newTxn *transaction.Transaction,
-	) error {
+		update user.BalanceUpdate,
+	) (decimal.Decimal, error) {
 		t.Fatal("AtomicUpdateBalanceAndCreateTransactionFunc should not be called for insufficient balance")
-		return nil
+		return decimal.Decimal{}, nil
 	}
 
 	reqTransaction := &transaction.Transaction{
@@ -137,7 +256,7 @@ func TestTransactionService_ProcessTransaction_Lose_InsufficientBalance(t *testi
 		SourceType:    "game",
 	}
 
-	err := svc.ProcessTransaction(userID, reqTransaction)
+	_, err := svc.ProcessTransaction(userID, reqTransaction)
 	assert.Error(t, err)
 	assert.True(t, appErrors.IsValidationError(err))
 	assert.Contains(t, err.Error(), "insufficient balance")
@@ -147,29 +266,29 @@ func TestTransactionService_ProcessTransaction_DuplicateTransactionID(t *testing
 	mockUserRepo := &mocks.MockUserRepository{}
 	mockTransactionRepo := &mocks.MockTransactionRepository{}
 	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
 
 	userID := uint64(1)
 	existingTransactionID := "duplicate-txn-id"
 
-	mockTransactionRepo.GetByTransactionIDFunc = func(transactionID string) (*transaction.Transaction, error) {
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
 		if transactionID == existingTransactionID {
 			return &transaction.Transaction{TransactionID: existingTransactionID}, nil // Transaction already exists
 		}
 		return nil, sql.ErrNoRows
 	}
 
-	// GetByIDFunc and AtomicUpdateBalanceAndCreateTransactionFunc should not be called
-	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
-		t.Fatal("GetByIDFunc should not be called for duplicate transaction")
+	// GetWalletFunc and AtomicUpdateBalanceAndCreateTransactionFunc should not be called
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		t.Fatal("GetWalletFunc should not be called for duplicate transaction")
 		return nil, nil
 	}
 	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(
 		uid uint64,
-		newBalance decimal.Decimal,
-		newTxn *transaction.Transaction,
-	) error {
+		update user.BalanceUpdate,
+	) (decimal.Decimal, error) {
 		t.Fatal("AtomicUpdateBalanceAndCreateTransactionFunc should not be called for duplicate transaction")
-		return nil
+		return decimal.Decimal{}, nil
 	}
 
 	reqTransaction := &transaction.Transaction{
@@ -179,7 +298,7 @@ func TestTransactionService_ProcessTransaction_DuplicateTransactionID(t *testing
 		SourceType:    "game",
 	}
 
-	err := svc.ProcessTransaction(userID, reqTransaction)
+	_, err := svc.ProcessTransaction(userID, reqTransaction)
 	assert.Error(t, err)
 	assert.True(t, appErrors.IsConflictError(err))
 	assert.Contains(t, err.Error(), "transaction with this ID has already been processed")
@@ -192,10 +311,13 @@ func TestTransactionService_ProcessTransaction_UserNotFound(t *testing.T) {
 
 	userID := uint64(999) // Non-existent user
 
-	mockTransactionRepo.GetByTransactionIDFunc = func(transactionID string) (*transaction.Transaction, error) {
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
 		return nil, sql.ErrNoRows // No existing transaction
 	}
 
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return nil, sql.ErrNoRows // No wallet yet
+	}
 	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
 		return nil, sql.ErrNoRows // User not found
 	}
@@ -203,11 +325,10 @@ func TestTransactionService_ProcessTransaction_UserNotFound(t *testing.T) {
 	// AtomicUpdateBalanceAndCreateTransactionFunc should not be called
 	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(
 		uid uint64,
-		newBalance decimal.Decimal,
-		newTxn *transaction.Transaction,
-	) error {
+		update user.BalanceUpdate,
+	) (decimal.Decimal, error) {
 		t.Fatal("AtomicUpdateBalanceAndCreateTransactionFunc should not be called for user not found")
-		return nil
+		return decimal.Decimal{}, nil
 	}
 
 	reqTransaction := &transaction.Transaction{
@@ -217,12 +338,50 @@ func TestTransactionService_ProcessTransaction_UserNotFound(t *testing.T) {
 		SourceType:    "game",
 	}
 
-	err := svc.ProcessTransaction(userID, reqTransaction)
+	_, err := svc.ProcessTransaction(userID, reqTransaction)
 	assert.Error(t, err)
 	assert.True(t, appErrors.IsNotFoundError(err))
 	assert.Contains(t, err.Error(), "user with ID 999 not found")
 }
 
+func TestTransactionService_ProcessTransaction_Lose_InsufficientBalanceAfterLock(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
+
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	userID := uint64(1)
+	// The stale read still shows enough funds; a concurrent request drained them before the lock was taken.
+	staleBalance := decimal.NewFromFloat(20.00)
+
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: userID, Currency: code, Balance: staleBalance}, nil
+	}
+
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(
+		uid uint64,
+		update user.BalanceUpdate,
+	) (decimal.Decimal, error) {
+		return decimal.Decimal{}, appErrors.NewValidationError("insufficient balance: balance remains 5.00")
+	}
+
+	reqTransaction := &transaction.Transaction{
+		TransactionID: "txn-lose-raced",
+		State:         "lose",
+		Amount:        decimal.NewFromFloat(10.00),
+		SourceType:    "game",
+	}
+
+	_, err := svc.ProcessTransaction(userID, reqTransaction)
+	assert.Error(t, err)
+	assert.True(t, appErrors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "insufficient balance")
+}
+
 func TestTransactionService_GetUserBalance_Success(t *testing.T) {
 	mockUserRepo := &mocks.MockUserRepository{}
 	mockTransactionRepo := &mocks.MockTransactionRepository{}
@@ -233,33 +392,44 @@ func TestTransactionService_GetUserBalance_Success(t *testing.T) {
 
 	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
 		if id == userID {
-			return &user.User{ID: userID, Balance: expectedBalance}, nil
+			return &user.User{ID: userID}, nil
 		}
 		return nil, sql.ErrNoRows
 	}
+	mockUserRepo.ListWalletsFunc = func(id uint64) ([]user.Wallet, error) {
+		return []user.Wallet{
+			{UserID: userID, Currency: "EUR", Balance: expectedBalance},
+			{UserID: userID, Currency: "USD", Balance: decimal.NewFromFloat(1.50)},
+		}, nil
+	}
 
-	u, err := svc.GetUserBalance(userID)
+	wallets, err := svc.GetUserBalance(userID)
 	assert.NoError(t, err)
-	assert.NotNil(t, u)
-	assert.Equal(t, userID, u.ID)
-	assert.True(t, u.Balance.Equal(expectedBalance))
+	assert.Len(t, wallets, 2)
+	assert.Equal(t, "EUR", wallets[0].Currency)
+	assert.True(t, wallets[0].Balance.Equal(expectedBalance))
+
+	_, err = svc.GetUserBalance(2)
+	assert.True(t, appErrors.IsNotFoundError(err))
 }
+
 func TestTransactionService_ProcessTransaction_InvalidState(t *testing.T) {
 	mockUserRepo := &mocks.MockUserRepository{}
 	mockTransactionRepo := &mocks.MockTransactionRepository{}
 	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
 
 	userID := uint64(1)
 	initialBalance := decimal.NewFromFloat(100.00)
 
-	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
 		if id == userID {
-			return &user.User{ID: userID, Balance: initialBalance}, nil
+			return &user.Wallet{UserID: userID, Currency: code, Balance: initialBalance}, nil
 		}
 		return nil, sql.ErrNoRows
 	}
 
-	mockTransactionRepo.GetByTransactionIDFunc = func(transactionID string) (*transaction.Transaction, error) {
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
 		return nil, sql.ErrNoRows
 	}
 
@@ -270,8 +440,776 @@ func TestTransactionService_ProcessTransaction_InvalidState(t *testing.T) {
 		SourceType:    "game",
 	}
 
-	err := svc.ProcessTransaction(userID, reqTransaction)
+	_, err := svc.ProcessTransaction(userID, reqTransaction)
 	assert.Error(t, err)
 	assert.True(t, appErrors.IsValidationError(err))
 	assert.Contains(t, err.Error(), "invalid transaction state")
 }
+
+func TestTransactionService_ProcessTransaction_Types(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
+	mockUserRepo.GetWalletFunc = func(userID uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: userID, Currency: code, Balance: decimal.NewFromInt(10), BonusBalance: decimal.NewFromInt(4)}, nil
+	}
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	tests := []struct {
+		name       string
+		state      transaction.Type
+		sourceType string
+		bucket     string
+		amount     int64
+		delta      int64
+		allocation user.Bucket
+	}{
+		{"deposit", transaction.TypeDeposit, "payment", "", 5, 5, user.BucketReal},
+		{"withdrawal", transaction.TypeWithdrawal, "payment", "", 6, -6, user.BucketReal},
+		{"bonus grant", transaction.TypeBonusGrant, "server", "", 3, 3, user.BucketBonus},
+		{"bonus grant naming its bucket", transaction.TypeBonusGrant, "server", "bonus", 3, 3, user.BucketBonus},
+		{"adjustment", transaction.TypeAdjustment, "server", "", 2, 2, ""},
+		{"fee", transaction.TypeFee, "payment", "", 1, -1, ""},
+		{"refund", transaction.TypeRefund, "server", "", 4, 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(userID uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+				assert.True(t, decimal.NewFromInt(tt.delta).Equal(update.Delta), "got delta %s", update.Delta)
+				assert.Equal(t, tt.allocation, update.Allocation.Bucket)
+				house := update.Entry.Postings[0] // Credits are paid from the house account, debits paid into it
+				if tt.delta < 0 {
+					house = update.Entry.Postings[1]
+				}
+				assert.Equal(t, "house:"+tt.sourceType+":EUR", house.AccountCode)
+				return decimal.NewFromInt(10 + tt.delta), nil
+			}
+			_, err := svc.ProcessTransaction(1, &transaction.Transaction{
+				TransactionID: "txn-" + string(tt.state),
+				SourceType:    tt.sourceType,
+				State:         tt.state,
+				Amount:        decimal.NewFromInt(tt.amount),
+				Bucket:        tt.bucket,
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTransactionService_ProcessTransaction_TypeRejected(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
+	mockUserRepo.GetWalletFunc = func(userID uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: userID, Currency: code, Balance: decimal.NewFromInt(10)}, nil
+	}
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(userID uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		t.Fatal("AtomicUpdateBalanceAndCreateTransactionFunc should not be called for a rejected transaction")
+		return decimal.Zero, nil
+	}
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	tests := []struct {
+		name  string
+		t     *transaction.Transaction
+		check func(error) bool
+	}{
+		{"deposit from a game", &transaction.Transaction{SourceType: "game", State: transaction.TypeDeposit}, appErrors.IsValidationError},
+		{"bonus grant on the real balance", &transaction.Transaction{SourceType: "server", State: transaction.TypeBonusGrant, Bucket: "real"}, appErrors.IsValidationError},
+		{"deposit in a round", &transaction.Transaction{SourceType: "payment", State: transaction.TypeDeposit, RoundID: "r-1"}, appErrors.IsValidationError},
+		{"transfer leg", &transaction.Transaction{SourceType: "game", State: transaction.TypeDebit}, appErrors.IsValidationError},
+		{"cancel without reference", &transaction.Transaction{SourceType: "game", State: transaction.TypeCancel}, appErrors.IsValidationError},
+		{"withdrawal beyond the balance", &transaction.Transaction{SourceType: "payment", State: transaction.TypeWithdrawal, Amount: decimal.NewFromInt(11)}, appErrors.IsInsufficientBalanceError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.t.TransactionID = "txn-rejected"
+			if tt.t.Amount.IsZero() {
+				tt.t.Amount = decimal.NewFromInt(1)
+			}
+			_, err := svc.ProcessTransaction(1, tt.t)
+			assert.True(t, tt.check(err), "got %v", err)
+		})
+	}
+}
+
+func TestTransactionService_ProcessTransaction_Optimistic_RetriesOnVersionConflict(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo, services.WithLockingStrategy(services.LockingOptimistic, 3))
+	mockUserRepo.GetByIDFunc = activeUser
+
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	userID := uint64(1)
+	version := uint64(7)
+	balance := decimal.NewFromFloat(100.00)
+
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: userID, Currency: code, Balance: balance, Version: version}, nil
+	}
+
+	attempts := 0
+	mockUserRepo.UpdateBalanceIfVersionMatchesFunc = func(
+		uid uint64,
+		expectedVersion uint64,
+		update user.BalanceUpdate,
+	) (decimal.Decimal, error) {
+		attempts++
+		assert.Equal(t, version, expectedVersion)
+		if attempts < 3 {
+			// Simulate a concurrent writer bumping the version between read and update.
+			version++
+			return decimal.Decimal{}, appErrors.NewVersionConflictError("conflict")
+		}
+		return balance.Add(update.Delta), nil
+	}
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(
+		uid uint64,
+		update user.BalanceUpdate,
+	) (decimal.Decimal, error) {
+		t.Fatal("AtomicUpdateBalanceAndCreateTransactionFunc should not be called with optimistic locking")
+		return decimal.Decimal{}, nil
+	}
+
+	reqTransaction := &transaction.Transaction{
+		TransactionID: "txn-optimistic-retry",
+		State:         "win",
+		Amount:        decimal.NewFromFloat(5.00),
+		SourceType:    "game",
+	}
+
+	_, err := svc.ProcessTransaction(userID, reqTransaction)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestTransactionService_ProcessTransaction_Optimistic_RetriesExhausted(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo, services.WithLockingStrategy(services.LockingOptimistic, 2))
+	mockUserRepo.GetByIDFunc = activeUser
+
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	userID := uint64(1)
+
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: userID, Currency: code, Balance: decimal.NewFromFloat(100.00)}, nil
+	}
+
+	attempts := 0
+	mockUserRepo.UpdateBalanceIfVersionMatchesFunc = func(
+		uid uint64,
+		expectedVersion uint64,
+		update user.BalanceUpdate,
+	) (decimal.Decimal, error) {
+		attempts++
+		return decimal.Decimal{}, appErrors.NewVersionConflictError("conflict")
+	}
+
+	reqTransaction := &transaction.Transaction{
+		TransactionID: "txn-optimistic-exhausted",
+		State:         "lose",
+		Amount:        decimal.NewFromFloat(5.00),
+		SourceType:    "game",
+	}
+
+	_, err := svc.ProcessTransaction(userID, reqTransaction)
+	assert.Error(t, err)
+	assert.True(t, appErrors.IsConflictError(err))
+	assert.Equal(t, 3, attempts) // initial attempt + 2 retries
+}
+
+func TestTransactionService_ListUserTransactions_ReturnsNextCursor(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	userID := uint64(1)
+	now := time.Now().UTC()
+
+	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+		return &user.User{ID: userID}, nil
+	}
+	mockTransactionRepo.ListByUserFunc = func(uid uint64, filter transaction.ListFilter) ([]transaction.Transaction, error) {
+		assert.Equal(t, userID, uid)
+		assert.Equal(t, 3, filter.Limit) // page size + 1
+		return []transaction.Transaction{
+			{ID: 30, ProcessedAt: now},
+			{ID: 20, ProcessedAt: now.Add(-time.Minute)},
+			{ID: 10, ProcessedAt: now.Add(-2 * time.Minute)},
+		}, nil
+	}
+
+	transactions, nextCursor, err := svc.ListUserTransactions(userID, transaction.ListFilter{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 2)
+	assert.NotEmpty(t, nextCursor)
+
+	cursor, err := transaction.DecodeCursor(nextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), cursor.ID)
+	assert.True(t, cursor.ProcessedAt.Equal(now.Add(-time.Minute)))
+}
+
+func TestTransactionService_ListUserTransactions_LastPage(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+		return &user.User{ID: id}, nil
+	}
+	mockTransactionRepo.ListByUserFunc = func(uid uint64, filter transaction.ListFilter) ([]transaction.Transaction, error) {
+		assert.Equal(t, services.DefaultHistoryPageSize+1, filter.Limit)
+		return []transaction.Transaction{{ID: 1}}, nil
+	}
+
+	transactions, nextCursor, err := svc.ListUserTransactions(1, transaction.ListFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 1)
+	assert.Empty(t, nextCursor)
+}
+
+func TestTransactionService_ListUserTransactions_UserNotFound(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	_, _, err := svc.ListUserTransactions(999, transaction.ListFilter{})
+	assert.Error(t, err)
+	assert.True(t, appErrors.IsNotFoundError(err))
+}
+
+func TestTransactionService_GetUserTransaction_OtherUser(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return &transaction.Transaction{UserID: 2, TransactionID: transactionID}, nil
+	}
+
+	txn, err := svc.GetUserTransaction(2, "game", "txn-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "txn-1", txn.TransactionID)
+
+	_, err = svc.GetUserTransaction(1, "game", "txn-1")
+	assert.Error(t, err)
+	assert.True(t, appErrors.IsNotFoundError(err))
+}
+
+func TestTransactionService_GetTransaction_NotFound(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	_, err := svc.GetTransaction("", "missing")
+	assert.Error(t, err)
+	assert.True(t, appErrors.IsNotFoundError(err))
+}
+
+func TestTransactionService_ProcessTransaction_ReplayReturnsStoredTransaction(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
+
+	userID := uint64(1)
+	stored := &transaction.Transaction{
+		ID:            42,
+		UserID:        userID,
+		TransactionID: "txn-replayed",
+		SourceType:    "game",
+		State:         "lose",
+		Currency:      "EUR",
+		Amount:        decimal.NewFromFloat(10.00),
+		BalanceAfter:  decimal.NewNullDecimal(decimal.NewFromFloat(90.00)),
+	}
+
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return stored, nil
+	}
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		t.Fatal("GetWalletFunc should not be called for a replay")
+		return nil, nil
+	}
+
+	reqTransaction := &transaction.Transaction{
+		TransactionID: "txn-replayed",
+		State:         "lose",
+		Amount:        decimal.NewFromFloat(10.00),
+		SourceType:    "game",
+	}
+
+	result, err := svc.ProcessTransaction(userID, reqTransaction)
+	assert.NoError(t, err)
+	assert.True(t, result.IdempotentReplay)
+	assert.Equal(t, uint64(42), result.Transaction.ID)
+	assert.True(t, result.Transaction.BalanceAfter.Decimal.Equal(decimal.NewFromFloat(90.00)))
+}
+
+func TestTransactionService_ProcessTransaction_ConcurrentDuplicateIsReplayed(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
+
+	userID := uint64(1)
+	lookups := 0
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		lookups++
+		if lookups == 1 {
+			return nil, sql.ErrNoRows // Not yet stored when the request arrives
+		}
+		return &transaction.Transaction{ID: 7, UserID: userID, TransactionID: transactionID, State: "win", Currency: "EUR", Amount: decimal.NewFromFloat(1.00), SourceType: "game"}, nil
+	}
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: userID, Currency: code, Balance: decimal.NewFromFloat(5.00)}, nil
+	}
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(
+		uid uint64,
+		update user.BalanceUpdate,
+	) (decimal.Decimal, error) {
+		return decimal.Decimal{}, appErrors.NewAlreadyProcessedError("transaction with this ID has already been processed")
+	}
+
+	reqTransaction := &transaction.Transaction{
+		TransactionID: "txn-raced",
+		State:         "win",
+		Amount:        decimal.NewFromFloat(1.00),
+		SourceType:    "game",
+	}
+
+	result, err := svc.ProcessTransaction(userID, reqTransaction)
+	assert.NoError(t, err)
+	assert.True(t, result.IdempotentReplay)
+	assert.Equal(t, uint64(7), result.Transaction.ID)
+}
+
+func TestTransactionService_ProcessTransaction_ReplayWithDifferentPayload(t *testing.T) {
+	userID := uint64(1)
+	original := &transaction.Transaction{
+		UserID:        userID,
+		TransactionID: "txn-fingerprinted",
+		SourceType:    "game",
+		State:         "win",
+		Currency:      "EUR",
+		Amount:        decimal.NewFromFloat(10.00),
+	}
+	original.RequestHash = original.Fingerprint()
+
+	testCases := []struct {
+		name   string
+		userID uint64
+		req    transaction.Transaction
+	}{
+		{"Amount", userID, transaction.Transaction{SourceType: "game", State: "win", Amount: decimal.NewFromFloat(10.01)}},
+		{"State", userID, transaction.Transaction{SourceType: "game", State: "lose", Amount: decimal.NewFromFloat(10.00)}},
+		{"User", 2, transaction.Transaction{SourceType: "game", State: "win", Amount: decimal.NewFromFloat(10.00)}},
+		{"Currency", userID, transaction.Transaction{SourceType: "game", State: "win", Currency: "USD", Amount: decimal.NewFromFloat(10.00)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepo := &mocks.MockUserRepository{}
+			mockTransactionRepo := &mocks.MockTransactionRepository{}
+			svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+			mockUserRepo.GetByIDFunc = activeUser
+
+			mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+				return original, nil
+			}
+
+			req := tc.req
+			req.TransactionID = original.TransactionID
+			_, err := svc.ProcessTransaction(tc.userID, &req)
+			assert.Error(t, err)
+			assert.True(t, appErrors.IsConflictError(err))
+		})
+	}
+}
+
+func chainRow(id uint64, state transaction.Type, amount, before, after float64) transaction.Transaction {
+	return transaction.Transaction{
+		ID:            id,
+		TransactionID: fmt.Sprintf("txn-%d", id),
+		State:         state,
+		Currency:      "EUR",
+		Amount:        decimal.NewFromFloat(amount),
+		BalanceBefore: decimal.NewNullDecimal(decimal.NewFromFloat(before)),
+		BalanceAfter:  decimal.NewNullDecimal(decimal.NewFromFloat(after)),
+	}
+}
+
+func TestTransactionService_VerifyTransactionChain_Consistent(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+		return &user.User{ID: id}, nil
+	}
+	mockUserRepo.ListWalletsFunc = func(id uint64) ([]user.Wallet, error) {
+		return []user.Wallet{{UserID: id, Currency: "EUR", Balance: decimal.NewFromFloat(10.00)}}, nil
+	}
+	reversed := uint64(2)
+	cancel := chainRow(3, "cancel", 4.00, 2.00, 6.00)
+	cancel.ReversesID = &reversed
+	rows := []transaction.Transaction{
+		{ID: 1, TransactionID: "legacy", State: "win", Currency: "EUR", Amount: decimal.NewFromFloat(1.00)}, // recorded before snapshots existed
+		chainRow(2, "lose", 4.00, 6.00, 2.00),
+		cancel,
+		chainRow(4, "deposit", 5.00, 6.00, 11.00),
+		chainRow(5, "fee", 1.00, 11.00, 10.00),
+	}
+	mockTransactionRepo.ListByUserInApplyOrderFunc = func(userID uint64, afterID uint64, limit int) ([]transaction.Transaction, error) {
+		var page []transaction.Transaction
+		for _, r := range rows {
+			if r.ID > afterID {
+				page = append(page, r)
+			}
+		}
+		return page, nil
+	}
+
+	report, err := svc.VerifyTransactionChain(1)
+	assert.NoError(t, err)
+	assert.True(t, report.Consistent())
+	assert.Equal(t, 4, report.Checked)
+	assert.Empty(t, report.Breaks)
+	assert.Len(t, report.Wallets, 1)
+	assert.True(t, report.Wallets[0].LastBalanceAfter.Decimal.Equal(decimal.NewFromFloat(10.00)))
+}
+
+func TestTransactionService_VerifyTransactionChain_Breaks(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+		return &user.User{ID: id}, nil
+	}
+	mockUserRepo.ListWalletsFunc = func(id uint64) ([]user.Wallet, error) {
+		return []user.Wallet{{UserID: id, Currency: "EUR", Balance: decimal.NewFromFloat(20.00)}}, nil
+	}
+	rows := []transaction.Transaction{
+		chainRow(1, "win", 10.00, 0.00, 10.00),
+		chainRow(2, "win", 5.00, 12.00, 17.00),  // does not start where row 1 ended
+		chainRow(3, "lose", 2.00, 17.00, 19.00), // moved the balance the wrong way
+	}
+	mockTransactionRepo.ListByUserInApplyOrderFunc = func(userID uint64, afterID uint64, limit int) ([]transaction.Transaction, error) {
+		var page []transaction.Transaction
+		for _, r := range rows {
+			if r.ID > afterID {
+				page = append(page, r)
+			}
+		}
+		return page, nil
+	}
+
+	report, err := svc.VerifyTransactionChain(1)
+	assert.NoError(t, err)
+	assert.False(t, report.Consistent())
+	assert.Len(t, report.Breaks, 2)
+	assert.Equal(t, uint64(2), report.Breaks[0].ID)
+	assert.Equal(t, uint64(3), report.Breaks[1].ID)
+	assert.True(t, report.Wallets[0].Balance.Decimal.Equal(decimal.NewFromFloat(20.00)))
+}
+
+func TestTransactionService_VerifyTransactionChain_UserNotFound(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+
+	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	_, err := svc.VerifyTransactionChain(99)
+	assert.True(t, appErrors.IsNotFoundError(err))
+}
+
+func TestTransactionService_ProcessTransaction_Currency(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo, services.WithDefaultCurrency("USD"))
+
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+	// The user has no wallet in the currency yet; it is opened by the balance update.
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return nil, sql.ErrNoRows
+	}
+	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+		return &user.User{ID: id}, nil
+	}
+	var updated []user.BalanceUpdate
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		updated = append(updated, update)
+		return update.Delta, nil
+	}
+
+	result, err := svc.ProcessTransaction(1, &transaction.Transaction{
+		TransactionID: "txn-default-currency", State: "win", Amount: decimal.NewFromFloat(2.50), SourceType: "game",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", result.Transaction.Currency)
+
+	_, err = svc.ProcessTransaction(1, &transaction.Transaction{
+		TransactionID: "txn-jpy", State: "win", Currency: "JPY", Amount: decimal.NewFromInt(500), SourceType: "game",
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, updated, 2) {
+		assert.Equal(t, "USD", updated[0].Currency)
+		assert.Equal(t, "user:1:USD", updated[0].Entry.Postings[1].AccountCode)
+		assert.Equal(t, "JPY", updated[1].Currency)
+	}
+
+	testCases := []struct {
+		name     string
+		currency string
+		amount   decimal.Decimal
+	}{
+		{"UnknownCurrency", "XXY", decimal.NewFromInt(1)},
+		{"TooPreciseForJPY", "JPY", decimal.NewFromFloat(1.5)},
+		{"TooPreciseForEUR", "EUR", decimal.NewFromFloat(1.005)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := svc.ProcessTransaction(1, &transaction.Transaction{
+				TransactionID: "txn-" + tc.name, State: "win", Currency: tc.currency, Amount: tc.amount, SourceType: "game",
+			})
+			assert.True(t, appErrors.IsValidationError(err))
+		})
+	}
+	assert.Len(t, updated, 2)
+}
+
+func TestTransactionService_ProcessTransaction_ConvertsToWalletCurrency(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	mockRates := &mocks.MockRateProvider{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo, services.WithRateProvider(mockRates))
+	mockUserRepo.GetByIDFunc = activeUser
+
+	asOf := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rate := decimal.RequireFromString("0.9137")
+	mockRates.RateFunc = func(from, to string) (currency.Rate, error) {
+		assert.Equal(t, "USD", from)
+		assert.Equal(t, "EUR", to)
+		return currency.Rate{From: from, To: to, Value: rate, AsOf: asOf}, nil
+	}
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: id, Currency: code, Balance: decimal.NewFromInt(100)}, nil
+	}
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		assert.Equal(t, "EUR", update.Currency)
+		// 10.00 USD * 0.9137 = 9.137, rounded half to even to 9.14 EUR
+		assert.True(t, update.Delta.Equal(decimal.RequireFromString("-9.14")), "delta %s", update.Delta)
+		assert.Equal(t, "user:1:EUR", update.Entry.Postings[0].AccountCode)
+		return decimal.NewFromInt(100).Add(update.Delta), nil
+	}
+
+	reqTransaction := &transaction.Transaction{
+		TransactionID:    "txn-usd",
+		State:            "lose",
+		Currency:         "EUR",
+		OriginalCurrency: "USD",
+		OriginalAmount:   decimal.NewNullDecimal(decimal.NewFromInt(10)),
+		SourceType:       "game",
+	}
+	result, err := svc.ProcessTransaction(1, reqTransaction)
+	assert.NoError(t, err)
+	assert.True(t, result.Transaction.Amount.Equal(decimal.RequireFromString("9.14")))
+	assert.True(t, result.Transaction.ExchangeRate.Decimal.Equal(rate))
+	assert.Equal(t, asOf, *result.Transaction.RateTimestamp)
+
+	// A replay is matched on the amount as sent, even after the rate has moved.
+	stored := *result.Transaction
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return &stored, nil
+	}
+	rate = decimal.RequireFromString("0.95")
+	replayed, err := svc.ProcessTransaction(1, &transaction.Transaction{
+		TransactionID:    "txn-usd",
+		State:            "lose",
+		Currency:         "EUR",
+		OriginalCurrency: "USD",
+		OriginalAmount:   decimal.NewNullDecimal(decimal.NewFromInt(10)),
+		SourceType:       "game",
+	})
+	assert.NoError(t, err)
+	assert.True(t, replayed.IdempotentReplay)
+}
+
+func TestTransactionService_ProcessTransaction_ConversionUnavailable(t *testing.T) {
+	newRequest := func() *transaction.Transaction {
+		return &transaction.Transaction{
+			TransactionID:    "txn-gbp",
+			State:            "win",
+			Currency:         "EUR",
+			OriginalCurrency: "GBP",
+			OriginalAmount:   decimal.NewNullDecimal(decimal.NewFromInt(5)),
+			SourceType:       "game",
+		}
+	}
+
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	// Without a rate provider, conversions are refused.
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
+	_, err := svc.ProcessTransaction(1, newRequest())
+	assert.True(t, appErrors.IsValidationError(err))
+
+	mockRates := &mocks.MockRateProvider{}
+	mockRates.RateFunc = func(from, to string) (currency.Rate, error) {
+		return currency.Rate{}, currency.ErrRateNotFound
+	}
+	svc = services.NewTransactionService(mockUserRepo, mockTransactionRepo, services.WithRateProvider(mockRates))
+	_, err = svc.ProcessTransaction(1, newRequest())
+	assert.True(t, appErrors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "no exchange rate from GBP to EUR")
+}
+
+func TestTransactionService_ProcessTransaction_BonusSplit(t *testing.T) {
+	// The wallet holds 30.00 real and 10.00 bonus money.
+	wallet := user.Wallet{UserID: 1, Currency: "EUR", Balance: decimal.NewFromInt(40), BonusBalance: decimal.NewFromInt(10)}
+
+	tests := []struct {
+		name       string
+		order      user.DebitOrder
+		state      transaction.Type
+		bucket     string
+		amount     string
+		wantBonus  string
+		wantReject bool
+	}{
+		{name: "lose real first", order: user.DebitRealFirst, state: "lose", amount: "35.00", wantBonus: "-5"},
+		{name: "lose covered by real", order: user.DebitRealFirst, state: "lose", amount: "20.00", wantBonus: "0"},
+		{name: "lose bonus first", order: user.DebitBonusFirst, state: "lose", amount: "15.00", wantBonus: "-10"},
+		{name: "lose from bonus bucket", order: user.DebitRealFirst, state: "lose", bucket: "bonus", amount: "8.00", wantBonus: "-8"},
+		{name: "lose exceeding bonus bucket", order: user.DebitRealFirst, state: "lose", bucket: "bonus", amount: "12.00", wantReject: true},
+		{name: "win split proportionally", order: user.DebitRealFirst, state: "win", amount: "10.01", wantBonus: "2.50"},
+		{name: "win to real bucket", order: user.DebitRealFirst, state: "win", bucket: "real", amount: "10.00", wantBonus: "0"},
+		{name: "win to bonus bucket", order: user.DebitRealFirst, state: "win", bucket: "bonus", amount: "10.00", wantBonus: "10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := &mocks.MockUserRepository{}
+			mockTransactionRepo := &mocks.MockTransactionRepository{}
+			svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo, services.WithDebitOrder(tt.order))
+			mockUserRepo.GetByIDFunc = activeUser
+
+			mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+				w := wallet
+				return &w, nil
+			}
+			mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+				return nil, sql.ErrNoRows
+			}
+			calls := 0
+			mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+				calls++
+				bonusDelta := wallet.BonusDelta(update.Delta, update.Allocation)
+				assert.True(t, bonusDelta.Equal(decimal.RequireFromString(tt.wantBonus)), "bonus delta %s", bonusDelta)
+				return wallet.Balance.Add(update.Delta), nil
+			}
+
+			_, err := svc.ProcessTransaction(1, &transaction.Transaction{
+				TransactionID: "txn-bonus", SourceType: "game", State: tt.state, Bucket: tt.bucket, Amount: decimal.RequireFromString(tt.amount),
+			})
+			if tt.wantReject {
+				assert.True(t, appErrors.IsValidationError(err))
+				assert.Contains(t, err.Error(), "insufficient bonus balance")
+				assert.Equal(t, 0, calls)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestTransactionService_ProcessTransaction_CreditLimit(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	mockTransactionRepo := &mocks.MockTransactionRepository{}
+	svc := services.NewTransactionService(mockUserRepo, mockTransactionRepo)
+	mockUserRepo.GetByIDFunc = activeUser
+
+	mockUserRepo.GetWalletFunc = func(id uint64, code string) (*user.Wallet, error) {
+		return &user.Wallet{UserID: id, Currency: code, Balance: decimal.NewFromInt(10), CreditLimit: decimal.NewFromInt(50)}, nil
+	}
+	mockTransactionRepo.GetByTransactionIDFunc = func(sourceType, transactionID string) (*transaction.Transaction, error) {
+		return nil, sql.ErrNoRows
+	}
+	calls := 0
+	mockUserRepo.AtomicUpdateBalanceAndCreateTransactionFunc = func(uid uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+		calls++
+		assert.False(t, update.AllowNegative)
+		return decimal.NewFromInt(-50), nil
+	}
+
+	_, err := svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "txn-credit", SourceType: "game", State: "lose", Amount: decimal.NewFromInt(60)})
+	assert.NoError(t, err)
+
+	_, err = svc.ProcessTransaction(1, &transaction.Transaction{TransactionID: "txn-over-credit", SourceType: "game", State: "lose", Amount: decimal.RequireFromString("60.01")})
+	assert.True(t, appErrors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "credit limit of 50.00 EUR")
+	assert.Equal(t, 1, calls)
+}
+
+func TestTransactionService_SetCreditLimit(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	svc := services.NewTransactionService(mockUserRepo, &mocks.MockTransactionRepository{})
+
+	mockUserRepo.GetByIDFunc = func(id uint64) (*user.User, error) {
+		if id == 1 {
+			return &user.User{ID: 1}, nil
+		}
+		return nil, sql.ErrNoRows
+	}
+	mockUserRepo.SetCreditLimitFunc = func(userID uint64, code string, limit decimal.Decimal) (*user.Wallet, error) {
+		assert.Equal(t, "EUR", code)
+		return &user.Wallet{UserID: userID, Currency: code, CreditLimit: limit}, nil
+	}
+
+	w, err := svc.SetCreditLimit(1, "", decimal.NewFromInt(100))
+	assert.NoError(t, err)
+	assert.True(t, w.CreditLimit.Equal(decimal.NewFromInt(100)))
+
+	_, err = svc.SetCreditLimit(1, "", decimal.NewFromInt(-1))
+	assert.True(t, appErrors.IsValidationError(err))
+	_, err = svc.SetCreditLimit(1, "", decimal.RequireFromString("0.001"))
+	assert.True(t, appErrors.IsValidationError(err))
+	_, err = svc.SetCreditLimit(2, "", decimal.NewFromInt(100))
+	assert.True(t, appErrors.IsNotFoundError(err))
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/transfer"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// WithTransfers enables transfers of funds between users.
+func WithTransfers(repo transfer.Repository) Option {
+	return func(s *TransactionService) {
+		s.transferRepo = repo
+	}
+}
+
+// TransferResult describes a transfer and the transactions recording its two legs.
+type TransferResult struct {
+	Transfer *transfer.Transfer
+	Debit    *transaction.Transaction
+	Credit   *transaction.Transaction
+	// IdempotentReplay is true when the request matched an already processed transferId.
+	IdempotentReplay bool
+}
+
+// Transfer moves req.Amount of real money from req.FromUserID to req.ToUserID. The debit of the
+// sender and the credit of the receiver are applied in one database transaction, so either both
+// happen or neither does. Transfers always lock pessimistically, whatever the configured locking
+// strategy, and are not subject to loss limits. Repeating a transferId replays the stored result.
+func (s *TransactionService) Transfer(req *transfer.Transfer) (*TransferResult, error) {
+	if s.transferRepo == nil {
+		return nil, appErrors.NewValidationError("transfers are not available")
+	}
+	if req.TransferID == "" || len(req.TransferID) > transfer.MaxTransferIDLength {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("transferId must be 1 to %d characters", transfer.MaxTransferIDLength))
+	}
+	if req.FromUserID == req.ToUserID {
+		return nil, appErrors.NewValidationError("cannot transfer funds to the same user")
+	}
+	if req.Currency == "" {
+		req.Currency = s.defaultCurrency
+	}
+	if err := validateAmount(req.Amount, req.Currency); err != nil {
+		return nil, err
+	}
+	if !req.Amount.IsPositive() {
+		return nil, appErrors.NewValidationError("amount must be positive")
+	}
+
+	existing, err := s.findTransfer(req.TransferID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return s.replayTransfer(existing, req)
+	}
+
+	sender, err := s.getUser(req.FromUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatus(sender, true); err != nil {
+		return nil, err
+	}
+	receiver, err := s.getUser(req.ToUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatus(receiver, false); err != nil {
+		return nil, err
+	}
+
+	// Bonus money stays with the user it was granted to.
+	allocation := user.Allocation{Bucket: user.BucketReal}
+	delta := req.Amount.Neg()
+	w, err := s.currentWallet(req.FromUserID, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if w.Spendable().LessThan(req.Amount) {
+		return nil, w.InsufficientBalanceError()
+	}
+	if overdrawn := w.Overdrawn(delta, w.BonusDelta(delta, allocation)); overdrawn != "" {
+		return nil, w.InsufficientBucketError(overdrawn)
+	}
+
+	debit := user.BalanceUpdate{Delta: delta, Allocation: allocation}
+	if err := s.completeUpdate(&debit, transferLeg(req, transfer.StateDebit, req.FromUserID)); err != nil {
+		return nil, err
+	}
+	credit := user.BalanceUpdate{Delta: req.Amount, Allocation: allocation}
+	if err := s.completeUpdate(&credit, transferLeg(req, transfer.StateCredit, req.ToUserID)); err != nil {
+		return nil, err
+	}
+
+	if err := s.transferRepo.Execute(req, debit, credit); err != nil {
+		if appErrors.IsAlreadyProcessedError(err) {
+			// A concurrent request with the same transferId won the race after our pre-check.
+			existing, findErr := s.findTransfer(req.TransferID)
+			if findErr != nil {
+				return nil, findErr
+			}
+			if existing == nil {
+				return nil, fmt.Errorf("transfer %s reported as processed but not found", req.TransferID)
+			}
+			return s.replayTransfer(existing, req)
+		}
+		if appErrors.IsNotFoundError(err) || appErrors.IsValidationError(err) || appErrors.IsConflictError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to execute transfer: %w", err)
+	}
+
+	log.Printf("Transferred %s %s from user %d to user %d (transfer %s)", currency.Format(req.Currency, req.Amount),
+		req.Currency, req.FromUserID, req.ToUserID, req.TransferID)
+	return &TransferResult{Transfer: req, Debit: debit.Transaction, Credit: credit.Transaction}, nil
+}
+
+// transferLeg returns the transaction recording the leg of t in the given state for userID.
+func transferLeg(t *transfer.Transfer, state transaction.Type, userID uint64) *transaction.Transaction {
+	leg := &transaction.Transaction{
+		UserID:        userID,
+		TransactionID: t.LegID(state),
+		SourceType:    transfer.SourceType,
+		State:         state,
+		Currency:      t.Currency,
+		Amount:        t.Amount,
+		Bucket:        string(user.BucketReal),
+	}
+	leg.RequestHash = leg.Fingerprint()
+	return leg
+}
+
+func (s *TransactionService) replayTransfer(existing, req *transfer.Transfer) (*TransferResult, error) {
+	if !existing.MatchesRequest(req) {
+		return nil, appErrors.NewConflictError("transfer with this ID has already been processed with a different payload")
+	}
+	log.Printf("Transfer %s already processed. Replaying stored result.", req.TransferID)
+	result, err := s.transferResult(existing)
+	if err != nil {
+		return nil, err
+	}
+	result.IdempotentReplay = true
+	return result, nil
+}
+
+// GetTransfer returns the transfer with the given external ID and its legs.
+func (s *TransactionService) GetTransfer(transferID string) (*TransferResult, error) {
+	if s.transferRepo == nil {
+		return nil, appErrors.NewValidationError("transfers are not available")
+	}
+	t, err := s.findTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("transfer with ID %s not found", transferID))
+	}
+	return s.transferResult(t)
+}
+
+// transferResult loads the legs of the stored transfer t.
+func (s *TransactionService) transferResult(t *transfer.Transfer) (*TransferResult, error) {
+	debit, err := s.storedTransferLeg(t, transfer.StateDebit)
+	if err != nil {
+		return nil, err
+	}
+	credit, err := s.storedTransferLeg(t, transfer.StateCredit)
+	if err != nil {
+		return nil, err
+	}
+	return &TransferResult{Transfer: t, Debit: debit, Credit: credit}, nil
+}
+
+func (s *TransactionService) storedTransferLeg(t *transfer.Transfer, state transaction.Type) (*transaction.Transaction, error) {
+	leg, err := s.findProcessedTransaction(transfer.SourceType, t.LegID(state))
+	if err != nil {
+		return nil, err
+	}
+	if leg == nil {
+		return nil, fmt.Errorf("%s leg of transfer %s not found", state, t.TransferID)
+	}
+	return leg, nil
+}
+
+// findTransfer returns the transfer with the given external ID, or nil if there is none.
+func (s *TransactionService) findTransfer(transferID string) (*transfer.Transfer, error) {
+	t, err := s.transferRepo.GetByTransferID(transferID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for existing transfer: %w", err)
+	}
+	return t, nil
+}
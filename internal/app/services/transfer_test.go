@@ -0,0 +1,127 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/transfer"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// transferRepo returns a transfer repository that has stored the given transfers.
+func transferRepo(transfers ...*transfer.Transfer) *mocks.MockTransferRepository {
+	mockTransferRepo := &mocks.MockTransferRepository{}
+	mockTransferRepo.GetByTransferIDFunc = func(transferID string) (*transfer.Transfer, error) {
+		for _, t := range transfers {
+			if t.TransferID == transferID {
+				return t, nil
+			}
+		}
+		return nil, sql.ErrNoRows
+	}
+	return mockTransferRepo
+}
+
+// withTransferUsers makes users 1 and 2 active and user 3 frozen; only user 1 has a wallet, holding 10.
+func withTransferUsers() testOption {
+	return func(r *testRepos) {
+		withUsers(user.User{ID: 1}, user.User{ID: 2}, user.User{ID: 3, Status: user.StatusFrozen})(r)
+		withBalances(map[uint64]int64{1: 10})(r)
+	}
+}
+
+func TestTransactionService_Transfer(t *testing.T) {
+	mockTransferRepo := transferRepo()
+	svc, _ := newTestService(withTransferUsers(), withOptions(services.WithTransfers(mockTransferRepo)))
+	mockTransferRepo.ExecuteFunc = func(tr *transfer.Transfer, debit, credit user.BalanceUpdate) error {
+		assert.Equal(t, "payout-1", tr.TransferID)
+		assert.Equal(t, "EUR", tr.Currency)
+
+		assert.Equal(t, decimal.NewFromInt(-4), debit.Delta)
+		assert.Equal(t, user.BucketReal, debit.Allocation.Bucket)
+		assert.Equal(t, uint64(1), debit.Transaction.UserID)
+		assert.Equal(t, "payout-1:debit", debit.Transaction.TransactionID)
+		assert.Equal(t, transfer.StateDebit, debit.Transaction.State)
+
+		assert.Equal(t, decimal.NewFromInt(4), credit.Delta)
+		assert.Equal(t, uint64(2), credit.Transaction.UserID)
+		assert.Equal(t, "payout-1:credit", credit.Transaction.TransactionID)
+		assert.Equal(t, transfer.SourceType, credit.Transaction.SourceType)
+
+		// Both legs pass through the transfer house account, which nets to zero.
+		if assert.NotNil(t, debit.Entry) && assert.NotNil(t, credit.Entry) {
+			assert.Equal(t, "house:transfer:EUR", debit.Entry.Postings[1].AccountCode)
+			assert.Equal(t, "house:transfer:EUR", credit.Entry.Postings[0].AccountCode)
+		}
+		tr.ID = 9
+		return nil
+	}
+
+	result, err := svc.Transfer(&transfer.Transfer{TransferID: "payout-1", FromUserID: 1, ToUserID: 2, Amount: decimal.NewFromInt(4)})
+	assert.NoError(t, err)
+	assert.False(t, result.IdempotentReplay)
+	assert.Equal(t, uint64(9), result.Transfer.ID)
+	assert.Equal(t, "payout-1:debit", result.Debit.TransactionID)
+	assert.Equal(t, "payout-1:credit", result.Credit.TransactionID)
+}
+
+func TestTransactionService_Transfer_Rejected(t *testing.T) {
+	mockTransferRepo := transferRepo()
+	svc, _ := newTestService(withTransferUsers(), withOptions(services.WithTransfers(mockTransferRepo)))
+	mockTransferRepo.ExecuteFunc = func(tr *transfer.Transfer, debit, credit user.BalanceUpdate) error {
+		t.Fatal("ExecuteFunc should not be called for a rejected transfer")
+		return nil
+	}
+
+	tests := []struct {
+		name  string
+		req   *transfer.Transfer
+		check func(error) bool
+	}{
+		{"insufficient balance", &transfer.Transfer{TransferID: "r-1", FromUserID: 1, ToUserID: 2, Amount: decimal.NewFromInt(11)}, appErrors.IsInsufficientBalanceError},
+		{"same user", &transfer.Transfer{TransferID: "r-2", FromUserID: 1, ToUserID: 1, Amount: decimal.NewFromInt(1)}, appErrors.IsValidationError},
+		{"too precise", &transfer.Transfer{TransferID: "r-3", FromUserID: 1, ToUserID: 2, Amount: decimal.RequireFromString("0.001")}, appErrors.IsValidationError},
+		{"unknown receiver", &transfer.Transfer{TransferID: "r-4", FromUserID: 1, ToUserID: 4, Amount: decimal.NewFromInt(1)}, appErrors.IsNotFoundError},
+		{"restricted receiver", &transfer.Transfer{TransferID: "r-5", FromUserID: 1, ToUserID: 3, Amount: decimal.NewFromInt(1)}, appErrors.IsAccountRestrictedError},
+		{"missing transferId", &transfer.Transfer{FromUserID: 1, ToUserID: 2, Amount: decimal.NewFromInt(1)}, appErrors.IsValidationError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Transfer(tt.req)
+			assert.True(t, tt.check(err), "got %v", err)
+		})
+	}
+}
+
+func TestTransactionService_Transfer_Replay(t *testing.T) {
+	stored := &transfer.Transfer{ID: 5, TransferID: "payout-1", FromUserID: 1, ToUserID: 2, Currency: "EUR", Amount: decimal.NewFromInt(4)}
+	debit := &transaction.Transaction{ID: 11, UserID: 1, TransactionID: "payout-1:debit", SourceType: transfer.SourceType, TransferID: &stored.ID}
+	credit := &transaction.Transaction{ID: 12, UserID: 2, TransactionID: "payout-1:credit", SourceType: transfer.SourceType, TransferID: &stored.ID}
+	mockTransferRepo := transferRepo(stored)
+	svc, _ := newTestService(withTransferUsers(), withTransactions(debit, credit), withOptions(services.WithTransfers(mockTransferRepo)))
+	mockTransferRepo.ExecuteFunc = func(tr *transfer.Transfer, debit, credit user.BalanceUpdate) error {
+		t.Fatal("ExecuteFunc should not be called for a replayed transfer")
+		return nil
+	}
+
+	result, err := svc.Transfer(&transfer.Transfer{TransferID: "payout-1", FromUserID: 1, ToUserID: 2, Amount: decimal.NewFromInt(4)})
+	assert.NoError(t, err)
+	assert.True(t, result.IdempotentReplay)
+	assert.Same(t, debit, result.Debit)
+	assert.Same(t, credit, result.Credit)
+
+	_, err = svc.Transfer(&transfer.Transfer{TransferID: "payout-1", FromUserID: 1, ToUserID: 2, Amount: decimal.NewFromInt(5)})
+	assert.True(t, appErrors.IsConflictError(err))
+
+	found, err := svc.GetTransfer("payout-1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), found.Transfer.ID)
+	_, err = svc.GetTransfer("payout-2")
+	assert.True(t, appErrors.IsNotFoundError(err))
+}
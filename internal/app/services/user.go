@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+const (
+	DefaultUserPageSize = 50
+	MaxUserPageSize     = 100
+)
+
+// NewUserRequest describes a user to provision.
+type NewUserRequest struct {
+	// ExternalRef optionally references the user in the operator's own systems; it must be unique.
+	ExternalRef string
+	// Currency of the wallet opened for the user; the default currency when empty.
+	Currency string
+	// Status is the initial account status, active when empty. Accounts cannot be created closed.
+	Status user.Status
+	// Until is the end of the self-exclusion when Status is self_excluded.
+	Until *time.Time
+}
+
+// CreateUser provisions a user with an empty wallet in the requested currency.
+func (s *TransactionService) CreateUser(req NewUserRequest) (*user.User, error) {
+	code := req.Currency
+	if code == "" {
+		code = s.defaultCurrency
+	}
+	if !currency.Valid(code) {
+		return nil, appErrors.NewValidationError(fmt.Sprintf("unsupported currency %q", code))
+	}
+
+	status := req.Status
+	if status == "" {
+		status = user.StatusActive
+	}
+	switch status {
+	case user.StatusActive, user.StatusFrozen:
+		if req.Until != nil {
+			return nil, appErrors.NewValidationError("an end date is only allowed for a self-exclusion")
+		}
+	case user.StatusSelfExcluded:
+		if req.Until == nil || !req.Until.After(time.Now()) {
+			return nil, appErrors.NewValidationError("a self-exclusion needs an end date in the future")
+		}
+	default:
+		return nil, appErrors.NewValidationError(fmt.Sprintf("users cannot be created with status %q", status))
+	}
+
+	u := &user.User{Status: status, SelfExcludedUntil: req.Until}
+	if ref := strings.TrimSpace(req.ExternalRef); ref != "" {
+		u.ExternalRef = &ref
+	}
+	if err := s.userRepo.CreateWithWallet(u, code); err != nil {
+		if appErrors.IsConflictError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	log.Printf("User %d created with a %s wallet and status %s", u.ID, code, u.Status)
+	return u, nil
+}
+
+// GetUser returns the user or a not found error.
+func (s *TransactionService) GetUser(userID uint64) (*user.User, error) {
+	return s.getUser(userID)
+}
+
+// ListUsers returns one page of users ordered by ID, together with the cursor for the next page
+// (empty when there are no more users).
+func (s *TransactionService) ListUsers(filter user.ListFilter) ([]user.User, string, error) {
+	if filter.Status != "" && !user.ValidStatus(filter.Status) {
+		return nil, "", appErrors.NewValidationError(fmt.Sprintf("unknown account status %q", filter.Status))
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultUserPageSize
+	}
+	if filter.Limit > MaxUserPageSize {
+		filter.Limit = MaxUserPageSize
+	}
+	pageSize := filter.Limit
+	// Fetch one extra row to find out whether another page exists.
+	filter.Limit++
+
+	users, err := s.userRepo.List(filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		nextCursor = strconv.FormatUint(users[pageSize-1].ID, 10)
+	}
+	return users, nextCursor, nil
+}
@@ -0,0 +1,76 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/internal/mocks"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+func TestTransactionService_CreateUser(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	svc := services.NewTransactionService(mockUserRepo, &mocks.MockTransactionRepository{}, services.WithDefaultCurrency("USD"))
+
+	var walletCurrency string
+	mockUserRepo.CreateWithWalletFunc = func(u *user.User, code string) error {
+		walletCurrency = code
+		u.ID = 42
+		return nil
+	}
+
+	u, err := svc.CreateUser(services.NewUserRequest{ExternalRef: " crm-1 "})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), u.ID)
+	assert.Equal(t, user.StatusActive, u.Status)
+	if assert.NotNil(t, u.ExternalRef) {
+		assert.Equal(t, "crm-1", *u.ExternalRef)
+	}
+	assert.Equal(t, "USD", walletCurrency)
+
+	u, err = svc.CreateUser(services.NewUserRequest{Currency: "EUR", Status: user.StatusFrozen})
+	assert.NoError(t, err)
+	assert.Nil(t, u.ExternalRef)
+	assert.Equal(t, user.StatusFrozen, u.Status)
+	assert.Equal(t, "EUR", walletCurrency)
+
+	until := time.Now().Add(time.Hour)
+	u, err = svc.CreateUser(services.NewUserRequest{Status: user.StatusSelfExcluded, Until: &until})
+	assert.NoError(t, err)
+	assert.Equal(t, &until, u.SelfExcludedUntil)
+
+	_, err = svc.CreateUser(services.NewUserRequest{Status: user.StatusSelfExcluded})
+	assert.True(t, appErrors.IsValidationError(err))
+	_, err = svc.CreateUser(services.NewUserRequest{Status: user.StatusClosed})
+	assert.True(t, appErrors.IsValidationError(err))
+	_, err = svc.CreateUser(services.NewUserRequest{Currency: "XXX"})
+	assert.True(t, appErrors.IsValidationError(err))
+
+	mockUserRepo.CreateWithWalletFunc = func(u *user.User, code string) error {
+		return appErrors.NewConflictError("a user with external reference \"crm-1\" already exists")
+	}
+	_, err = svc.CreateUser(services.NewUserRequest{ExternalRef: "crm-1"})
+	assert.True(t, appErrors.IsConflictError(err))
+}
+
+func TestTransactionService_ListUsers(t *testing.T) {
+	mockUserRepo := &mocks.MockUserRepository{}
+	svc := services.NewTransactionService(mockUserRepo, &mocks.MockTransactionRepository{})
+
+	mockUserRepo.ListFunc = func(filter user.ListFilter) ([]user.User, error) {
+		assert.Equal(t, 3, filter.Limit, "one extra row is fetched to detect the next page")
+		assert.Equal(t, uint64(10), filter.AfterID)
+		return []user.User{{ID: 11}, {ID: 12}, {ID: 13}}, nil
+	}
+
+	users, next, err := svc.ListUsers(user.ListFilter{AfterID: 10, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "12", next)
+
+	_, _, err = svc.ListUsers(user.ListFilter{Status: "suspended"})
+	assert.True(t, appErrors.IsValidationError(err))
+}
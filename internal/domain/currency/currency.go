@@ -0,0 +1,66 @@
+package currency
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxMinorUnits is the largest number of decimal places used by an ISO 4217 currency.
+// Amount columns are sized for it; the precision of each amount is enforced per currency.
+const MaxMinorUnits = 4
+
+const defaultMinorUnits = 2
+
+// minorUnits lists the ISO 4217 currencies that do not use two decimal places.
+var minorUnits = map[string]int32{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// codes holds the active ISO 4217 currency codes, excluding funds, precious metals and testing codes.
+var codes = map[string]struct{}{}
+
+func init() {
+	for _, code := range strings.Fields(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BRL BSD BTN BWP
+		BYN BZD CAD CDF CHF CLF CLP CNY COP CRC CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP
+		GBP GEL GHS GIP GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS
+		KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR
+		MWK MXN MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF
+		SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD
+		TZS UAH UGX USD UYI UYU UYW UZS VES VND VUV WST XAF XCD XOF XPF YER ZAR ZMW ZWL`) {
+		codes[code] = struct{}{}
+	}
+}
+
+// Valid reports whether code is a supported ISO 4217 currency code.
+func Valid(code string) bool {
+	_, ok := codes[code]
+	return ok
+}
+
+// MinorUnits returns the number of decimal places used by the currency.
+func MinorUnits(code string) int32 {
+	if units, ok := minorUnits[code]; ok {
+		return units
+	}
+	return defaultMinorUnits
+}
+
+// CheckAmount verifies that amount can be expressed in the currency's minor units.
+func CheckAmount(code string, amount decimal.Decimal) error {
+	units := MinorUnits(code)
+	if !amount.Equal(amount.Truncate(units)) {
+		return fmt.Errorf("amount %s has more than %d decimal places allowed for %s", amount.String(), units, code)
+	}
+	return nil
+}
+
+// Format renders amount with the currency's number of decimal places.
+func Format(code string, amount decimal.Decimal) string {
+	return amount.StringFixed(MinorUnits(code))
+}
@@ -0,0 +1,31 @@
+package currency
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrRateNotFound is returned by a RateProvider that has no rate for the requested pair.
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// Rate converts amounts in From into To: an amount a in From is worth a * Value in To.
+type Rate struct {
+	From  string
+	To    string
+	Value decimal.Decimal
+	// AsOf is when the rate was published by its source.
+	AsOf time.Time
+}
+
+// Convert returns amount expressed in the target currency, rounded half to even to its minor units.
+func (r Rate) Convert(amount decimal.Decimal) decimal.Decimal {
+	return amount.Mul(r.Value).RoundBank(MinorUnits(r.To))
+}
+
+// RateProvider supplies exchange rates used to convert amounts into wallet currencies.
+type RateProvider interface {
+	// Rate returns the current rate from one currency into another, or ErrRateNotFound.
+	Rate(from, to string) (Rate, error)
+}
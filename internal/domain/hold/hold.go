@@ -0,0 +1,62 @@
+package hold
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+)
+
+// Status is the lifecycle state of a hold. Only active holds reserve funds.
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusCaptured Status = "captured" // Settled by a "lose" transaction
+	StatusReleased Status = "released"
+	StatusExpired  Status = "expired" // Released by the sweeper after its TTL
+)
+
+// Hold reserves part of a wallet's balance, e.g. the stake of a bet between placement and
+// settlement. Reserved funds count towards the balance but not towards the available balance.
+type Hold struct {
+	ID         uint64          `json:"id" gorm:"primaryKey"`
+	UserID     uint64          `json:"userId" gorm:"not null;index"`
+	HoldID     string          `json:"holdId" gorm:"not null;uniqueIndex:idx_holds_source_hold_id,priority:2"` // External ID for idempotency, unique per source
+	SourceType string          `json:"sourceType" gorm:"not null;uniqueIndex:idx_holds_source_hold_id,priority:1"`
+	Currency   string          `json:"currency" gorm:"type:varchar(3);not null"`
+	Amount     decimal.Decimal `json:"amount" gorm:"type:numeric(24,4);not null"`
+	Status     Status          `json:"status" gorm:"type:varchar(10);not null;index:idx_holds_status_expires_at,priority:1"`
+	ExpiresAt  time.Time       `json:"expiresAt" gorm:"not null;index:idx_holds_status_expires_at,priority:2"`
+	// TransactionID is the internal ID of the "lose" transaction that captured the hold.
+	TransactionID *uint64   `json:"transactionId,omitempty"`
+	CreatedAt     time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// Expired reports whether the hold's TTL has passed at now.
+func (h *Hold) Expired(now time.Time) bool {
+	return !now.Before(h.ExpiresAt)
+}
+
+// MatchesRequest reports whether the stored hold was placed by a request equivalent to req.
+func (h *Hold) MatchesRequest(req *Hold) bool {
+	return h.UserID == req.UserID && h.Currency == req.Currency && h.Amount.Equal(req.Amount)
+}
+
+type Repository interface {
+	// Place stores the active hold and reserves its amount on the user's wallet if the available
+	// balance, including the wallet's credit limit, covers it and the amount is within the loss limits.
+	Place(h *Hold, lossLimits []limit.Limit) error
+	// GetByHoldID returns sql.ErrNoRows if the source has no hold with this ID.
+	GetByHoldID(sourceType, holdID string) (*Hold, error)
+	// Capture marks the active hold as captured by the update's transaction and applies the update,
+	// which debits the held amount, in the same database transaction, freeing the reservation.
+	// It returns the resulting balance.
+	Capture(id uint64, update user.BalanceUpdate) (decimal.Decimal, error)
+	// Release frees the amount of the active hold and sets its status to StatusReleased or StatusExpired.
+	Release(id uint64, status Status) (*Hold, error)
+	// ListExpired returns up to limit active holds whose TTL has passed at now, oldest first.
+	ListExpired(now time.Time, limit int) ([]Hold, error)
+}
@@ -0,0 +1,142 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type AccountType string
+
+const (
+	// AccountTypeUser holds the funds of a player's wallet; its ledger balance must equal the wallet balance.
+	AccountTypeUser AccountType = "user"
+	// AccountTypeHouse is the counterparty of a source (game, server, payment) for wins and losses.
+	AccountTypeHouse AccountType = "house"
+	// AccountTypeEquity balances entries that do not originate from a transaction, such as opening balances.
+	AccountTypeEquity AccountType = "equity"
+)
+
+// Accounts hold a single currency; postings against an account are in its currency.
+type Account struct {
+	Code      string      `json:"code" gorm:"primaryKey;type:varchar(100)"`
+	Type      AccountType `json:"type" gorm:"type:varchar(20);not null"`
+	UserID    *uint64     `json:"userId,omitempty" gorm:"uniqueIndex:idx_ledger_accounts_user_currency,priority:1"`
+	Currency  string      `json:"currency" gorm:"type:varchar(3);not null;uniqueIndex:idx_ledger_accounts_user_currency,priority:2"`
+	CreatedAt time.Time   `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (Account) TableName() string {
+	return "ledger_accounts"
+}
+
+// UserAccount returns the ledger account holding the funds of the user's wallet in the currency.
+func UserAccount(userID uint64, currency string) Account {
+	return Account{Code: fmt.Sprintf("user:%d:%s", userID, currency), Type: AccountTypeUser, UserID: &userID, Currency: currency}
+}
+
+// HouseAccount returns the house account a source settles wins and losses in the currency against.
+func HouseAccount(sourceType, currency string) Account {
+	return Account{Code: fmt.Sprintf("house:%s:%s", sourceType, currency), Type: AccountTypeHouse, Currency: currency}
+}
+
+// OpeningBalancesAccount is the counterparty of balances that existed before the ledger was introduced.
+func OpeningBalancesAccount(currency string) Account {
+	return Account{Code: "equity:opening-balances:" + currency, Type: AccountTypeEquity, Currency: currency}
+}
+
+// JournalEntry records one balanced movement of funds. Its postings always sum to zero.
+type JournalEntry struct {
+	ID            uint64    `json:"id" gorm:"primaryKey"`
+	TransactionID *uint64   `json:"transactionId,omitempty" gorm:"uniqueIndex"` // transactions.id of the operation that produced the entry
+	Description   string    `json:"description" gorm:"not null"`
+	Postings      []Posting `json:"postings" gorm:"foreignKey:JournalEntryID"`
+	CreatedAt     time.Time `json:"createdAt" gorm:"autoCreateTime"`
+
+	// Accounts lists the accounts referenced by the postings so that they can be created on first use.
+	Accounts []Account `json:"-" gorm:"-"`
+}
+
+func (JournalEntry) TableName() string {
+	return "ledger_journal_entries"
+}
+
+// Posting is one side of a journal entry. A positive amount increases the account's balance.
+type Posting struct {
+	ID             uint64          `json:"id" gorm:"primaryKey"`
+	JournalEntryID uint64          `json:"journalEntryId" gorm:"not null;index"`
+	AccountCode    string          `json:"accountCode" gorm:"type:varchar(100);not null;index"`
+	Amount         decimal.Decimal `json:"amount" gorm:"type:numeric(24,4);not null"`
+}
+
+func (Posting) TableName() string {
+	return "ledger_postings"
+}
+
+// Transfer builds a journal entry moving amount from one account to another in the same currency.
+func Transfer(description string, from, to Account, amount decimal.Decimal) (*JournalEntry, error) {
+	if from.Currency != to.Currency {
+		return nil, fmt.Errorf("cannot transfer between %s account %s and %s account %s", from.Currency, from.Code, to.Currency, to.Code)
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("transfer amount must be positive, got %s", amount.String())
+	}
+	entry := &JournalEntry{
+		Description: description,
+		Postings: []Posting{
+			{AccountCode: from.Code, Amount: amount.Neg()},
+			{AccountCode: to.Code, Amount: amount},
+		},
+		Accounts: []Account{from, to},
+	}
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Validate checks the double-entry invariant: at least two postings summing to zero.
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return errors.New("journal entry needs at least two postings")
+	}
+	sum := decimal.Zero
+	for _, p := range e.Postings {
+		sum = sum.Add(p.Amount)
+	}
+	if !sum.IsZero() {
+		return fmt.Errorf("journal entry postings sum to %s instead of zero", sum.String())
+	}
+	return nil
+}
+
+// UnbalancedEntry identifies a stored journal entry whose postings do not sum to zero.
+type UnbalancedEntry struct {
+	JournalEntryID uint64          `json:"journalEntryId"`
+	Sum            decimal.Decimal `json:"sum"`
+}
+
+// Reconciliation compares a wallet's stored balance with the sum of the postings on its account.
+type Reconciliation struct {
+	UserID        uint64          `json:"userId"`
+	Currency      string          `json:"currency"`
+	Balance       decimal.Decimal `json:"balance"`
+	LedgerBalance decimal.Decimal `json:"ledgerBalance"`
+}
+
+// Consistent reports whether the stored balance matches the ledger.
+func (r Reconciliation) Consistent() bool {
+	return r.Balance.Equal(r.LedgerBalance)
+}
+
+type Repository interface {
+	// ReconcileUser reads the stored and ledger balances of all the user's wallets from one snapshot.
+	// It returns sql.ErrNoRows if the user does not exist.
+	ReconcileUser(userID uint64) ([]Reconciliation, error)
+	// MismatchedWallets returns the wallets whose stored balance differs from their ledger balance.
+	MismatchedWallets() ([]Reconciliation, error)
+	// UnbalancedEntries returns the entries violating the double-entry invariant.
+	UnbalancedEntries() ([]UnbalancedEntry, error)
+}
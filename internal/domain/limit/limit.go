@@ -0,0 +1,160 @@
+package limit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+)
+
+// Kind is what a limit caps.
+type Kind string
+
+const (
+	KindLoss    Kind = "loss"    // Stakes, and amounts reserved by active holds
+	KindDeposit Kind = "deposit" // Deposits
+)
+
+// ValidKind reports whether k is a supported kind.
+func ValidKind(k Kind) bool {
+	return k == KindLoss || k == KindDeposit
+}
+
+// KindOf returns the kind of limit transactions of type t count towards, if any.
+func KindOf(t transaction.Type) (Kind, bool) {
+	switch {
+	case t.IsStake():
+		return KindLoss, true
+	case t == transaction.TypeDeposit:
+		return KindDeposit, true
+	default:
+		return "", false
+	}
+}
+
+// Types returns the transaction types counting towards limits of the kind.
+func (k Kind) Types() []transaction.Type {
+	if k == KindDeposit {
+		return []transaction.Type{transaction.TypeDeposit}
+	}
+	return transaction.StakeTypes()
+}
+
+// Period is the length of the rolling window a limit applies to.
+type Period string
+
+const (
+	PeriodDaily   Period = "daily"
+	PeriodWeekly  Period = "weekly"
+	PeriodMonthly Period = "monthly"
+)
+
+// Periods lists the supported periods from shortest to longest.
+var Periods = []Period{PeriodDaily, PeriodWeekly, PeriodMonthly}
+
+// ValidPeriod reports whether p is a supported period.
+func ValidPeriod(p Period) bool {
+	for _, known := range Periods {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Window returns the length of the period's rolling window.
+func (p Period) Window() time.Duration {
+	switch p {
+	case PeriodWeekly:
+		return 7 * 24 * time.Hour
+	case PeriodMonthly:
+		return 30 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// Limit caps the sum of a user's losses or deposits in one currency over a rolling window. Lowering
+// the limit applies at once; raising or removing it is only scheduled and applies after a
+// cooling-off period, so that a user cannot lift a limit on impulse.
+type Limit struct {
+	ID       uint64          `json:"id" gorm:"primaryKey"`
+	UserID   uint64          `json:"userId" gorm:"not null;uniqueIndex:idx_loss_limits_user_kind_currency_period,priority:1"`
+	Kind     Kind            `json:"kind" gorm:"type:varchar(10);not null;default:'loss';uniqueIndex:idx_loss_limits_user_kind_currency_period,priority:2"`
+	Currency string          `json:"currency" gorm:"type:varchar(3);not null;uniqueIndex:idx_loss_limits_user_kind_currency_period,priority:3"`
+	Period   Period          `json:"period" gorm:"type:varchar(10);not null;uniqueIndex:idx_loss_limits_user_kind_currency_period,priority:4"`
+	Amount   decimal.Decimal `json:"amount" gorm:"type:numeric(24,4);not null"`
+
+	// A pending change replaces Amount, or removes the limit if PendingRemoval is set, once
+	// PendingFrom has passed.
+	PendingAmount  decimal.NullDecimal `json:"pendingAmount" gorm:"type:numeric(24,4)"`
+	PendingRemoval bool                `json:"pendingRemoval" gorm:"not null;default:false"`
+	PendingFrom    *time.Time          `json:"pendingFrom,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// TableName keeps the table of the loss limits, which deposit limits were added to.
+func (Limit) TableName() string {
+	return "loss_limits"
+}
+
+// Pending reports whether a raise or removal is scheduled.
+func (l *Limit) Pending() bool {
+	return l.PendingFrom != nil
+}
+
+// Settle applies a pending change whose cooling-off period has passed at now. It returns false if
+// the change removed the limit.
+func (l *Limit) Settle(now time.Time) bool {
+	if !l.Pending() || now.Before(*l.PendingFrom) {
+		return true
+	}
+	if l.PendingRemoval {
+		return false
+	}
+	l.Amount = l.PendingAmount.Decimal
+	l.ClearPending()
+	return true
+}
+
+// ClearPending drops a scheduled change.
+func (l *Limit) ClearPending() {
+	l.PendingAmount = decimal.NullDecimal{}
+	l.PendingRemoval = false
+	l.PendingFrom = nil
+}
+
+// Check returns an error describing the breach if amount on top of total, the sum within the
+// limit's window, would exceed the limit.
+func (l *Limit) Check(total, amount decimal.Decimal) error {
+	if !total.Add(amount).GreaterThan(l.Amount) {
+		return nil
+	}
+	verb := "lost"
+	if l.Kind == KindDeposit {
+		verb = "deposited"
+	}
+	return fmt.Errorf("%s %s limit of %s %s exceeded: %s %s %s in the last %s", l.Period, l.Kind,
+		currency.Format(l.Currency, l.Amount), l.Currency, currency.Format(l.Currency, total), l.Currency, verb, formatWindow(l.Period.Window()))
+}
+
+// formatWindow renders a rolling window in days, or in hours if it is shorter than two days.
+func formatWindow(d time.Duration) string {
+	if d < 48*time.Hour {
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d days", int(d.Hours()/24))
+}
+
+type Repository interface {
+	ListByUser(userID uint64) ([]Limit, error)
+	// Get returns sql.ErrNoRows if the user has no limit of the kind for the currency and period.
+	Get(userID uint64, kind Kind, currency string, period Period) (*Limit, error)
+	// Save creates or updates the limit for its user, kind, currency and period.
+	Save(l *Limit) error
+	Delete(id uint64) error
+}
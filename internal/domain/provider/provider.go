@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxActiveKeys is how many signing keys a provider has at most: the current key and the one it
+// replaced, so that the provider can switch keys without rejected requests.
+const MaxActiveKeys = 2
+
+// namePattern restricts provider names to what fits the provider columns and path parameters.
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+// Provider is an integration that sends transactions and authenticates by signing its requests
+// with one of its keys. Its name is the provider of the /provider/:provider/player routes.
+type Provider struct {
+	Name    string `json:"name" gorm:"primaryKey;type:varchar(50)"`
+	Enabled bool   `json:"enabled" gorm:"not null"` // Requests of disabled providers are rejected
+	// SourceTypes are the Source-Types the provider may send requests with.
+	SourceTypes Names     `json:"sourceTypes" gorm:"type:text;not null"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+func (Provider) TableName() string {
+	return "providers"
+}
+
+// Validate checks the name of a provider to be saved; its Source-Types are checked by the caller.
+func (p *Provider) Validate() error {
+	if !namePattern.MatchString(p.Name) {
+		return fmt.Errorf("invalid provider name %q: must be lower case letters, digits, '_' or '-', starting with a letter, at most 50 characters", p.Name)
+	}
+	return nil
+}
+
+// AllowsSource reports whether the provider may send requests with the Source-Type.
+func (p *Provider) AllowsSource(sourceType string) bool {
+	return slices.Contains(p.SourceTypes, sourceType)
+}
+
+// Key is a signing key of a provider. The secret is shared with the provider when the key is
+// created and is needed in clear to verify signatures.
+type Key struct {
+	ID        string    `json:"keyId" gorm:"primaryKey;type:varchar(32)"`
+	Provider  string    `json:"provider" gorm:"type:varchar(50);not null;index"`
+	Secret    string    `json:"-" gorm:"type:varchar(64);not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (Key) TableName() string {
+	return "provider_keys"
+}
+
+// NewKey generates a key with a random ID and secret for the provider.
+func NewKey(provider string) (*Key, error) {
+	id, err := randomHex(12)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: "pk_" + id, Provider: provider, Secret: secret}, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random key material: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of a request under secret. The signed message is the
+// method, the path including the query string, the timestamp and the body, joined by newlines.
+func Sign(secret, method, path, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of the request under the key, comparing in
+// constant time.
+func (k *Key) Verify(method, path, timestamp string, body []byte, signature string) bool {
+	expected, _ := hex.DecodeString(Sign(k.Secret, method, path, timestamp, body))
+	actual, err := hex.DecodeString(signature)
+	return err == nil && hmac.Equal(expected, actual)
+}
+
+// Signature records that a key signed a request, so that the request cannot be replayed while
+// its timestamp is within the authentication window.
+type Signature struct {
+	KeyID     string    `gorm:"primaryKey;type:varchar(32)"`
+	Signature string    `gorm:"primaryKey;type:varchar(64)"` // Lower-case hex
+	SeenAt    time.Time `gorm:"type:timestamptz;not null;index"`
+}
+
+func (Signature) TableName() string {
+	return "provider_signatures"
+}
+
+// Names is a list of names, stored as a comma-separated string.
+type Names []string
+
+func (n Names) Value() (driver.Value, error) {
+	return strings.Join(n, ","), nil
+}
+
+func (n *Names) Scan(value any) error {
+	var raw string
+	switch v := value.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Names", value)
+	}
+	*n = nil
+	for _, name := range strings.Split(raw, ",") {
+		if name != "" {
+			*n = append(*n, name)
+		}
+	}
+	return nil
+}
+
+type Repository interface {
+	List() ([]Provider, error)
+	// Get returns sql.ErrNoRows if there is no such provider.
+	Get(name string) (*Provider, error)
+	// Save creates the provider or replaces the settings of an existing one.
+	Save(p *Provider) error
+	// GetKey returns sql.ErrNoRows if there is no such key.
+	GetKey(id string) (*Key, error)
+	// ListKeys returns the keys of the provider, oldest first.
+	ListKeys(provider string) ([]Key, error)
+	// AddKey stores the key and deletes all but the newest keep keys of its provider, in one
+	// database transaction.
+	AddKey(key *Key, keep int) error
+	// DeleteKey returns sql.ErrNoRows if the provider has no such key.
+	DeleteKey(provider, id string) error
+	// RecordSignature stores the signature and deletes those seen before expired. It reports false
+	// if the signature was stored before.
+	RecordSignature(sig *Signature, expired time.Time) (bool, error)
+}
@@ -0,0 +1,101 @@
+package round
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+)
+
+// Status is the lifecycle state of a game round.
+type Status string
+
+const (
+	StatusOpen      Status = "open"      // Stakes placed, no payout yet
+	StatusSettled   Status = "settled"   // Paid out by a "win"
+	StatusCancelled Status = "cancelled" // A transaction of the round was reversed
+)
+
+var (
+	// ErrUnknownRound rejects a payout for a round no stake was placed in, in strict mode.
+	ErrUnknownRound = errors.New("unknown round")
+	// ErrRoundClosed rejects stakes and payouts for settled or cancelled rounds, in strict mode.
+	ErrRoundClosed = errors.New("round is closed")
+	// ErrRoundMismatch rejects a transaction whose user, currency or game differ from its round's.
+	ErrRoundMismatch = errors.New("transaction does not belong to round")
+)
+
+// Round links the stakes ("lose") and payouts ("win") a provider sends for one game round of a
+// user. Its transactions reference it by source and round ID.
+type Round struct {
+	ID           uint64          `json:"id" gorm:"primaryKey"`
+	RoundID      string          `json:"roundId" gorm:"not null;uniqueIndex:idx_rounds_source_round_id,priority:2"` // External ID, unique per source
+	SourceType   string          `json:"sourceType" gorm:"not null;uniqueIndex:idx_rounds_source_round_id,priority:1"`
+	UserID       uint64          `json:"userId" gorm:"not null;index"`
+	GameID       string          `json:"gameId,omitempty" gorm:"type:varchar(255);not null;default:''"`
+	Currency     string          `json:"currency" gorm:"type:varchar(3);not null"`
+	Status       Status          `json:"status" gorm:"type:varchar(10);not null"`
+	StakeAmount  decimal.Decimal `json:"stakeAmount" gorm:"type:numeric(24,4);not null;default:0"`  // Sum of the round's stakes
+	PayoutAmount decimal.Decimal `json:"payoutAmount" gorm:"type:numeric(24,4);not null;default:0"` // Sum of the round's payouts
+	CreatedAt    time.Time       `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time       `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// Book applies t, a transaction of the round r, and returns the round as t leaves it; r is nil if
+// the round has not been started yet and is left unchanged. A stake opens the round or adds to it,
+// a payout settles it and a reversal cancels it. In strict mode, payouts for unknown rounds and
+// stakes or payouts for closed rounds are rejected; reversals are always accepted. The result is
+// nil if t does not start a round, i.e. a reversal of a transaction the round was not tracked for.
+func Book(r *Round, t *transaction.Transaction, strict bool) (*Round, error) {
+	info, _ := transaction.LookupType(t.State)
+	var next Round
+	if r == nil {
+		switch {
+		case info.Direction == transaction.DirectionDebit || info.Direction == transaction.DirectionCredit && !strict:
+			next = Round{RoundID: t.RoundID, SourceType: t.SourceType, UserID: t.UserID, GameID: t.GameID,
+				Currency: t.Currency, Status: StatusOpen}
+		case info.Direction == transaction.DirectionCredit:
+			return nil, fmt.Errorf("%w: no stake was placed in round %s", ErrUnknownRound, t.RoundID)
+		default:
+			return nil, nil
+		}
+	} else {
+		next = *r
+		switch {
+		case t.UserID != r.UserID:
+			return nil, fmt.Errorf("%w: round %s belongs to another user", ErrRoundMismatch, r.RoundID)
+		case t.Currency != r.Currency:
+			return nil, fmt.Errorf("%w: round %s is played in %s", ErrRoundMismatch, r.RoundID, r.Currency)
+		case t.GameID != "" && r.GameID != "" && t.GameID != r.GameID:
+			return nil, fmt.Errorf("%w: round %s belongs to game %s", ErrRoundMismatch, r.RoundID, r.GameID)
+		}
+		if next.GameID == "" {
+			next.GameID = t.GameID
+		}
+		if strict && r.Status != StatusOpen && info.Direction != transaction.DirectionReversal {
+			return nil, fmt.Errorf("%w: round %s is %s", ErrRoundClosed, r.RoundID, r.Status)
+		}
+	}
+
+	switch info.Direction {
+	case transaction.DirectionDebit:
+		next.StakeAmount = next.StakeAmount.Add(t.Amount)
+	case transaction.DirectionCredit:
+		next.PayoutAmount = next.PayoutAmount.Add(t.Amount)
+		if next.Status == StatusOpen {
+			next.Status = StatusSettled
+		}
+	case transaction.DirectionReversal:
+		next.Status = StatusCancelled
+	}
+	return &next, nil
+}
+
+type Repository interface {
+	// GetByRoundID finds a round by its external ID within the given source. An empty sourceType
+	// searches all sources and fails with a conflict if the ID is ambiguous. It returns
+	// sql.ErrNoRows if there is no such round.
+	GetByRoundID(sourceType, roundID string) (*Round, error)
+}
@@ -0,0 +1,124 @@
+package source
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+)
+
+// Builtin are the Source-Types created with their default settings on startup.
+var Builtin = []string{"game", "server", "payment"}
+
+// namePattern restricts Source-Type names to what fits the source_type columns and header values.
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+// Source is a Source-Type providers send transactions with and the settings the transactions
+// are checked against. Sources are never deleted, only disabled, since transactions refer to them.
+type Source struct {
+	Name    string `json:"name" gorm:"primaryKey;type:varchar(50)"`
+	Enabled bool   `json:"enabled" gorm:"not null"` // Transactions and holds of disabled sources are rejected
+	// States are the transaction types the source may send.
+	States States `json:"states" gorm:"type:text;not null"`
+	// MaxAmount caps the amount of a single transaction in MaxAmountCurrency; amounts sent in another
+	// currency are converted before they are compared. No cap if null.
+	MaxAmount         decimal.NullDecimal `json:"maxAmount" gorm:"type:numeric(24,4)"`
+	MaxAmountCurrency string              `json:"maxAmountCurrency,omitempty" gorm:"type:varchar(3)"` // Empty if MaxAmount is null
+	// CheckBalance rejects debits that would take the available balance below zero or the credit
+	// limit. Debits of sources without the check, e.g. chargebacks, may drive a balance negative.
+	CheckBalance bool      `json:"checkBalance" gorm:"not null"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+func (Source) TableName() string {
+	return "source_types"
+}
+
+// Validate checks the name and settings of a source to be saved.
+func (s *Source) Validate() error {
+	if !namePattern.MatchString(s.Name) {
+		return fmt.Errorf("invalid Source-Type name %q: must be lower case letters, digits, '_' or '-', starting with a letter, at most 50 characters", s.Name)
+	}
+	for _, state := range s.States {
+		info, ok := transaction.LookupType(state)
+		if !ok || info.Internal {
+			return fmt.Errorf("unknown transaction state %q", state)
+		}
+	}
+	if s.MaxAmount.Valid && !s.MaxAmount.Decimal.IsPositive() {
+		return fmt.Errorf("maxAmount must be positive")
+	}
+	if s.MaxAmount.Valid && !currency.Valid(s.MaxAmountCurrency) {
+		return fmt.Errorf("unsupported maxAmountCurrency %q", s.MaxAmountCurrency)
+	}
+	if !s.MaxAmount.Valid && s.MaxAmountCurrency != "" {
+		return fmt.Errorf("maxAmountCurrency requires a maxAmount")
+	}
+	return nil
+}
+
+// Allows reports whether the source may send transactions of type t.
+func (s *Source) Allows(t transaction.Type) bool {
+	return slices.Contains(s.States, t)
+}
+
+// Defaults returns the built-in sources, enabled with the transaction types registered for them,
+// no amount cap and the balance check.
+func Defaults() []Source {
+	sources := make([]Source, 0, len(Builtin))
+	for _, name := range Builtin {
+		var states States
+		for _, info := range transaction.Types() {
+			if !info.Internal && info.AllowsSource(name) {
+				states = append(states, info.Type)
+			}
+		}
+		sources = append(sources, Source{Name: name, Enabled: true, States: states, CheckBalance: true})
+	}
+	return sources
+}
+
+// States is a list of transaction types, stored as a comma-separated string.
+type States []transaction.Type
+
+func (s States) Value() (driver.Value, error) {
+	names := make([]string, len(s))
+	for i, t := range s {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ","), nil
+}
+
+func (s *States) Scan(value any) error {
+	var raw string
+	switch v := value.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into States", value)
+	}
+	*s = nil
+	for _, name := range strings.Split(raw, ",") {
+		if name != "" {
+			*s = append(*s, transaction.Type(name))
+		}
+	}
+	return nil
+}
+
+type Repository interface {
+	List() ([]Source, error)
+	// Get returns sql.ErrNoRows if there is no such source.
+	Get(name string) (*Source, error)
+	// Save creates the source or replaces the settings of an existing one.
+	Save(s *Source) error
+}
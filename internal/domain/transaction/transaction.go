@@ -1,22 +1,239 @@
 package transaction
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
 )
 
 type Transaction struct {
-	ID            uint64          `json:"id" gorm:"primaryKey"`
-	UserID        uint64          `json:"userId" gorm:"not null"`
-	TransactionID string          `json:"transactionId" gorm:"unique;not null"` // External ID for idempotency
-	SourceType    string          `json:"sourceType" gorm:"not null"`
-	State         string          `json:"state" gorm:"not null"` // "win" or "lose"
-	Amount        decimal.Decimal `json:"amount" gorm:"type:numeric(20,2);not null"`
-	ProcessedAt   time.Time       `json:"processedAt" gorm:"autoCreateTime"`
+	ID            uint64              `json:"id" gorm:"primaryKey;index:idx_transactions_user_history,priority:3,sort:desc"`
+	UserID        uint64              `json:"userId" gorm:"not null;index:idx_transactions_user_history,priority:1"`
+	TransactionID string              `json:"transactionId" gorm:"not null;uniqueIndex:idx_transactions_source_transaction_id,priority:2"` // External ID for idempotency, unique per source
+	SourceType    string              `json:"sourceType" gorm:"not null;uniqueIndex:idx_transactions_source_transaction_id,priority:1;index:idx_transactions_source_round_id,priority:1"`
+	State         Type                `json:"state" gorm:"type:varchar(32);not null"`   // One of the registered types, see LookupType
+	Currency      string              `json:"currency" gorm:"type:varchar(3);not null"` // ISO 4217 code of the wallet the transaction applies to
+	Amount        decimal.Decimal     `json:"amount" gorm:"type:numeric(24,4);not null"`
+	BalanceBefore decimal.NullDecimal `json:"balanceBefore" gorm:"type:numeric(24,4)"`                              // NULL for rows recorded before the column existed
+	BalanceAfter  decimal.NullDecimal `json:"balanceAfter" gorm:"type:numeric(24,4)"`                               // NULL for rows recorded before the column existed
+	ReversesID    *uint64             `json:"reversesId,omitempty" gorm:"uniqueIndex:idx_transactions_reverses_id"` // For "cancel" rows, the ID of the reversed transaction
+	TransferID    *uint64             `json:"transferId,omitempty" gorm:"index"`                                    // For the legs of a transfer, the ID of the transfer
+	RequestHash   string              `json:"-" gorm:"type:varchar(64)"`                                            // Fingerprint of the request that created the row
+	ProcessedAt   time.Time           `json:"processedAt" gorm:"type:timestamptz;autoCreateTime;index:idx_transactions_user_history,priority:2,sort:desc"`
+
+	// Bucket is the sub-balance ("real" or "bonus") the request asked to book the whole amount on;
+	// empty if the amount was split by the wallet's policy. BonusAmount is the part of Amount that
+	// was booked on the bonus balance.
+	Bucket      string          `json:"bucket,omitempty" gorm:"type:varchar(5);not null;default:''"`
+	BonusAmount decimal.Decimal `json:"bonusAmount" gorm:"type:numeric(24,4);not null;default:0"`
+
+	// Transactions sent in a currency other than the wallet's are converted on ingestion; Amount is
+	// then the converted amount and these fields record what was sent and the rate applied.
+	OriginalAmount   decimal.NullDecimal `json:"originalAmount,omitempty" gorm:"type:numeric(24,4)"`
+	OriginalCurrency string              `json:"originalCurrency,omitempty" gorm:"type:varchar(3)"` // Empty if no conversion took place
+	ExchangeRate     decimal.NullDecimal `json:"exchangeRate,omitempty" gorm:"type:numeric(30,12)"` // Amount = OriginalAmount * ExchangeRate, rounded to the wallet currency
+	RateTimestamp    *time.Time          `json:"rateTimestamp,omitempty"`                           // When the applied rate was published
+
+	// RoundID links the stakes and payouts of one game round, scoped per source like TransactionID;
+	// GameID optionally names the game. Both are empty for transactions outside rounds.
+	RoundID string `json:"roundId,omitempty" gorm:"type:varchar(255);not null;default:'';index:idx_transactions_source_round_id,priority:2"`
+	GameID  string `json:"gameId,omitempty" gorm:"type:varchar(255);not null;default:''"`
+}
+
+// Converted reports whether the transaction was sent in a currency other than its wallet's.
+func (t *Transaction) Converted() bool {
+	return t.OriginalCurrency != ""
+}
+
+// RequestedAmount returns the amount and currency as sent by the provider, before any conversion.
+func (t *Transaction) RequestedAmount() (decimal.Decimal, string) {
+	if t.Converted() {
+		return t.OriginalAmount.Decimal, t.OriginalCurrency
+	}
+	return t.Amount, t.Currency
+}
+
+// Fingerprint returns a SHA-256 hash over the canonical form of the request fields that
+// define the transaction. Two requests with the same transactionId are only considered the
+// same operation if their fingerprints match. For converted transactions the amount as sent is
+// used, so that a replay matches even if the exchange rate has changed since.
+func (t *Transaction) Fingerprint() string {
+	fields := []string{
+		strconv.FormatUint(t.UserID, 10),
+		t.TransactionID,
+		t.SourceType,
+		string(t.State),
+		t.Currency,
+	}
+	amount, code := t.RequestedAmount()
+	if t.Converted() {
+		fields = append(fields, code)
+	}
+	fields = append(fields, currency.Format(code, amount))
+	if t.Bucket != "" {
+		fields = append(fields, t.Bucket)
+	}
+	if t.ReversesID != nil {
+		fields = append(fields, strconv.FormatUint(*t.ReversesID, 10))
+	}
+	if t.RoundID != "" {
+		fields = append(fields, t.RoundID, t.GameID)
+	}
+	canonical := strings.Join(fields, "|")
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:])
+}
+
+// MatchesRequest reports whether the stored transaction was created by a request equivalent to req.
+// Rows stored before fingerprints were recorded are compared by recomputing their fingerprint.
+func (t *Transaction) MatchesRequest(req *Transaction) bool {
+	stored := t.RequestHash
+	if stored == "" {
+		stored = t.Fingerprint()
+	}
+	return stored == req.Fingerprint()
+}
+
+// Cursor identifies a position in a user's transaction history, which is ordered by
+// (processed_at, id) descending.
+type Cursor struct {
+	ProcessedAt time.Time
+	ID          uint64
+}
+
+// Encode returns the opaque string form of the cursor handed out to API clients.
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.ProcessedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor previously produced by Cursor.Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return nil, errors.New("malformed cursor")
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	return &Cursor{ProcessedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
+
+// ListFilter narrows down a user's transaction history. Zero values mean "no filter".
+type ListFilter struct {
+	State       Type
+	SourceType  string
+	SourceTypes []string // only transactions of these sources, if not empty
+	Currency    string
+	MinAmount   *decimal.Decimal
+	MaxAmount   *decimal.Decimal
+	From        *time.Time // inclusive
+	To          *time.Time // exclusive
+	After       *Cursor    // return rows strictly older than this position
+	Limit       int
+}
+
+// ChainBreak describes a transaction whose balance snapshot does not follow from its predecessor.
+type ChainBreak struct {
+	ID            uint64
+	TransactionID string
+	Currency      string
+	Reason        string
+}
+
+// ChainChecker verifies that a user's transactions, fed in the order they were applied, form an
+// unbroken balance timeline per wallet: every row starts from the balance the previous row in the
+// same currency ended with and moves it by its own amount. Rows without snapshots are skipped and
+// restart the chain of their wallet.
+type ChainChecker struct {
+	Checked int
+	Breaks  []ChainBreak
+
+	last map[string]*Transaction
+	// directions holds the sign of every non-reversal row seen so far, so that the reversals that
+	// follow them can be checked.
+	directions map[uint64]int
+}
+
+func NewChainChecker() *ChainChecker {
+	return &ChainChecker{last: make(map[string]*Transaction), directions: make(map[uint64]int)}
+}
+
+// Add checks t against the previously added row of the same currency.
+func (c *ChainChecker) Add(t Transaction) {
+	direction := 0
+	if info, ok := LookupType(t.State); ok {
+		direction = info.Direction.Sign()
+		if info.Direction == DirectionReversal && t.ReversesID != nil {
+			direction = -c.directions[*t.ReversesID]
+		} else if direction != 0 {
+			c.directions[t.ID] = direction
+		}
+	}
+
+	if !t.BalanceBefore.Valid || !t.BalanceAfter.Valid {
+		delete(c.last, t.Currency)
+		return
+	}
+	c.Checked++
+
+	format := func(d decimal.Decimal) string { return currency.Format(t.Currency, d) }
+	if last := c.last[t.Currency]; last != nil && !last.BalanceAfter.Decimal.Equal(t.BalanceBefore.Decimal) {
+		c.addBreak(t, fmt.Sprintf("balance before %s does not match balance after %s of transaction %s",
+			format(t.BalanceBefore.Decimal), format(last.BalanceAfter.Decimal), last.TransactionID))
+	}
+
+	moved := t.BalanceAfter.Decimal.Sub(t.BalanceBefore.Decimal)
+	switch {
+	case direction != 0 && !moved.Equal(t.Amount.Mul(decimal.NewFromInt(int64(direction)))):
+		c.addBreak(t, fmt.Sprintf("balance moved by %s for a %s of %s", format(moved), t.State, format(t.Amount)))
+	case direction == 0 && !moved.Abs().Equal(t.Amount):
+		// The reversed transaction predates the checked range, so only the magnitude is known.
+		c.addBreak(t, fmt.Sprintf("balance moved by %s for a %s of %s", format(moved), t.State, format(t.Amount)))
+	}
+
+	last := t
+	c.last[t.Currency] = &last
+}
+
+// LastBalance returns the balance after the most recently added row in the currency, if it has a snapshot.
+func (c *ChainChecker) LastBalance(currency string) decimal.NullDecimal {
+	if last := c.last[currency]; last != nil {
+		return last.BalanceAfter
+	}
+	return decimal.NullDecimal{}
+}
+
+func (c *ChainChecker) addBreak(t Transaction, reason string) {
+	c.Breaks = append(c.Breaks, ChainBreak{ID: t.ID, TransactionID: t.TransactionID, Currency: t.Currency, Reason: reason})
 }
 
 type Repository interface {
 	Create(transaction *Transaction) error
-	GetByTransactionID(transactionID string) (*Transaction, error)
+	// GetByTransactionID finds a transaction by its external ID within the given source.
+	// An empty sourceType searches all sources and fails with a conflict if the ID is ambiguous.
+	GetByTransactionID(sourceType, transactionID string) (*Transaction, error)
+	ListByUser(userID uint64, filter ListFilter) ([]Transaction, error)
+	// ListByUserInApplyOrder returns up to limit of the user's transactions with an ID greater than
+	// afterID, in the order they were applied to the balance.
+	ListByUserInApplyOrder(userID uint64, afterID uint64, limit int) ([]Transaction, error)
+	// ListByRound returns the transactions of the source's round in the order they were applied.
+	ListByRound(sourceType, roundID string) ([]Transaction, error)
 }
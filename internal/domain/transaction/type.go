@@ -0,0 +1,131 @@
+package transaction
+
+import "slices"
+
+// Type is the kind of a transaction, sent and stored as its "state". Its TypeInfo in the registry
+// declares how it moves the balance and which built-in sources accept it by default, so that a type
+// is added by registering it rather than by editing every place that handles transactions.
+type Type string
+
+const (
+	TypeWin        Type = "win"         // Payout of a wager
+	TypeLose       Type = "lose"        // Stake of a wager
+	TypeCancel     Type = "cancel"      // Reversal of another transaction
+	TypeDeposit    Type = "deposit"     // Money paid in by the user
+	TypeWithdrawal Type = "withdrawal"  // Money paid out to the user
+	TypeBonusGrant Type = "bonus_grant" // Bonus money granted by the operator
+	TypeAdjustment Type = "adjustment"  // Manual correction in the user's favour; corrections against the user are fees
+	TypeFee        Type = "fee"         // Charge levied on the user
+	TypeRefund     Type = "refund"      // Return of a charge or purchase
+	TypeDebit      Type = "debit"       // Leg of a transfer taking the amount from the sender
+	TypeCredit     Type = "credit"      // Leg of a transfer paying the amount to the receiver
+)
+
+// Direction is the way a transaction type moves the balance.
+type Direction string
+
+const (
+	DirectionCredit   Direction = "credit"   // Adds the amount
+	DirectionDebit    Direction = "debit"    // Takes the amount
+	DirectionReversal Direction = "reversal" // Undoes the transaction it reverses
+)
+
+// Sign returns 1 for credits, -1 for debits and 0 for reversals, whose sign is the opposite of
+// the reversed transaction's.
+func (d Direction) Sign() int {
+	switch d {
+	case DirectionCredit:
+		return 1
+	case DirectionDebit:
+		return -1
+	}
+	return 0
+}
+
+// TypeInfo describes a transaction type.
+type TypeInfo struct {
+	Type      Type
+	Direction Direction
+	// Sources lists the built-in Source-Types that accept the type by default. Which types a source
+	// accepts is then part of its settings, see the source package.
+	Sources []string
+	// Bucket, if set, is the sub-balance ("real" or "bonus") the type is always booked on.
+	Bucket string
+	// Wager marks the stakes and payouts of games: they may belong to a game round and their
+	// stakes count towards loss limits.
+	Wager bool
+	// Internal types are recorded by the service itself and never accepted in requests.
+	Internal bool
+}
+
+// providerSources are the built-in Source-Types providers send transactions with.
+var providerSources = []string{"game", "server", "payment"}
+
+// types is the registry of transaction types, in the order they are listed to clients.
+var types = []TypeInfo{
+	{Type: TypeWin, Direction: DirectionCredit, Sources: providerSources, Wager: true},
+	{Type: TypeLose, Direction: DirectionDebit, Sources: providerSources, Wager: true},
+	{Type: TypeCancel, Direction: DirectionReversal, Sources: providerSources},
+	{Type: TypeDeposit, Direction: DirectionCredit, Sources: []string{"payment"}, Bucket: "real"},
+	{Type: TypeWithdrawal, Direction: DirectionDebit, Sources: []string{"payment"}, Bucket: "real"},
+	{Type: TypeBonusGrant, Direction: DirectionCredit, Sources: []string{"server"}, Bucket: "bonus"},
+	{Type: TypeAdjustment, Direction: DirectionCredit, Sources: []string{"server"}},
+	{Type: TypeFee, Direction: DirectionDebit, Sources: []string{"server", "payment"}},
+	{Type: TypeRefund, Direction: DirectionCredit, Sources: []string{"server", "payment"}},
+	// The legs of transfers are booked under the transfer package's Source-Type.
+	{Type: TypeDebit, Direction: DirectionDebit, Sources: []string{"transfer"}, Bucket: "real", Internal: true},
+	{Type: TypeCredit, Direction: DirectionCredit, Sources: []string{"transfer"}, Bucket: "real", Internal: true},
+}
+
+// LookupType returns the registered description of t.
+func LookupType(t Type) (TypeInfo, bool) {
+	for _, info := range types {
+		if info.Type == t {
+			return info, true
+		}
+	}
+	return TypeInfo{}, false
+}
+
+// Types returns every registered type.
+func Types() []TypeInfo {
+	return slices.Clone(types)
+}
+
+// RequestTypes returns the types that may be sent in requests.
+func RequestTypes() []Type {
+	var result []Type
+	for _, info := range types {
+		if !info.Internal {
+			result = append(result, info.Type)
+		}
+	}
+	return result
+}
+
+// StakeTypes returns the types counting towards loss limits.
+func StakeTypes() []Type {
+	var result []Type
+	for _, info := range types {
+		if info.IsStake() {
+			result = append(result, info.Type)
+		}
+	}
+	return result
+}
+
+// AllowsSource reports whether the Source-Type may send the type.
+func (i TypeInfo) AllowsSource(sourceType string) bool {
+	return slices.Contains(i.Sources, sourceType)
+}
+
+// IsStake reports whether the type is the stake of a wager.
+func (i TypeInfo) IsStake() bool {
+	return i.Wager && i.Direction == DirectionDebit
+}
+
+// IsStake reports whether t is a registered stake type.
+func (t Type) IsStake() bool {
+	info, ok := LookupType(t)
+	return ok && info.IsStake()
+}
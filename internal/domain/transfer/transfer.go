@@ -0,0 +1,55 @@
+package transfer
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+)
+
+// SourceType is the source of the transactions recording the legs of transfers. Their journal
+// entries pass through the house account of this source, which nets to zero after every transfer.
+const SourceType = "transfer"
+
+// States of the two transactions recording a transfer.
+const (
+	StateDebit  = transaction.TypeDebit  // Takes the amount from the sender
+	StateCredit = transaction.TypeCredit // Pays the amount to the receiver
+)
+
+// MaxTransferIDLength leaves room for the suffix of the legs' transaction IDs.
+const MaxTransferIDLength = 200
+
+// Transfer moves funds from one user's wallet to another's, e.g. an affiliate payout. It is
+// recorded as a debit and a credit transaction that both reference it.
+type Transfer struct {
+	ID         uint64          `json:"id" gorm:"primaryKey"`
+	TransferID string          `json:"transferId" gorm:"type:varchar(200);not null;uniqueIndex:idx_transfers_transfer_id"` // External ID for idempotency
+	FromUserID uint64          `json:"fromUserId" gorm:"not null;index"`
+	ToUserID   uint64          `json:"toUserId" gorm:"not null;index"`
+	Currency   string          `json:"currency" gorm:"type:varchar(3);not null"`
+	Amount     decimal.Decimal `json:"amount" gorm:"type:numeric(24,4);not null"`
+	Reason     string          `json:"reason,omitempty" gorm:"type:varchar(255);not null;default:''"`
+	CreatedAt  time.Time       `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+// LegID returns the transaction ID of the transfer's leg in the given state.
+func (t *Transfer) LegID(state transaction.Type) string {
+	return t.TransferID + ":" + string(state)
+}
+
+// MatchesRequest reports whether the stored transfer was requested with the same payload as req.
+func (t *Transfer) MatchesRequest(req *Transfer) bool {
+	return t.FromUserID == req.FromUserID && t.ToUserID == req.ToUserID &&
+		t.Currency == req.Currency && t.Amount.Equal(req.Amount) && t.Reason == req.Reason
+}
+
+type Repository interface {
+	// Execute stores the transfer and applies the debit and credit updates, whose transactions are
+	// linked to it, in one database transaction. Both wallets are locked in (user ID, currency)
+	// order before either update is applied. A reused transfer ID is reported as already processed.
+	Execute(t *Transfer, debit, credit user.BalanceUpdate) error
+	// GetByTransferID returns sql.ErrNoRows if there is no transfer with this ID.
+	GetByTransferID(transferID string) (*Transfer, error)
+}
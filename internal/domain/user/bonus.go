@@ -0,0 +1,103 @@
+package user
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+// Bucket names one of the sub-balances of a wallet.
+type Bucket string
+
+const (
+	BucketReal  Bucket = "real"
+	BucketBonus Bucket = "bonus"
+)
+
+// DebitOrder decides which sub-balance a debit is paid from first.
+type DebitOrder string
+
+const (
+	DebitRealFirst  DebitOrder = "real_first"
+	DebitBonusFirst DebitOrder = "bonus_first"
+)
+
+// ValidDebitOrder reports whether o is a known debit order.
+func ValidDebitOrder(o DebitOrder) bool {
+	return o == DebitRealFirst || o == DebitBonusFirst
+}
+
+// Allocation decides how a balance change is split between a wallet's real and bonus balances.
+type Allocation struct {
+	// Bucket, when set, takes the whole change.
+	Bucket Bucket
+	// Bonus, when valid, is the exact unsigned part of the change booked on the bonus balance,
+	// e.g. to undo the split of an earlier transaction.
+	Bonus decimal.NullDecimal
+	// DebitOrder orders the sub-balances a debit is paid from when neither Bucket nor Bonus is set.
+	DebitOrder DebitOrder
+}
+
+// RealBalance returns the part of the balance that is real money.
+func (w *Wallet) RealBalance() decimal.Decimal {
+	return w.Balance.Sub(w.BonusBalance)
+}
+
+// BonusDelta returns the signed part of delta that goes to the bonus balance; the rest goes to the
+// real balance. Debits are paid in the allocation's debit order; whatever neither sub-balance covers
+// is drawn from the real balance against the credit limit. Credits are split in proportion to the
+// current real and bonus balances, rounding the bonus part down to the currency's minor unit.
+func (w *Wallet) BonusDelta(delta decimal.Decimal, a Allocation) decimal.Decimal {
+	switch {
+	case a.Bonus.Valid:
+		if delta.IsNegative() {
+			return a.Bonus.Decimal.Neg()
+		}
+		return a.Bonus.Decimal
+	case a.Bucket == BucketBonus:
+		return delta
+	case a.Bucket == BucketReal:
+		return decimal.Zero
+	}
+
+	bonus := decimal.Max(w.BonusBalance, decimal.Zero)
+	real := decimal.Max(w.RealBalance(), decimal.Zero)
+	if delta.IsNegative() {
+		amount := delta.Neg()
+		if a.DebitOrder == DebitBonusFirst {
+			return decimal.Min(amount, bonus).Neg()
+		}
+		return decimal.Min(amount.Sub(decimal.Min(amount, real)), bonus).Neg()
+	}
+
+	if !bonus.IsPositive() {
+		return decimal.Zero
+	}
+	return delta.Mul(bonus).Div(bonus.Add(real)).RoundFloor(currency.MinorUnits(w.Currency))
+}
+
+// Overdrawn returns the sub-balance that applying delta, bonusDelta of it on the bonus balance,
+// would newly drive below its floor, or an empty Bucket if neither. The bonus balance may not go
+// negative; the real balance may go down to the negated credit limit.
+func (w *Wallet) Overdrawn(delta, bonusDelta decimal.Decimal) Bucket {
+	realDelta := delta.Sub(bonusDelta)
+	if bonusDelta.IsNegative() && w.BonusBalance.Add(bonusDelta).IsNegative() {
+		return BucketBonus
+	}
+	if realDelta.IsNegative() && w.RealBalance().Add(realDelta).LessThan(w.CreditLimit.Neg()) {
+		return BucketReal
+	}
+	return ""
+}
+
+// InsufficientBucketError reports that a debit would overdraw the given sub-balance.
+func (w *Wallet) InsufficientBucketError(bucket Bucket) error {
+	remaining := w.RealBalance()
+	if bucket == BucketBonus {
+		remaining = w.BonusBalance
+	}
+	return appErrors.NewInsufficientBalanceError(fmt.Sprintf("insufficient %s balance: %s balance remains %s %s",
+		bucket, bucket, currency.Format(w.Currency, remaining), w.Currency))
+}
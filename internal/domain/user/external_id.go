@@ -0,0 +1,17 @@
+package user
+
+import "time"
+
+// ExternalID maps the ID a provider uses for a player to the user. An external ID belongs to at most
+// one user per provider; a user may be known to several providers.
+type ExternalID struct {
+	ID         uint64    `json:"-" gorm:"primaryKey"`
+	Provider   string    `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_user_external_ids_provider_external_id"`
+	ExternalID string    `json:"externalId" gorm:"type:varchar(255);not null;uniqueIndex:idx_user_external_ids_provider_external_id"`
+	UserID     uint64    `json:"userId" gorm:"not null;index"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (ExternalID) TableName() string {
+	return "user_external_ids"
+}
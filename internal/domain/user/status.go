@@ -0,0 +1,94 @@
+package user
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status is the lifecycle state of an account; it decides which balance movements are allowed.
+type Status string
+
+const (
+	StatusActive Status = "active"
+	// StatusFrozen blocks all balance movements, e.g. while an account is investigated.
+	StatusFrozen Status = "frozen"
+	// StatusSelfExcluded blocks debits until SelfExcludedUntil; credits such as the settlement of
+	// earlier bets are still accepted.
+	StatusSelfExcluded Status = "self_excluded"
+	// StatusClosed is final and blocks all balance movements.
+	StatusClosed Status = "closed"
+)
+
+// ValidStatus reports whether s is a known status.
+func ValidStatus(s Status) bool {
+	switch s {
+	case StatusActive, StatusFrozen, StatusSelfExcluded, StatusClosed:
+		return true
+	}
+	return false
+}
+
+// transitions lists the statuses each status may be changed to. A self-exclusion can be
+// extended but not lifted before it ends; once it has ended the account counts as active.
+var transitions = map[Status][]Status{
+	StatusActive:       {StatusFrozen, StatusSelfExcluded, StatusClosed},
+	StatusFrozen:       {StatusActive, StatusClosed},
+	StatusSelfExcluded: {StatusSelfExcluded, StatusClosed},
+	StatusClosed:       {},
+}
+
+// CurrentStatus returns the user's status at now, treating an ended self-exclusion as active.
+// Rows stored before statuses existed have an empty status and count as active.
+func (u *User) CurrentStatus(now time.Time) Status {
+	switch {
+	case u.Status == "":
+		return StatusActive
+	case u.Status == StatusSelfExcluded && u.SelfExcludedUntil != nil && !now.Before(*u.SelfExcludedUntil):
+		return StatusActive
+	default:
+		return u.Status
+	}
+}
+
+// CanDebit reports whether the user's balance may be debited at now.
+func (u *User) CanDebit(now time.Time) bool {
+	return u.CurrentStatus(now) == StatusActive
+}
+
+// CanCredit reports whether the user's balance may be credited at now.
+func (u *User) CanCredit(now time.Time) bool {
+	status := u.CurrentStatus(now)
+	return status == StatusActive || status == StatusSelfExcluded
+}
+
+// CheckTransition returns an error if the user may not be changed to status to at now. until is
+// the end of the self-exclusion when to is StatusSelfExcluded.
+func (u *User) CheckTransition(to Status, until *time.Time, now time.Time) error {
+	from := u.CurrentStatus(now)
+	allowed := false
+	for _, s := range transitions[from] {
+		if s == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("account status cannot change from %s to %s", from, to)
+	}
+	if from == StatusSelfExcluded && to == StatusSelfExcluded && u.SelfExcludedUntil != nil && until.Before(*u.SelfExcludedUntil) {
+		return fmt.Errorf("a self-exclusion can be extended but not shortened")
+	}
+	return nil
+}
+
+// StatusChange is an audit record of a change of a user's status.
+type StatusChange struct {
+	ID     uint64 `json:"id" gorm:"primaryKey"`
+	UserID uint64 `json:"userId" gorm:"not null;index"`
+	// FromStatus is the stored status the change was made from.
+	FromStatus        Status     `json:"fromStatus" gorm:"type:varchar(15);not null"`
+	ToStatus          Status     `json:"toStatus" gorm:"type:varchar(15);not null"`
+	SelfExcludedUntil *time.Time `json:"selfExcludedUntil,omitempty"`
+	Reason            string     `json:"reason" gorm:"type:text;not null"`
+	CreatedAt         time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
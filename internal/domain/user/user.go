@@ -1,21 +1,164 @@
 package user
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/ledger"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
 	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
 )
 
 type User struct {
-	ID        uint64          `json:"userId" gorm:"primaryKey"`
-	Balance   decimal.Decimal `json:"balance" gorm:"type:numeric(20,2);default:0.00;not null"`
-	CreatedAt time.Time       `gorm:"autoCreateTime"`
-	UpdatedAt time.Time       `gorm:"autoUpdateTime"`
+	ID uint64 `json:"userId" gorm:"primaryKey"`
+	// ExternalRef is an optional reference to the user in an operator's own systems, unique if set.
+	ExternalRef *string `json:"externalRef,omitempty" gorm:"type:varchar(255);uniqueIndex:idx_users_external_ref"`
+	Status      Status  `json:"status" gorm:"type:varchar(15);not null;default:'active'"`
+	// SelfExcludedUntil is when a self-exclusion ends; nil unless the status is self_excluded.
+	SelfExcludedUntil *time.Time `json:"selfExcludedUntil,omitempty"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime"`
+}
+
+// Wallet holds a user's funds in one currency. A user has at most one wallet per currency;
+// wallets are opened on the first transaction in their currency.
+// Balance is the total of the wallet; BonusBalance is the part of it that is bonus money, the rest is real money.
+// HeldBalance is the part of the balance reserved by active holds, which cannot be spent otherwise.
+// CreditLimit is how far the balance may be drawn below zero; it is zero unless granted by an admin.
+type Wallet struct {
+	UserID       uint64          `json:"userId" gorm:"primaryKey;autoIncrement:false"`
+	Currency     string          `json:"currency" gorm:"primaryKey;type:varchar(3)"` // ISO 4217 code
+	Balance      decimal.Decimal `json:"balance" gorm:"type:numeric(24,4);default:0;not null"`
+	BonusBalance decimal.Decimal `json:"bonusBalance" gorm:"type:numeric(24,4);default:0;not null"`
+	HeldBalance  decimal.Decimal `json:"heldBalance" gorm:"type:numeric(24,4);default:0;not null"`
+	CreditLimit  decimal.Decimal `json:"creditLimit" gorm:"type:numeric(24,4);default:0;not null"`
+	Version      uint64          `json:"version" gorm:"not null;default:0"` // Incremented on every balance change, used for optimistic locking
+	CreatedAt    time.Time       `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time       `gorm:"autoUpdateTime"`
+}
+
+// BalanceUpdate describes a change to a wallet's balance together with the transaction and the
+// journal entry that record it.
+type BalanceUpdate struct {
+	// Currency selects the wallet; it is opened if the user has none in this currency yet.
+	Currency string
+	// Delta is the signed amount added to the balance.
+	Delta decimal.Decimal
+	// Allocation decides how Delta is split between the real and bonus balances.
+	Allocation Allocation
+	// HeldDelta is the signed change of the held balance, e.g. the negated amount of a hold being captured.
+	HeldDelta decimal.Decimal
+	// AllowNegative skips the insufficient-balance checks, e.g. for forced reversals.
+	AllowNegative bool
+	// StrictRounds rejects a payout for an unknown round and stakes or payouts for closed rounds
+	// when the transaction belongs to a round.
+	StrictRounds bool
+	// Limits are the user's limits in force in the currency of the kind the transaction counts
+	// towards, if any, such as loss limits for stakes. The repository checks them against the
+	// locked wallet.
+	Limits      []limit.Limit
+	Transaction *transaction.Transaction
+	// Entry moves Delta between the user's ledger account and a counterparty account.
+	Entry *ledger.JournalEntry
+}
+
+// BatchUpdate is one balance update of a batch applied with Repository.ApplyBatch.
+type BatchUpdate struct {
+	UserID uint64
+	Update BalanceUpdate
+}
+
+// BatchError reports the update of a batch that failed; none of the batch's updates was applied.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch update %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Available returns the part of the balance that is not reserved by holds.
+func (w *Wallet) Available() decimal.Decimal {
+	return w.Balance.Sub(w.HeldBalance)
+}
+
+// Spendable returns how much can be debited: the available balance plus the credit limit.
+func (w *Wallet) Spendable() decimal.Decimal {
+	return w.Available().Add(w.CreditLimit)
+}
+
+// InsufficientBalanceError reports that a debit would take the wallet below what it can spend,
+// naming the balance, available balance or credit limit that applies.
+func (w *Wallet) InsufficientBalanceError() error {
+	switch {
+	case w.CreditLimit.IsPositive():
+		return appErrors.NewInsufficientBalanceError(fmt.Sprintf("insufficient balance: available balance remains %s %s with a credit limit of %s %s",
+			currency.Format(w.Currency, w.Available()), w.Currency, currency.Format(w.Currency, w.CreditLimit), w.Currency))
+	case w.HeldBalance.IsZero():
+		return appErrors.NewInsufficientBalanceError(fmt.Sprintf("insufficient balance: balance remains %s %s",
+			currency.Format(w.Currency, w.Balance), w.Currency))
+	default:
+		return appErrors.NewInsufficientBalanceError(fmt.Sprintf("insufficient balance: available balance remains %s %s",
+			currency.Format(w.Currency, w.Available()), w.Currency))
+	}
+}
+
+// ListFilter narrows down a listing of users, which is ordered by ID. Zero values mean "no filter".
+type ListFilter struct {
+	Status  Status
+	AfterID uint64 // return users with a greater ID
+	Limit   int
 }
 
 type Repository interface {
 	GetByID(id uint64) (*User, error)
-	AtomicUpdateBalanceAndCreateTransaction(userID uint64, newBalance decimal.Decimal, newTransaction *transaction.Transaction) error
+	List(filter ListFilter) ([]User, error)
+	// GetWallet returns sql.ErrNoRows if the user has no wallet in the currency.
+	GetWallet(userID uint64, currency string) (*Wallet, error)
+	// ListWallets returns all wallets of the user ordered by currency.
+	ListWallets(userID uint64) ([]Wallet, error)
+	// AtomicUpdateBalanceAndCreateTransaction applies the update to the wallet's balance under a row lock,
+	// splitting it between the sub-balances of the locked wallet, and records its transaction and journal
+	// entry, returning the resulting balance.
+	AtomicUpdateBalanceAndCreateTransaction(userID uint64, update BalanceUpdate) (decimal.Decimal, error)
+	// UpdateBalanceIfVersionMatches applies the update and records its transaction and journal entry only if the
+	// wallet's version still equals expectedVersion, returning a version conflict error otherwise.
+	// A missing wallet is opened and has version 0.
+	UpdateBalanceIfVersionMatches(userID uint64, expectedVersion uint64, update BalanceUpdate) (decimal.Decimal, error)
+	// ApplyBatch applies the updates in order like AtomicUpdateBalanceAndCreateTransaction, all in one
+	// database transaction: either every update is applied or none. The wallets involved are locked up
+	// front in a fixed order. A failing update is reported as a *BatchError.
+	ApplyBatch(updates []BatchUpdate) error
+	// SetCreditLimit sets the credit limit of the user's wallet in the currency, opening the wallet
+	// if needed, and returns the updated wallet with its version bumped.
+	SetCreditLimit(userID uint64, currency string, limit decimal.Decimal) (*Wallet, error)
+	// ChangeStatus applies the change to the user and records it in the audit trail, failing with a
+	// conflict if the user's stored status is no longer change.FromStatus. It bumps the version of the
+	// user's wallets and returns the updated user.
+	ChangeStatus(change *StatusChange) (*User, error)
+	// ListStatusChanges returns the user's status changes, oldest first.
+	ListStatusChanges(userID uint64) ([]StatusChange, error)
 	Create(user *User) error
+	// CreateWithWallet creates the user together with an empty wallet in the currency. A reused
+	// external reference is reported as a conflict.
+	CreateWithWallet(user *User, currency string) error
+	// GetByExternalID returns the mapping of the provider's player ID, or sql.ErrNoRows if there is none.
+	GetByExternalID(provider, externalID string) (*ExternalID, error)
+	// ListExternalIDs returns the user's external IDs ordered by provider.
+	ListExternalIDs(userID uint64) ([]ExternalID, error)
+	// LinkExternalID maps an external ID to an existing user. An external ID that is already mapped
+	// is reported as a conflict.
+	LinkExternalID(link *ExternalID) error
+	// ProvisionExternalUser creates a user with an empty wallet in the currency and maps link to it,
+	// setting link.UserID. If the external ID was mapped concurrently, it fails with an already
+	// processed error and creates nothing.
+	ProvisionExternalUser(link *ExternalID, currency string) (*User, error)
 }
@@ -0,0 +1,54 @@
+package mocks
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/hold"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+)
+
+type MockHoldRepository struct {
+	PlaceFunc       func(h *hold.Hold, lossLimits []limit.Limit) error
+	GetByHoldIDFunc func(sourceType, holdID string) (*hold.Hold, error)
+	CaptureFunc     func(id uint64, update user.BalanceUpdate) (decimal.Decimal, error)
+	ReleaseFunc     func(id uint64, status hold.Status) (*hold.Hold, error)
+	ListExpiredFunc func(now time.Time, limit int) ([]hold.Hold, error)
+}
+
+func (m *MockHoldRepository) Place(h *hold.Hold, lossLimits []limit.Limit) error {
+	if m.PlaceFunc != nil {
+		return m.PlaceFunc(h, lossLimits)
+	}
+	return errors.New("PlaceFunc not set")
+}
+
+func (m *MockHoldRepository) GetByHoldID(sourceType, holdID string) (*hold.Hold, error) {
+	if m.GetByHoldIDFunc != nil {
+		return m.GetByHoldIDFunc(sourceType, holdID)
+	}
+	return nil, errors.New("GetByHoldIDFunc not set")
+}
+
+func (m *MockHoldRepository) Capture(id uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+	if m.CaptureFunc != nil {
+		return m.CaptureFunc(id, update)
+	}
+	return decimal.Decimal{}, errors.New("CaptureFunc not set")
+}
+
+func (m *MockHoldRepository) Release(id uint64, status hold.Status) (*hold.Hold, error) {
+	if m.ReleaseFunc != nil {
+		return m.ReleaseFunc(id, status)
+	}
+	return nil, errors.New("ReleaseFunc not set")
+}
+
+func (m *MockHoldRepository) ListExpired(now time.Time, limit int) ([]hold.Hold, error) {
+	if m.ListExpiredFunc != nil {
+		return m.ListExpiredFunc(now, limit)
+	}
+	return nil, errors.New("ListExpiredFunc not set")
+}
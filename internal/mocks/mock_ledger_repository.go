@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"errors"
+
+	"github.com/zaynkorai/enlabs/internal/domain/ledger"
+)
+
+type MockLedgerRepository struct {
+	ReconcileUserFunc     func(userID uint64) ([]ledger.Reconciliation, error)
+	MismatchedWalletsFunc func() ([]ledger.Reconciliation, error)
+	UnbalancedEntriesFunc func() ([]ledger.UnbalancedEntry, error)
+}
+
+func (m *MockLedgerRepository) ReconcileUser(userID uint64) ([]ledger.Reconciliation, error) {
+	if m.ReconcileUserFunc != nil {
+		return m.ReconcileUserFunc(userID)
+	}
+	return nil, errors.New("ReconcileUserFunc not set")
+}
+
+func (m *MockLedgerRepository) MismatchedWallets() ([]ledger.Reconciliation, error) {
+	if m.MismatchedWalletsFunc != nil {
+		return m.MismatchedWalletsFunc()
+	}
+	return nil, errors.New("MismatchedWalletsFunc not set")
+}
+
+func (m *MockLedgerRepository) UnbalancedEntries() ([]ledger.UnbalancedEntry, error) {
+	if m.UnbalancedEntriesFunc != nil {
+		return m.UnbalancedEntriesFunc()
+	}
+	return nil, errors.New("UnbalancedEntriesFunc not set")
+}
@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"errors"
+
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+)
+
+type MockLimitRepository struct {
+	ListByUserFunc func(userID uint64) ([]limit.Limit, error)
+	GetFunc        func(userID uint64, kind limit.Kind, currency string, period limit.Period) (*limit.Limit, error)
+	SaveFunc       func(l *limit.Limit) error
+	DeleteFunc     func(id uint64) error
+}
+
+func (m *MockLimitRepository) ListByUser(userID uint64) ([]limit.Limit, error) {
+	if m.ListByUserFunc != nil {
+		return m.ListByUserFunc(userID)
+	}
+	return nil, errors.New("ListByUserFunc not set")
+}
+
+func (m *MockLimitRepository) Get(userID uint64, kind limit.Kind, currency string, period limit.Period) (*limit.Limit, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(userID, kind, currency, period)
+	}
+	return nil, errors.New("GetFunc not set")
+}
+
+func (m *MockLimitRepository) Save(l *limit.Limit) error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc(l)
+	}
+	return errors.New("SaveFunc not set")
+}
+
+func (m *MockLimitRepository) Delete(id uint64) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(id)
+	}
+	return errors.New("DeleteFunc not set")
+}
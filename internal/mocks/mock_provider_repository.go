@@ -0,0 +1,76 @@
+package mocks
+
+import (
+	"errors"
+	"time"
+
+	"github.com/zaynkorai/enlabs/internal/domain/provider"
+)
+
+type MockProviderRepository struct {
+	ListFunc      func() ([]provider.Provider, error)
+	GetFunc       func(name string) (*provider.Provider, error)
+	SaveFunc      func(p *provider.Provider) error
+	GetKeyFunc    func(id string) (*provider.Key, error)
+	ListKeysFunc  func(name string) ([]provider.Key, error)
+	AddKeyFunc    func(key *provider.Key, keep int) error
+	DeleteKeyFunc func(name, id string) error
+
+	RecordSignatureFunc func(sig *provider.Signature, expired time.Time) (bool, error)
+}
+
+func (m *MockProviderRepository) List() ([]provider.Provider, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc()
+	}
+	return nil, errors.New("ListFunc not set")
+}
+
+func (m *MockProviderRepository) Get(name string) (*provider.Provider, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(name)
+	}
+	return nil, errors.New("GetFunc not set")
+}
+
+func (m *MockProviderRepository) Save(p *provider.Provider) error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc(p)
+	}
+	return errors.New("SaveFunc not set")
+}
+
+func (m *MockProviderRepository) GetKey(id string) (*provider.Key, error) {
+	if m.GetKeyFunc != nil {
+		return m.GetKeyFunc(id)
+	}
+	return nil, errors.New("GetKeyFunc not set")
+}
+
+func (m *MockProviderRepository) ListKeys(name string) ([]provider.Key, error) {
+	if m.ListKeysFunc != nil {
+		return m.ListKeysFunc(name)
+	}
+	return nil, errors.New("ListKeysFunc not set")
+}
+
+func (m *MockProviderRepository) AddKey(key *provider.Key, keep int) error {
+	if m.AddKeyFunc != nil {
+		return m.AddKeyFunc(key, keep)
+	}
+	return errors.New("AddKeyFunc not set")
+}
+
+func (m *MockProviderRepository) DeleteKey(name, id string) error {
+	if m.DeleteKeyFunc != nil {
+		return m.DeleteKeyFunc(name, id)
+	}
+	return errors.New("DeleteKeyFunc not set")
+}
+
+func (m *MockProviderRepository) RecordSignature(sig *provider.Signature, expired time.Time) (bool, error) {
+	if m.RecordSignatureFunc != nil {
+		return m.RecordSignatureFunc(sig, expired)
+	}
+	return false, errors.New("RecordSignatureFunc not set")
+}
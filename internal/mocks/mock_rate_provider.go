@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"errors"
+
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+)
+
+type MockRateProvider struct {
+	RateFunc func(from, to string) (currency.Rate, error)
+}
+
+func (m *MockRateProvider) Rate(from, to string) (currency.Rate, error) {
+	if m.RateFunc != nil {
+		return m.RateFunc(from, to)
+	}
+	return currency.Rate{}, errors.New("RateFunc not set")
+}
@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"errors"
+
+	"github.com/zaynkorai/enlabs/internal/domain/round"
+)
+
+type MockRoundRepository struct {
+	GetByRoundIDFunc func(sourceType, roundID string) (*round.Round, error)
+}
+
+func (m *MockRoundRepository) GetByRoundID(sourceType, roundID string) (*round.Round, error) {
+	if m.GetByRoundIDFunc != nil {
+		return m.GetByRoundIDFunc(sourceType, roundID)
+	}
+	return nil, errors.New("GetByRoundIDFunc not set")
+}
@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"errors"
+
+	"github.com/zaynkorai/enlabs/internal/domain/source"
+)
+
+type MockSourceRepository struct {
+	ListFunc func() ([]source.Source, error)
+	GetFunc  func(name string) (*source.Source, error)
+	SaveFunc func(s *source.Source) error
+}
+
+func (m *MockSourceRepository) List() ([]source.Source, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc()
+	}
+	return nil, errors.New("ListFunc not set")
+}
+
+func (m *MockSourceRepository) Get(name string) (*source.Source, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(name)
+	}
+	return nil, errors.New("GetFunc not set")
+}
+
+func (m *MockSourceRepository) Save(s *source.Source) error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc(s)
+	}
+	return errors.New("SaveFunc not set")
+}
@@ -7,8 +7,11 @@ import (
 )
 
 type MockTransactionRepository struct {
-	CreateFunc             func(transaction *transaction.Transaction) error
-	GetByTransactionIDFunc func(transactionID string) (*transaction.Transaction, error)
+	CreateFunc                 func(transaction *transaction.Transaction) error
+	GetByTransactionIDFunc     func(sourceType, transactionID string) (*transaction.Transaction, error)
+	ListByUserFunc             func(userID uint64, filter transaction.ListFilter) ([]transaction.Transaction, error)
+	ListByUserInApplyOrderFunc func(userID uint64, afterID uint64, limit int) ([]transaction.Transaction, error)
+	ListByRoundFunc            func(sourceType, roundID string) ([]transaction.Transaction, error)
 }
 
 func (m *MockTransactionRepository) Create(transaction *transaction.Transaction) error {
@@ -18,9 +21,30 @@ func (m *MockTransactionRepository) Create(transaction *transaction.Transaction)
 	return errors.New("CreateFunc not set")
 }
 
-func (m *MockTransactionRepository) GetByTransactionID(transactionID string) (*transaction.Transaction, error) {
+func (m *MockTransactionRepository) GetByTransactionID(sourceType, transactionID string) (*transaction.Transaction, error) {
 	if m.GetByTransactionIDFunc != nil {
-		return m.GetByTransactionIDFunc(transactionID)
+		return m.GetByTransactionIDFunc(sourceType, transactionID)
 	}
 	return nil, errors.New("GetByTransactionIDFunc not set")
 }
+
+func (m *MockTransactionRepository) ListByUser(userID uint64, filter transaction.ListFilter) ([]transaction.Transaction, error) {
+	if m.ListByUserFunc != nil {
+		return m.ListByUserFunc(userID, filter)
+	}
+	return nil, errors.New("ListByUserFunc not set")
+}
+
+func (m *MockTransactionRepository) ListByUserInApplyOrder(userID uint64, afterID uint64, limit int) ([]transaction.Transaction, error) {
+	if m.ListByUserInApplyOrderFunc != nil {
+		return m.ListByUserInApplyOrderFunc(userID, afterID, limit)
+	}
+	return nil, errors.New("ListByUserInApplyOrderFunc not set")
+}
+
+func (m *MockTransactionRepository) ListByRound(sourceType, roundID string) ([]transaction.Transaction, error) {
+	if m.ListByRoundFunc != nil {
+		return m.ListByRoundFunc(sourceType, roundID)
+	}
+	return nil, errors.New("ListByRoundFunc not set")
+}
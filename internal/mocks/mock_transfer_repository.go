@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"errors"
+
+	"github.com/zaynkorai/enlabs/internal/domain/transfer"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+)
+
+type MockTransferRepository struct {
+	ExecuteFunc         func(t *transfer.Transfer, debit, credit user.BalanceUpdate) error
+	GetByTransferIDFunc func(transferID string) (*transfer.Transfer, error)
+}
+
+func (m *MockTransferRepository) Execute(t *transfer.Transfer, debit, credit user.BalanceUpdate) error {
+	if m.ExecuteFunc != nil {
+		return m.ExecuteFunc(t, debit, credit)
+	}
+	return errors.New("ExecuteFunc not set")
+}
+
+func (m *MockTransferRepository) GetByTransferID(transferID string) (*transfer.Transfer, error) {
+	if m.GetByTransferIDFunc != nil {
+		return m.GetByTransferIDFunc(transferID)
+	}
+	return nil, errors.New("GetByTransferIDFunc not set")
+}
@@ -4,14 +4,26 @@ import (
 	"errors"
 
 	"github.com/shopspring/decimal"
-	"github.com/zaynkorai/enlabs/internal/domain/transaction"
 	"github.com/zaynkorai/enlabs/internal/domain/user"
 )
 
 type MockUserRepository struct {
 	GetByIDFunc                                 func(id uint64) (*user.User, error)
-	AtomicUpdateBalanceAndCreateTransactionFunc func(userID uint64, newBalance decimal.Decimal, newTransaction *transaction.Transaction) error
+	ListFunc                                    func(filter user.ListFilter) ([]user.User, error)
+	GetWalletFunc                               func(userID uint64, currency string) (*user.Wallet, error)
+	ListWalletsFunc                             func(userID uint64) ([]user.Wallet, error)
+	AtomicUpdateBalanceAndCreateTransactionFunc func(userID uint64, update user.BalanceUpdate) (decimal.Decimal, error)
+	UpdateBalanceIfVersionMatchesFunc           func(userID uint64, expectedVersion uint64, update user.BalanceUpdate) (decimal.Decimal, error)
+	ApplyBatchFunc                              func(updates []user.BatchUpdate) error
+	SetCreditLimitFunc                          func(userID uint64, currency string, limit decimal.Decimal) (*user.Wallet, error)
+	ChangeStatusFunc                            func(change *user.StatusChange) (*user.User, error)
+	ListStatusChangesFunc                       func(userID uint64) ([]user.StatusChange, error)
 	CreateFunc                                  func(user *user.User) error
+	CreateWithWalletFunc                        func(user *user.User, currency string) error
+	GetByExternalIDFunc                         func(provider, externalID string) (*user.ExternalID, error)
+	ListExternalIDsFunc                         func(userID uint64) ([]user.ExternalID, error)
+	LinkExternalIDFunc                          func(link *user.ExternalID) error
+	ProvisionExternalUserFunc                   func(link *user.ExternalID, currency string) (*user.User, error)
 }
 
 func (m *MockUserRepository) GetByID(id uint64) (*user.User, error) {
@@ -21,11 +33,67 @@ func (m *MockUserRepository) GetByID(id uint64) (*user.User, error) {
 	return nil, errors.New("GetByIDFunc not set")
 }
 
-func (m *MockUserRepository) AtomicUpdateBalanceAndCreateTransaction(userID uint64, newBalance decimal.Decimal, newTransaction *transaction.Transaction) error {
+func (m *MockUserRepository) List(filter user.ListFilter) ([]user.User, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(filter)
+	}
+	return nil, errors.New("ListFunc not set")
+}
+
+func (m *MockUserRepository) GetWallet(userID uint64, currency string) (*user.Wallet, error) {
+	if m.GetWalletFunc != nil {
+		return m.GetWalletFunc(userID, currency)
+	}
+	return nil, errors.New("GetWalletFunc not set")
+}
+
+func (m *MockUserRepository) ListWallets(userID uint64) ([]user.Wallet, error) {
+	if m.ListWalletsFunc != nil {
+		return m.ListWalletsFunc(userID)
+	}
+	return nil, errors.New("ListWalletsFunc not set")
+}
+
+func (m *MockUserRepository) AtomicUpdateBalanceAndCreateTransaction(userID uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
 	if m.AtomicUpdateBalanceAndCreateTransactionFunc != nil {
-		return m.AtomicUpdateBalanceAndCreateTransactionFunc(userID, newBalance, newTransaction)
+		return m.AtomicUpdateBalanceAndCreateTransactionFunc(userID, update)
+	}
+	return decimal.Decimal{}, errors.New("AtomicUpdateBalanceAndCreateTransactionFunc not set")
+}
+
+func (m *MockUserRepository) UpdateBalanceIfVersionMatches(userID uint64, expectedVersion uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+	if m.UpdateBalanceIfVersionMatchesFunc != nil {
+		return m.UpdateBalanceIfVersionMatchesFunc(userID, expectedVersion, update)
+	}
+	return decimal.Decimal{}, errors.New("UpdateBalanceIfVersionMatchesFunc not set")
+}
+
+func (m *MockUserRepository) ApplyBatch(updates []user.BatchUpdate) error {
+	if m.ApplyBatchFunc != nil {
+		return m.ApplyBatchFunc(updates)
 	}
-	return errors.New("AtomicUpdateBalanceAndCreateTransactionFunc not set")
+	return errors.New("ApplyBatchFunc not set")
+}
+
+func (m *MockUserRepository) SetCreditLimit(userID uint64, currency string, limit decimal.Decimal) (*user.Wallet, error) {
+	if m.SetCreditLimitFunc != nil {
+		return m.SetCreditLimitFunc(userID, currency, limit)
+	}
+	return nil, errors.New("SetCreditLimitFunc not set")
+}
+
+func (m *MockUserRepository) ChangeStatus(change *user.StatusChange) (*user.User, error) {
+	if m.ChangeStatusFunc != nil {
+		return m.ChangeStatusFunc(change)
+	}
+	return nil, errors.New("ChangeStatusFunc not set")
+}
+
+func (m *MockUserRepository) ListStatusChanges(userID uint64) ([]user.StatusChange, error) {
+	if m.ListStatusChangesFunc != nil {
+		return m.ListStatusChangesFunc(userID)
+	}
+	return nil, errors.New("ListStatusChangesFunc not set")
 }
 
 func (m *MockUserRepository) Create(user *user.User) error {
@@ -34,3 +102,38 @@ func (m *MockUserRepository) Create(user *user.User) error {
 	}
 	return errors.New("CreateFunc not set")
 }
+
+func (m *MockUserRepository) CreateWithWallet(user *user.User, currency string) error {
+	if m.CreateWithWalletFunc != nil {
+		return m.CreateWithWalletFunc(user, currency)
+	}
+	return errors.New("CreateWithWalletFunc not set")
+}
+
+func (m *MockUserRepository) GetByExternalID(provider, externalID string) (*user.ExternalID, error) {
+	if m.GetByExternalIDFunc != nil {
+		return m.GetByExternalIDFunc(provider, externalID)
+	}
+	return nil, errors.New("GetByExternalIDFunc not set")
+}
+
+func (m *MockUserRepository) ListExternalIDs(userID uint64) ([]user.ExternalID, error) {
+	if m.ListExternalIDsFunc != nil {
+		return m.ListExternalIDsFunc(userID)
+	}
+	return nil, errors.New("ListExternalIDsFunc not set")
+}
+
+func (m *MockUserRepository) LinkExternalID(link *user.ExternalID) error {
+	if m.LinkExternalIDFunc != nil {
+		return m.LinkExternalIDFunc(link)
+	}
+	return errors.New("LinkExternalIDFunc not set")
+}
+
+func (m *MockUserRepository) ProvisionExternalUser(link *user.ExternalID, currency string) (*user.User, error) {
+	if m.ProvisionExternalUserFunc != nil {
+		return m.ProvisionExternalUserFunc(link, currency)
+	}
+	return nil, errors.New("ProvisionExternalUserFunc not set")
+}
@@ -0,0 +1,179 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/hold"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// holdIdempotencyKey is the unique index that scopes external hold IDs per source.
+const holdIdempotencyKey = "idx_holds_source_hold_id"
+
+type HoldRepository struct {
+	db *gorm.DB
+}
+
+func NewHoldRepository(db *gorm.DB) *HoldRepository {
+	return &HoldRepository{db: db}
+}
+
+// Place locks the wallet, checks that its available balance covers the hold and that the loss
+// limits allow it, and reserves the amount in the same database transaction that stores the hold.
+// A reused hold ID is reported as already processed.
+func (r *HoldRepository) Place(h *hold.Hold, lossLimits []limit.Limit) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		locked, err := lockWallet(tx, h.UserID, h.Currency)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Without a wallet nothing is available; only the error depends on whether the user exists.
+			if openErr := openWallet(tx, h.UserID, h.Currency); openErr != nil {
+				return openErr
+			}
+			locked, err = lockWallet(tx, h.UserID, h.Currency)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock %s wallet of user %d: %w", h.Currency, h.UserID, err)
+		}
+		if locked.Spendable().LessThan(h.Amount) {
+			return locked.InsufficientBalanceError()
+		}
+		if err := checkLimits(tx, locked, lossLimits, h.Amount); err != nil {
+			return err
+		}
+
+		if err := tx.Create(h).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == holdIdempotencyKey {
+				return appErrors.NewAlreadyProcessedError("hold with this ID has already been placed")
+			}
+			return fmt.Errorf("failed to create hold: %w", err)
+		}
+		return updateHeldBalance(tx, h.UserID, h.Currency, h.Amount)
+	})
+}
+
+func (r *HoldRepository) GetByHoldID(sourceType, holdID string) (*hold.Hold, error) {
+	var h hold.Hold
+	result := r.db.Where("source_type = ? AND hold_id = ?", sourceType, holdID).First(&h)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get hold by ID %s/%s: %w", sourceType, holdID, result.Error)
+	}
+	return &h, nil
+}
+
+// Capture locks the hold before the wallet, as Release does, so that a capture racing the sweeper
+// either settles the hold or finds it expired.
+func (r *HoldRepository) Capture(id uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+	var newBalance decimal.Decimal
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		h, err := lockActiveHold(tx, id)
+		if err != nil {
+			return err
+		}
+		if h.Expired(time.Now()) {
+			return appErrors.NewConflictError(fmt.Sprintf("hold %s has expired", h.HoldID))
+		}
+
+		update.HeldDelta = h.Amount.Neg()
+		newBalance, err = applyBalanceUpdate(tx, h.UserID, update)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(h).Updates(map[string]interface{}{
+			"status":         hold.StatusCaptured,
+			"transaction_id": update.Transaction.ID,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to mark hold %s as captured: %w", h.HoldID, result.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return newBalance, nil
+}
+
+func (r *HoldRepository) Release(id uint64, status hold.Status) (*hold.Hold, error) {
+	var released *hold.Hold
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		h, err := lockActiveHold(tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := lockWallet(tx, h.UserID, h.Currency); err != nil {
+			return fmt.Errorf("failed to lock %s wallet of user %d: %w", h.Currency, h.UserID, err)
+		}
+		if err := updateHeldBalance(tx, h.UserID, h.Currency, h.Amount.Neg()); err != nil {
+			return err
+		}
+		if err := tx.Model(h).Update("status", status).Error; err != nil {
+			return fmt.Errorf("failed to mark hold %s as %s: %w", h.HoldID, status, err)
+		}
+		released = h
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+func (r *HoldRepository) ListExpired(now time.Time, limit int) ([]hold.Hold, error) {
+	var holds []hold.Hold
+	err := r.db.Where("status = ? AND expires_at <= ?", hold.StatusActive, now).
+		Order("expires_at, id").
+		Limit(limit).
+		Find(&holds).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired holds: %w", err)
+	}
+	return holds, nil
+}
+
+// lockActiveHold locks the hold row, failing with a conflict if it is no longer active.
+func lockActiveHold(tx *gorm.DB, id uint64) (*hold.Hold, error) {
+	var h hold.Hold
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&h, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, appErrors.NewNotFoundError(fmt.Sprintf("hold %d not found", id))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock hold %d: %w", id, err)
+	}
+	if h.Status != hold.StatusActive {
+		return nil, appErrors.NewConflictError(fmt.Sprintf("hold %s is already %s", h.HoldID, h.Status))
+	}
+	return &h, nil
+}
+
+// updateHeldBalance changes the amount reserved on the wallet, which the caller has locked.
+func updateHeldBalance(tx *gorm.DB, userID uint64, code string, delta decimal.Decimal) error {
+	result := tx.Model(&user.Wallet{}).
+		Where("user_id = ? AND currency = ?", userID, code).
+		Updates(map[string]interface{}{
+			"held_balance": gorm.Expr("held_balance + ?", delta),
+			"version":      gorm.Expr("version + 1"),
+			"updated_at":   gorm.Expr("NOW()"),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update held balance: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s wallet of user %d not found", code, userID)
+	}
+	return nil
+}
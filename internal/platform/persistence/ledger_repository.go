@@ -0,0 +1,96 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/zaynkorai/enlabs/internal/domain/ledger"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reconciliationQuery pairs every wallet's stored balance with the sum of the postings on its account.
+const reconciliationQuery = `SELECT w.user_id, w.currency, w.balance, COALESCE(p.total, 0) AS ledger_balance
+FROM wallets w
+LEFT JOIN ledger_accounts a ON a.user_id = w.user_id AND a.currency = w.currency
+LEFT JOIN (SELECT account_code, SUM(amount) AS total FROM ledger_postings GROUP BY account_code) p ON p.account_code = a.code`
+
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+func (r *LedgerRepository) ReconcileUser(userID uint64) ([]ledger.Reconciliation, error) {
+	var reconciliations []ledger.Reconciliation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user.User{}, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return sql.ErrNoRows
+			}
+			return err
+		}
+		return tx.Raw(reconciliationQuery+" WHERE w.user_id = ? ORDER BY w.currency", userID).Scan(&reconciliations).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile balances of user %d: %w", userID, err)
+	}
+	return reconciliations, nil
+}
+
+func (r *LedgerRepository) MismatchedWallets() ([]ledger.Reconciliation, error) {
+	var reconciliations []ledger.Reconciliation
+	err := r.db.Raw(reconciliationQuery + " WHERE w.balance <> COALESCE(p.total, 0) ORDER BY w.user_id, w.currency").Scan(&reconciliations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find wallets with mismatched balances: %w", err)
+	}
+	return reconciliations, nil
+}
+
+func (r *LedgerRepository) UnbalancedEntries() ([]ledger.UnbalancedEntry, error) {
+	var entries []ledger.UnbalancedEntry
+	err := r.db.Model(&ledger.Posting{}).
+		Select("journal_entry_id, SUM(amount) AS sum").
+		Group("journal_entry_id").
+		Having("SUM(amount) <> 0").
+		Order("journal_entry_id").
+		Scan(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find unbalanced journal entries: %w", err)
+	}
+	return entries, nil
+}
+
+// createJournalEntry validates and inserts the entry with its postings inside tx, linking it to the
+// transaction with the given ID. Accounts referenced by the postings are created on first use.
+func createJournalEntry(tx *gorm.DB, entry *ledger.JournalEntry, transactionID uint64) error {
+	if entry == nil {
+		return fmt.Errorf("balance update for transaction %d has no journal entry", transactionID)
+	}
+	if err := entry.Validate(); err != nil {
+		return fmt.Errorf("invalid journal entry for transaction %d: %w", transactionID, err)
+	}
+
+	if len(entry.Accounts) > 0 {
+		// Insert in a fixed order so that concurrent first uses of the same accounts cannot deadlock.
+		accounts := append([]ledger.Account(nil), entry.Accounts...)
+		sort.Slice(accounts, func(i, j int) bool { return accounts[i].Code < accounts[j].Code })
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&accounts).Error; err != nil {
+			return fmt.Errorf("failed to create ledger accounts: %w", err)
+		}
+	}
+
+	entry.TransactionID = &transactionID
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create journal entry: %w", err)
+	}
+	return nil
+}
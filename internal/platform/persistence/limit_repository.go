@@ -0,0 +1,107 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LimitRepository struct {
+	db *gorm.DB
+}
+
+func NewLimitRepository(db *gorm.DB) *LimitRepository {
+	return &LimitRepository{db: db}
+}
+
+func (r *LimitRepository) ListByUser(userID uint64) ([]limit.Limit, error) {
+	var limits []limit.Limit
+	if err := r.db.Where("user_id = ?", userID).Order("kind, currency, id").Find(&limits).Error; err != nil {
+		return nil, fmt.Errorf("failed to list limits for user %d: %w", userID, err)
+	}
+	return limits, nil
+}
+
+func (r *LimitRepository) Get(userID uint64, kind limit.Kind, code string, period limit.Period) (*limit.Limit, error) {
+	var l limit.Limit
+	result := r.db.Where("user_id = ? AND kind = ? AND currency = ? AND period = ?", userID, kind, code, period).First(&l)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get %s %s %s limit for user %d: %w", period, code, kind, userID, result.Error)
+	}
+	return &l, nil
+}
+
+// Save inserts a new limit, or overwrites the one stored for the same user, kind, currency and
+// period if a concurrent request created it first.
+func (r *LimitRepository) Save(l *limit.Limit) error {
+	if l.ID != 0 {
+		if err := r.db.Save(l).Error; err != nil {
+			return fmt.Errorf("failed to save limit %d: %w", l.ID, err)
+		}
+		return nil
+	}
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "kind"}, {Name: "currency"}, {Name: "period"}},
+		DoUpdates: clause.AssignmentColumns([]string{"amount", "pending_amount", "pending_removal", "pending_from", "updated_at"}),
+	}).Create(l)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save %s %s %s limit for user %d: %w", l.Period, l.Currency, l.Kind, l.UserID, result.Error)
+	}
+	return nil
+}
+
+func (r *LimitRepository) Delete(id uint64) error {
+	if err := r.db.Delete(&limit.Limit{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete limit %d: %w", id, err)
+	}
+	return nil
+}
+
+// checkLimits rejects amount booked on the wallet if it would take the user's losses or deposits
+// over one of the limits. The caller has locked the wallet, so the sums include every transaction
+// booked before, among them the earlier updates of a batch in the same database transaction.
+// Amounts reserved by active holds are captured as losses and count as lost already.
+func checkLimits(tx *gorm.DB, w *user.Wallet, limits []limit.Limit, amount decimal.Decimal) error {
+	now := time.Now()
+	for _, l := range limits {
+		total, err := sumTransactions(tx, w.UserID, w.Currency, l.Kind.Types(), now.Add(-l.Period.Window()))
+		if err != nil {
+			return err
+		}
+		if l.Kind == limit.KindLoss {
+			total = total.Add(w.HeldBalance)
+		}
+		if err := l.Check(total, amount); err != nil {
+			return appErrors.NewLimitExceededError(err.Error())
+		}
+	}
+	return nil
+}
+
+// sumTransactions returns the total of the user's transactions of the given types in the currency
+// processed at or after since. A reversed transaction is counted out through the reversal's
+// reverses_id; the reversal itself is a "cancel" row and is not summed.
+func sumTransactions(tx *gorm.DB, userID uint64, code string, types []transaction.Type, since time.Time) (decimal.Decimal, error) {
+	var total decimal.NullDecimal
+	err := tx.Model(&transaction.Transaction{}).
+		Select("SUM(amount)").
+		Where("user_id = ? AND currency = ? AND state IN ? AND processed_at >= ?", userID, code, types, since).
+		Where("NOT EXISTS (SELECT 1 FROM transactions r WHERE r.reverses_id = transactions.id)").
+		Scan(&total).Error
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("failed to sum %v transactions of user %d: %w", types, userID, err)
+	}
+	return total.Decimal, nil
+}
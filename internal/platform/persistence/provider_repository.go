@@ -0,0 +1,116 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zaynkorai/enlabs/internal/domain/provider"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProviderRepository struct {
+	db *gorm.DB
+}
+
+func NewProviderRepository(db *gorm.DB) *ProviderRepository {
+	return &ProviderRepository{db: db}
+}
+
+func (r *ProviderRepository) List() ([]provider.Provider, error) {
+	var providers []provider.Provider
+	if err := r.db.Order("name").Find(&providers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list providers: %w", err)
+	}
+	return providers, nil
+}
+
+func (r *ProviderRepository) Get(name string) (*provider.Provider, error) {
+	var p provider.Provider
+	result := r.db.Where("name = ?", name).First(&p)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get provider %s: %w", name, result.Error)
+	}
+	return &p, nil
+}
+
+func (r *ProviderRepository) Save(p *provider.Provider) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "source_types", "updated_at"}),
+	}).Create(p).Error
+	if err != nil {
+		return fmt.Errorf("failed to save provider %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+func (r *ProviderRepository) GetKey(id string) (*provider.Key, error) {
+	var key provider.Key
+	result := r.db.Where("id = ?", id).First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get provider key %s: %w", id, result.Error)
+	}
+	return &key, nil
+}
+
+func (r *ProviderRepository) ListKeys(name string) ([]provider.Key, error) {
+	var keys []provider.Key
+	if err := r.db.Where("provider = ?", name).Order("created_at, id").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list keys of provider %s: %w", name, err)
+	}
+	return keys, nil
+}
+
+func (r *ProviderRepository) AddKey(key *provider.Key, keep int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the provider so that concurrent rotations cannot leave more than keep keys behind.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", key.Provider).First(&provider.Provider{}).Error; err != nil {
+			return fmt.Errorf("failed to lock provider %s: %w", key.Provider, err)
+		}
+		if err := tx.Create(key).Error; err != nil {
+			return fmt.Errorf("failed to create key of provider %s: %w", key.Provider, err)
+		}
+		retained := tx.Model(&provider.Key{}).Select("id").Where("provider = ?", key.Provider).Order("created_at DESC, id DESC").Limit(keep)
+		err := tx.Where("provider = ? AND id NOT IN (?)", key.Provider, retained).Delete(&provider.Key{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to retire keys of provider %s: %w", key.Provider, err)
+		}
+		return nil
+	})
+}
+
+func (r *ProviderRepository) DeleteKey(name, id string) error {
+	result := r.db.Where("provider = ? AND id = ?", name, id).Delete(&provider.Key{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete key %s of provider %s: %w", id, name, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *ProviderRepository) RecordSignature(sig *provider.Signature, expired time.Time) (bool, error) {
+	var recorded bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("seen_at < ?", expired).Delete(&provider.Signature{}).Error; err != nil {
+			return fmt.Errorf("failed to delete expired provider signatures: %w", err)
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(sig)
+		if result.Error != nil {
+			return fmt.Errorf("failed to record signature of key %s: %w", sig.KeyID, result.Error)
+		}
+		recorded = result.RowsAffected == 1
+		return nil
+	})
+	return recorded, err
+}
@@ -0,0 +1,99 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/zaynkorai/enlabs/internal/domain/round"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoundRepository struct {
+	db *gorm.DB
+}
+
+func NewRoundRepository(db *gorm.DB) *RoundRepository {
+	return &RoundRepository{db: db}
+}
+
+func (r *RoundRepository) GetByRoundID(sourceType, roundID string) (*round.Round, error) {
+	if sourceType != "" {
+		var rd round.Round
+		result := r.db.Where("source_type = ? AND round_id = ?", sourceType, roundID).First(&rd)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return nil, sql.ErrNoRows
+			}
+			return nil, fmt.Errorf("failed to get round by ID %s/%s: %w", sourceType, roundID, result.Error)
+		}
+		return &rd, nil
+	}
+
+	var matches []round.Round
+	result := r.db.Where("round_id = ?", roundID).Limit(2).Find(&matches)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get round by ID %s: %w", roundID, result.Error)
+	}
+	switch len(matches) {
+	case 0:
+		return nil, sql.ErrNoRows
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, appErrors.NewConflictError(fmt.Sprintf("round ID %s is used by several sources, specify the Source-Type", roundID))
+	}
+}
+
+// bookRound applies t to its round inside tx, re-checking the round's state against the locked row.
+// A round started concurrently by another transaction is waited for and then booked on.
+func bookRound(tx *gorm.DB, t *transaction.Transaction, strict bool) error {
+	if t.RoundID == "" {
+		return nil
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		var current *round.Round
+		var locked round.Round
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("source_type = ? AND round_id = ?", t.SourceType, t.RoundID).
+			First(&locked).Error
+		if err == nil {
+			current = &locked
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to lock round %s/%s: %w", t.SourceType, t.RoundID, err)
+		}
+
+		next, err := round.Book(current, t, strict)
+		if err != nil {
+			return roundError(err)
+		}
+		if next == nil {
+			return nil
+		}
+		if current != nil {
+			if err := tx.Save(next).Error; err != nil {
+				return fmt.Errorf("failed to update round %s/%s: %w", t.SourceType, t.RoundID, err)
+			}
+			return nil
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(next)
+		if result.Error != nil {
+			return fmt.Errorf("failed to create round %s/%s: %w", t.SourceType, t.RoundID, result.Error)
+		}
+		if result.RowsAffected == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("round %s/%s could not be booked", t.SourceType, t.RoundID)
+}
+
+func roundError(err error) error {
+	if errors.Is(err, round.ErrUnknownRound) {
+		return appErrors.NewValidationError(err.Error())
+	}
+	return appErrors.NewConflictError(err.Error())
+}
@@ -0,0 +1,50 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/zaynkorai/enlabs/internal/domain/source"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SourceRepository struct {
+	db *gorm.DB
+}
+
+func NewSourceRepository(db *gorm.DB) *SourceRepository {
+	return &SourceRepository{db: db}
+}
+
+func (r *SourceRepository) List() ([]source.Source, error) {
+	var sources []source.Source
+	if err := r.db.Order("name").Find(&sources).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+	return sources, nil
+}
+
+func (r *SourceRepository) Get(name string) (*source.Source, error) {
+	var s source.Source
+	result := r.db.Where("name = ?", name).First(&s)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get source %s: %w", name, result.Error)
+	}
+	return &s, nil
+}
+
+func (r *SourceRepository) Save(s *source.Source) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "states", "max_amount", "check_balance", "updated_at"}),
+	}).Create(s).Error
+	if err != nil {
+		return fmt.Errorf("failed to save source %s: %w", s.Name, err)
+	}
+	return nil
+}
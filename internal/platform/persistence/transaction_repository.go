@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
 	"gorm.io/gorm"
 )
 
@@ -24,14 +25,91 @@ func (r *TransactionRepository) Create(t *transaction.Transaction) error {
 	return nil
 }
 
-func (r *TransactionRepository) GetByTransactionID(transactionID string) (*transaction.Transaction, error) {
-	var t transaction.Transaction
-	result := r.db.Where("transaction_id = ?", transactionID).First(&t)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, sql.ErrNoRows
+func (r *TransactionRepository) GetByTransactionID(sourceType, transactionID string) (*transaction.Transaction, error) {
+	if sourceType != "" {
+		var t transaction.Transaction
+		result := r.db.Where("source_type = ? AND transaction_id = ?", sourceType, transactionID).First(&t)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return nil, sql.ErrNoRows
+			}
+			return nil, fmt.Errorf("failed to get transaction by ID %s/%s: %w", sourceType, transactionID, result.Error)
 		}
+		return &t, nil
+	}
+
+	var matches []transaction.Transaction
+	result := r.db.Where("transaction_id = ?", transactionID).Limit(2).Find(&matches)
+	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get transaction by ID %s: %w", transactionID, result.Error)
 	}
-	return &t, nil
+	switch len(matches) {
+	case 0:
+		return nil, sql.ErrNoRows
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, appErrors.NewConflictError(fmt.Sprintf("transaction ID %s is used by several sources, specify the Source-Type", transactionID))
+	}
+}
+
+// ListByUser returns the user's transactions newest first, applying the given filters and
+// keyset pagination over (processed_at, id).
+func (r *TransactionRepository) ListByUser(userID uint64, filter transaction.ListFilter) ([]transaction.Transaction, error) {
+	query := r.db.Where("user_id = ?", userID)
+
+	if filter.State != "" {
+		query = query.Where("state = ?", filter.State)
+	}
+	if filter.SourceType != "" {
+		query = query.Where("source_type = ?", filter.SourceType)
+	}
+	if len(filter.SourceTypes) > 0 {
+		query = query.Where("source_type IN ?", filter.SourceTypes)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where("amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where("amount <= ?", *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		query = query.Where("processed_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("processed_at < ?", *filter.To)
+	}
+	if filter.After != nil {
+		query = query.Where("(processed_at, id) < (?, ?)", filter.After.ProcessedAt, filter.After.ID)
+	}
+
+	var transactions []transaction.Transaction
+	result := query.Order("processed_at DESC, id DESC").Limit(filter.Limit).Find(&transactions)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list transactions for user %d: %w", userID, result.Error)
+	}
+	return transactions, nil
+}
+
+// ListByUserInApplyOrder relies on IDs being assigned while the user's balance is locked, so that
+// ID order is the order in which the transactions changed the balance.
+func (r *TransactionRepository) ListByUserInApplyOrder(userID uint64, afterID uint64, limit int) ([]transaction.Transaction, error) {
+	var transactions []transaction.Transaction
+	result := r.db.Where("user_id = ? AND id > ?", userID, afterID).Order("id").Limit(limit).Find(&transactions)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list transactions for user %d: %w", userID, result.Error)
+	}
+	return transactions, nil
+}
+
+func (r *TransactionRepository) ListByRound(sourceType, roundID string) ([]transaction.Transaction, error) {
+	var transactions []transaction.Transaction
+	result := r.db.Where("source_type = ? AND round_id = ?", sourceType, roundID).Order("id").Find(&transactions)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list transactions of round %s/%s: %w", sourceType, roundID, result.Error)
+	}
+	return transactions, nil
 }
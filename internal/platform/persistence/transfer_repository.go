@@ -0,0 +1,65 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/zaynkorai/enlabs/internal/domain/transfer"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// transferIdempotencyKey is the unique index on the external IDs of transfers.
+const transferIdempotencyKey = "idx_transfers_transfer_id"
+
+type TransferRepository struct {
+	db *gorm.DB
+}
+
+func NewTransferRepository(db *gorm.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+// Execute locks both wallets in the order ApplyBatch uses, so that transfers in opposite directions
+// between the same users, and batches touching them, cannot deadlock.
+func (r *TransferRepository) Execute(t *transfer.Transfer, debit, credit user.BalanceUpdate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(t).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == transferIdempotencyKey {
+				return appErrors.NewAlreadyProcessedError("transfer with this ID has already been processed")
+			}
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return appErrors.NewNotFoundError("user of the transfer not found")
+			}
+			return fmt.Errorf("failed to create transfer: %w", err)
+		}
+
+		legs := []user.BatchUpdate{{UserID: t.FromUserID, Update: debit}, {UserID: t.ToUserID, Update: credit}}
+		if err := lockBatchWallets(tx, legs); err != nil {
+			return err
+		}
+		for _, leg := range legs {
+			leg.Update.Transaction.TransferID = &t.ID
+			if _, err := applyBalanceUpdate(tx, leg.UserID, leg.Update); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *TransferRepository) GetByTransferID(transferID string) (*transfer.Transfer, error) {
+	var t transfer.Transfer
+	result := r.db.Where("transfer_id = ?", transferID).First(&t)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get transfer by ID %s: %w", transferID, result.Error)
+	}
+	return &t, nil
+}
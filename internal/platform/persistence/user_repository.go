@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/shopspring/decimal"
@@ -11,6 +12,18 @@ import (
 	"github.com/zaynkorai/enlabs/internal/domain/user"
 	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// transactionIdempotencyKey is the unique index that scopes external transaction IDs per source.
+	transactionIdempotencyKey = "idx_transactions_source_transaction_id"
+	// transactionReversalKey is the unique index allowing at most one reversal per transaction.
+	transactionReversalKey = "idx_transactions_reverses_id"
+	// userExternalRefKey is the unique index on the optional external reference of users.
+	userExternalRefKey = "idx_users_external_ref"
+	// userExternalIDKey is the unique index mapping a provider's player ID to at most one user.
+	userExternalIDKey = "idx_user_external_ids_provider_external_id"
 )
 
 type UserRepository struct {
@@ -33,38 +46,324 @@ func (r *UserRepository) GetByID(id uint64) (*user.User, error) {
 	return &u, nil
 }
 
+func (r *UserRepository) List(filter user.ListFilter) ([]user.User, error) {
+	query := r.db.Where("id > ?", filter.AfterID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	var users []user.User
+	if err := query.Order("id").Limit(filter.Limit).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, nil
+}
+
+func (r *UserRepository) GetWallet(userID uint64, currency string) (*user.Wallet, error) {
+	var w user.Wallet
+	result := r.db.Where("user_id = ? AND currency = ?", userID, currency).First(&w)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get %s wallet of user %d: %w", currency, userID, result.Error)
+	}
+	return &w, nil
+}
+
+func (r *UserRepository) ListWallets(userID uint64) ([]user.Wallet, error) {
+	var wallets []user.Wallet
+	if err := r.db.Where("user_id = ?", userID).Order("currency").Find(&wallets).Error; err != nil {
+		return nil, fmt.Errorf("failed to list wallets of user %d: %w", userID, err)
+	}
+	return wallets, nil
+}
+
 // AtomicUpdateBalanceAndCreateTransaction performs both operations in a single database transaction
 // to ensure atomicity and consistency.
+// The wallet row is locked with SELECT ... FOR UPDATE and the signed delta is applied to the
+// locked balance, so concurrent requests for the same wallet are serialized instead of
+// overwriting each other. Unless the update explicitly allows it, the balance may not drop below
+// the amount reserved by holds minus the wallet's credit limit. The loss or deposit limits of the
+// update are checked against the locked wallet too.
+// The update's journal entry is written in the same database transaction, keeping the ledger
+// in step with the wallet balance.
 // It gracefully handles duplicate transaction IDs by returning a specific error
 // that can be interpreted as "already processed".
-func (r *UserRepository) AtomicUpdateBalanceAndCreateTransaction(userID uint64, newBalance decimal.Decimal, newTransaction *transaction.Transaction) error {
+func (r *UserRepository) AtomicUpdateBalanceAndCreateTransaction(userID uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+	var newBalance decimal.Decimal
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		newBalance, err = applyBalanceUpdate(tx, userID, update)
+		return err
+	})
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return newBalance, nil
+}
+
+// applyBalanceUpdate is the body of AtomicUpdateBalanceAndCreateTransaction. It runs inside tx so
+// that callers can change other rows, such as a hold being captured, in the same database transaction.
+func applyBalanceUpdate(tx *gorm.DB, userID uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+	newTransaction := update.Transaction
+
+	locked, err := lockWallet(tx, userID, update.Currency)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if openErr := openWallet(tx, userID, update.Currency); openErr != nil {
+			return decimal.Decimal{}, openErr
+		}
+		locked, err = lockWallet(tx, userID, update.Currency)
+	}
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("failed to lock %s wallet of user %d: %w", update.Currency, userID, err)
+	}
+
+	newBalance := locked.Balance.Add(update.Delta)
+	bonusDelta := locked.BonusDelta(update.Delta, update.Allocation)
+	if err := checkBalance(locked, update, bonusDelta); err != nil {
+		return decimal.Decimal{}, err
+	}
+	if err := checkLimits(tx, locked, update.Limits, update.Transaction.Amount); err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	newTransaction.UserID = userID
+	newTransaction.BonusAmount = bonusDelta.Abs()
+	newTransaction.BalanceBefore = decimal.NewNullDecimal(locked.Balance)
+	newTransaction.BalanceAfter = decimal.NewNullDecimal(newBalance)
+	if createErr := createTransaction(tx, newTransaction); createErr != nil {
+		return decimal.Decimal{}, createErr
+	}
+	if entryErr := createJournalEntry(tx, update.Entry, newTransaction.ID); entryErr != nil {
+		return decimal.Decimal{}, entryErr
+	}
+	if roundErr := bookRound(tx, newTransaction, update.StrictRounds); roundErr != nil {
+		return decimal.Decimal{}, roundErr
+	}
+
+	result := tx.Model(&user.Wallet{}).
+		Where("user_id = ? AND currency = ?", userID, update.Currency).
+		Updates(map[string]interface{}{
+			"balance":       gorm.Expr("balance + ?", update.Delta),
+			"bonus_balance": gorm.Expr("bonus_balance + ?", bonusDelta),
+			"held_balance":  gorm.Expr("held_balance + ?", update.HeldDelta),
+			"version":       gorm.Expr("version + 1"),
+			"updated_at":    gorm.Expr("NOW()"),
+		})
+
+	if result.Error != nil {
+		return decimal.Decimal{}, fmt.Errorf("failed to update wallet balance: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return decimal.Decimal{}, fmt.Errorf("%s wallet of user %d not found or not updated after transaction creation", update.Currency, userID)
+	}
+	return newBalance, nil
+}
+
+// ApplyBatch locks every wallet the batch touches, ordered by user and currency, before applying the
+// first update, so that concurrent batches sharing wallets cannot deadlock.
+func (r *UserRepository) ApplyBatch(updates []user.BatchUpdate) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBatchWallets(tx, updates); err != nil {
+			return err
+		}
+		for i, u := range updates {
+			if _, err := applyBalanceUpdate(tx, u.UserID, u.Update); err != nil {
+				return &user.BatchError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
 
-		newTransaction.UserID = userID
-		if createErr := tx.Create(newTransaction).Error; createErr != nil {
-			var pgErr *pgconn.PgError
-			// Check if the error is a PostgreSQL unique constraint violation (code 23505)
-			if errors.As(createErr, &pgErr) && pgErr.Code == "23505" {
+// lockBatchWallets locks the existing wallets the updates touch in (user ID, currency) order. Wallets
+// that are not open yet are opened, and locked, by the update creating them.
+func lockBatchWallets(tx *gorm.DB, updates []user.BatchUpdate) error {
+	type walletKey struct {
+		userID   uint64
+		currency string
+	}
+	seen := make(map[walletKey]bool, len(updates))
+	keys := make([]walletKey, 0, len(updates))
+	for _, u := range updates {
+		key := walletKey{u.UserID, u.Update.Currency}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].userID != keys[j].userID {
+			return keys[i].userID < keys[j].userID
+		}
+		return keys[i].currency < keys[j].currency
+	})
+
+	for _, key := range keys {
+		_, err := lockWallet(tx, key.userID, key.currency)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to lock %s wallet of user %d: %w", key.currency, key.userID, err)
+		}
+	}
+	return nil
+}
+
+// UpdateBalanceIfVersionMatches is the optimistic counterpart of AtomicUpdateBalanceAndCreateTransaction.
+// No row lock is taken; instead the balance update is conditional on the version the caller read,
+// and the whole database transaction is rolled back with a version conflict error if another
+// request changed the wallet in the meantime. The balance, credit limit and sums are checked again
+// on the wallet at that version, so that the update never commits against a state the caller did
+// not check, and the split between the sub-balances is computed from it.
+func (r *UserRepository) UpdateBalanceIfVersionMatches(userID uint64, expectedVersion uint64, update user.BalanceUpdate) (decimal.Decimal, error) {
+	newTransaction := update.Transaction
+	var updated user.Wallet
+	err := r.db.Transaction(func(tx *gorm.DB) error {
 
-				return appErrors.NewAlreadyProcessedError("transaction with this ID has already been processed")
+		if expectedVersion == 0 {
+			// The caller may have seen no wallet at all; make sure there is one at version 0 to update.
+			if openErr := openWallet(tx, userID, update.Currency); openErr != nil {
+				return openErr
 			}
+		}
 
-			return fmt.Errorf("failed to create transaction record: %w", createErr)
+		conflict := appErrors.NewVersionConflictError(fmt.Sprintf("%s wallet of user %d was modified concurrently (expected version %d)", update.Currency, userID, expectedVersion))
+		var current user.Wallet
+		err := tx.Where("user_id = ? AND currency = ? AND version = ?", userID, update.Currency, expectedVersion).First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return conflict
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get %s wallet of user %d: %w", update.Currency, userID, err)
+		}
+		// The version pins the sums too: any transaction booked since the caller read the wallet changed it.
+		bonusDelta := current.BonusDelta(update.Delta, update.Allocation)
+		if err := checkBalance(&current, update, bonusDelta); err != nil {
+			return err
+		}
+		if err := checkLimits(tx, &current, update.Limits, update.Transaction.Amount); err != nil {
+			return err
 		}
 
-		result := tx.Model(&user.User{}).
-			Where("id = ?", userID).
-			Updates(map[string]interface{}{"balance": newBalance, "updated_at": gorm.Expr("NOW()")})
+		result := tx.Model(&updated).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "balance"}}}).
+			Where("user_id = ? AND currency = ? AND version = ?", userID, update.Currency, expectedVersion).
+			Updates(map[string]interface{}{
+				"balance":       gorm.Expr("balance + ?", update.Delta),
+				"bonus_balance": gorm.Expr("bonus_balance + ?", bonusDelta),
+				"held_balance":  gorm.Expr("held_balance + ?", update.HeldDelta),
+				"version":       gorm.Expr("version + 1"),
+				"updated_at":    gorm.Expr("NOW()"),
+			})
 
 		if result.Error != nil {
-			return fmt.Errorf("failed to update user balance: %w", result.Error)
+			return fmt.Errorf("failed to update wallet balance: %w", result.Error)
 		}
 		if result.RowsAffected == 0 {
-			return fmt.Errorf("user with ID %d not found or not updated after transaction creation", userID)
+			return conflict
+		}
+
+		newTransaction.UserID = userID
+		newTransaction.BonusAmount = bonusDelta.Abs()
+		newTransaction.BalanceBefore = decimal.NewNullDecimal(updated.Balance.Sub(update.Delta))
+		newTransaction.BalanceAfter = decimal.NewNullDecimal(updated.Balance)
+		if createErr := createTransaction(tx, newTransaction); createErr != nil {
+			return createErr
+		}
+		if entryErr := createJournalEntry(tx, update.Entry, newTransaction.ID); entryErr != nil {
+			return entryErr
+		}
+		if roundErr := bookRound(tx, newTransaction, update.StrictRounds); roundErr != nil {
+			return roundErr
 		}
 
 		return nil
 	})
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return updated.Balance, nil
+}
+
+// SetCreditLimit locks the wallet so that the new limit does not interleave with a balance update.
+func (r *UserRepository) SetCreditLimit(userID uint64, code string, limit decimal.Decimal) (*user.Wallet, error) {
+	var updated *user.Wallet
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := openWallet(tx, userID, code); err != nil {
+			return err
+		}
+		locked, err := lockWallet(tx, userID, code)
+		if err != nil {
+			return fmt.Errorf("failed to lock %s wallet of user %d: %w", code, userID, err)
+		}
+		result := tx.Model(&user.Wallet{}).
+			Where("user_id = ? AND currency = ?", userID, code).
+			Updates(map[string]interface{}{
+				"credit_limit": limit,
+				"version":      gorm.Expr("version + 1"),
+				"updated_at":   gorm.Expr("NOW()"),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to set credit limit of %s wallet of user %d: %w", code, userID, result.Error)
+		}
+		locked.CreditLimit = limit
+		locked.Version++
+		updated = locked
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// ChangeStatus locks the user row so that concurrent status changes are applied one at a time. It
+// bumps the version of the user's wallets, so that optimistic balance updates prepared under the
+// old status conflict and are checked against the new one when retried.
+func (r *UserRepository) ChangeStatus(change *user.StatusChange) (*user.User, error) {
+	var updated user.User
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&updated, change.UserID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.NewNotFoundError(fmt.Sprintf("user with ID %d not found", change.UserID))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock user %d: %w", change.UserID, err)
+		}
+		if updated.Status != change.FromStatus {
+			return appErrors.NewConflictError(fmt.Sprintf("account status of user %d was changed concurrently", change.UserID))
+		}
+
+		updated.Status = change.ToStatus
+		updated.SelfExcludedUntil = change.SelfExcludedUntil
+		result := tx.Model(&updated).Updates(map[string]interface{}{
+			"status":              updated.Status,
+			"self_excluded_until": updated.SelfExcludedUntil,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update status of user %d: %w", change.UserID, result.Error)
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return fmt.Errorf("failed to record status change of user %d: %w", change.UserID, err)
+		}
+		err = tx.Model(&user.Wallet{}).Where("user_id = ?", change.UserID).
+			Update("version", gorm.Expr("version + 1")).Error
+		if err != nil {
+			return fmt.Errorf("failed to bump wallet versions of user %d: %w", change.UserID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (r *UserRepository) ListStatusChanges(userID uint64) ([]user.StatusChange, error) {
+	var changes []user.StatusChange
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list status changes of user %d: %w", userID, err)
+	}
+	return changes, nil
 }
 
 func (r *UserRepository) Create(user *user.User) error {
@@ -73,3 +372,145 @@ func (r *UserRepository) Create(user *user.User) error {
 	}
 	return nil
 }
+
+func (r *UserRepository) CreateWithWallet(u *user.User, code string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == userExternalRefKey {
+				return appErrors.NewConflictError(fmt.Sprintf("a user with external reference %q already exists", *u.ExternalRef))
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return openWallet(tx, u.ID, code)
+	})
+}
+
+func (r *UserRepository) GetByExternalID(provider, externalID string) (*user.ExternalID, error) {
+	var link user.ExternalID
+	err := r.db.Where("provider = ? AND external_id = ?", provider, externalID).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, sql.ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get external ID %s/%s: %w", provider, externalID, err)
+	}
+	return &link, nil
+}
+
+func (r *UserRepository) ListExternalIDs(userID uint64) ([]user.ExternalID, error) {
+	var links []user.ExternalID
+	if err := r.db.Where("user_id = ?", userID).Order("provider, external_id").Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to list external IDs of user %d: %w", userID, err)
+	}
+	return links, nil
+}
+
+func (r *UserRepository) LinkExternalID(link *user.ExternalID) error {
+	err := r.db.Create(link).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			if pgErr.ConstraintName == userExternalIDKey {
+				return appErrors.NewConflictError(fmt.Sprintf("external ID %s/%s is already mapped to a user", link.Provider, link.ExternalID))
+			}
+		case "23503":
+			return appErrors.NewNotFoundError(fmt.Sprintf("user with ID %d not found", link.UserID))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to map external ID %s/%s: %w", link.Provider, link.ExternalID, err)
+	}
+	return nil
+}
+
+func (r *UserRepository) ProvisionExternalUser(link *user.ExternalID, code string) (*user.User, error) {
+	u := &user.User{Status: user.StatusActive}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		if err := openWallet(tx, u.ID, code); err != nil {
+			return err
+		}
+		link.UserID = u.ID
+		if err := tx.Create(link).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == userExternalIDKey {
+				return appErrors.NewAlreadyProcessedError(fmt.Sprintf("external ID %s/%s has already been mapped", link.Provider, link.ExternalID))
+			}
+			return fmt.Errorf("failed to map external ID %s/%s: %w", link.Provider, link.ExternalID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		link.UserID = 0
+		return nil, err
+	}
+	return u, nil
+}
+
+// createTransaction inserts the transaction record inside tx, translating PostgreSQL unique
+// violations (code 23505) on the idempotency and reversal keys into application errors.
+func createTransaction(tx *gorm.DB, newTransaction *transaction.Transaction) error {
+	err := tx.Create(newTransaction).Error
+	if err == nil {
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		switch pgErr.ConstraintName {
+		case transactionIdempotencyKey:
+			return appErrors.NewAlreadyProcessedError("transaction with this ID has already been processed")
+		case transactionReversalKey:
+			return appErrors.NewConflictError("transaction has already been reversed")
+		}
+	}
+
+	return fmt.Errorf("failed to create transaction record: %w", err)
+}
+
+// checkBalance rejects an update that may not drive the balance negative if it would take the
+// available balance below the credit limit, or a sub-balance below zero.
+func checkBalance(w *user.Wallet, update user.BalanceUpdate, bonusDelta decimal.Decimal) error {
+	if update.AllowNegative {
+		return nil
+	}
+	if w.Balance.Add(update.Delta).Sub(w.HeldBalance.Add(update.HeldDelta)).Add(w.CreditLimit).IsNegative() {
+		return w.InsufficientBalanceError()
+	}
+	if bucket := w.Overdrawn(update.Delta, bonusDelta); bucket != "" {
+		return w.InsufficientBucketError(bucket)
+	}
+	return nil
+}
+
+func lockWallet(tx *gorm.DB, userID uint64, currency string) (*user.Wallet, error) {
+	var w user.Wallet
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND currency = ?", userID, currency).
+		First(&w).Error
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// openWallet creates an empty wallet in the currency unless the user already has one.
+// It fails with a not found error if the user does not exist.
+func openWallet(tx *gorm.DB, userID uint64, currency string) error {
+	if err := tx.First(&user.User{}, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.NewNotFoundError(fmt.Sprintf("user with ID %d not found", userID))
+		}
+		return fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+
+	wallet := user.Wallet{UserID: userID, Currency: currency}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&wallet).Error; err != nil {
+		return fmt.Errorf("failed to open %s wallet for user %d: %w", currency, userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package rates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+)
+
+// inversePrecision is the number of decimal places kept when a rate is derived from its inverse pair.
+const inversePrecision = 12
+
+// StaticProvider serves a fixed set of exchange rates held in memory, for example loaded from a file.
+// A pair that is only listed in the opposite direction is served as the inverse of that rate.
+type StaticProvider struct {
+	asOf  time.Time
+	rates map[string]decimal.Decimal
+}
+
+// NewStaticProvider returns a provider for rates keyed by "FROM/TO" (e.g. "USD/EUR"), all published at asOf.
+func NewStaticProvider(asOf time.Time, rates map[string]decimal.Decimal) (*StaticProvider, error) {
+	p := &StaticProvider{asOf: asOf, rates: make(map[string]decimal.Decimal, len(rates))}
+	for pair, value := range rates {
+		from, to, ok := strings.Cut(strings.ToUpper(pair), "/")
+		if !ok || !currency.Valid(from) || !currency.Valid(to) {
+			return nil, fmt.Errorf("invalid currency pair %q: must be FROM/TO with ISO 4217 codes", pair)
+		}
+		if !value.IsPositive() {
+			return nil, fmt.Errorf("invalid rate %s for %s: must be positive", value.String(), pair)
+		}
+		p.rates[from+"/"+to] = value
+	}
+	return p, nil
+}
+
+// rateFile is the JSON format read by LoadStaticProvider:
+//
+//	{"asOf": "2025-01-01T00:00:00Z", "rates": {"USD/EUR": "0.92", "GBP/EUR": "1.17"}}
+type rateFile struct {
+	AsOf  time.Time                  `json:"asOf"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+// LoadStaticProvider reads rates from a JSON file.
+func LoadStaticProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates file: %w", err)
+	}
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates file %s: %w", path, err)
+	}
+	if file.AsOf.IsZero() {
+		return nil, fmt.Errorf("exchange rates file %s has no asOf timestamp", path)
+	}
+	return NewStaticProvider(file.AsOf, file.Rates)
+}
+
+func (p *StaticProvider) Rate(from, to string) (currency.Rate, error) {
+	if value, ok := p.rates[from+"/"+to]; ok {
+		return currency.Rate{From: from, To: to, Value: value, AsOf: p.asOf}, nil
+	}
+	if inverse, ok := p.rates[to+"/"+from]; ok {
+		value := decimal.NewFromInt(1).DivRound(inverse, inversePrecision)
+		return currency.Rate{From: from, To: to, Value: value, AsOf: p.asOf}, nil
+	}
+	return currency.Rate{}, currency.ErrRateNotFound
+}
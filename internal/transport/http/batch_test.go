@@ -0,0 +1,116 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func postBatch(t *testing.T, body apihandler.BatchTransactionRequest) (*httptest.ResponseRecorder, apihandler.BatchTransactionResponse) {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/transactions/batch", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Source-Type", "game")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response apihandler.BatchTransactionResponse
+	if w.Code == http.StatusOK || w.Code == http.StatusUnprocessableEntity {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	}
+	return w, response
+}
+
+func batchTransactionItem(userID uint64, state, amount, transactionID string) apihandler.BatchTransactionItem {
+	return apihandler.BatchTransactionItem{
+		UserID:             userID,
+		TransactionRequest: apihandler.TransactionRequest{State: state, Amount: amount, TransactionID: transactionID},
+	}
+}
+
+func batchStatuses(response apihandler.BatchTransactionResponse) []string {
+	statuses := make([]string, 0, len(response.Results))
+	for _, result := range response.Results {
+		statuses = append(statuses, result.Status)
+	}
+	return statuses
+}
+
+func TestProcessTransactionBatch_BestEffort(t *testing.T) {
+	setupTest(t)
+	setWalletBalance(t, testUsers[0], decimal.NewFromInt(10))
+
+	items := []apihandler.BatchTransactionItem{
+		batchTransactionItem(testUsers[0], "win", "5.00", "batch-1"),
+		batchTransactionItem(testUsers[0], "lose", "100.00", "batch-2"),
+		batchTransactionItem(999999, "win", "1.00", "batch-3"),
+		batchTransactionItem(testUsers[1], "win", "2.50", "batch-4"),
+	}
+	w, response := postBatch(t, apihandler.BatchTransactionRequest{Items: items})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "best_effort", response.Mode)
+	assert.Equal(t, []string{"processed", "insufficient_balance", "not_found", "processed"}, batchStatuses(response))
+	assert.Equal(t, 2, response.Succeeded)
+	assert.Equal(t, 2, response.Failed)
+	assert.True(t, decimal.NewFromInt(15).Equal(walletBalance(t, testUsers[0])))
+
+	// Sending the batch again replays the processed items.
+	w, response = postBatch(t, apihandler.BatchTransactionRequest{Items: items})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"replayed", "insufficient_balance", "not_found", "replayed"}, batchStatuses(response))
+	assert.True(t, decimal.NewFromInt(15).Equal(walletBalance(t, testUsers[0])))
+	assertLedgerConsistent(t, testUsers[0])
+}
+
+func TestProcessTransactionBatch_Atomic(t *testing.T) {
+	setupTest(t)
+	setWalletBalance(t, testUsers[0], decimal.NewFromInt(10))
+
+	w, response := postBatch(t, apihandler.BatchTransactionRequest{Mode: "atomic", Items: []apihandler.BatchTransactionItem{
+		batchTransactionItem(testUsers[0], "lose", "4.00", "atomic-1"),
+		batchTransactionItem(testUsers[1], "win", "3.00", "atomic-2"),
+		batchTransactionItem(testUsers[0], "lose", "7.00", "atomic-3"),
+	}})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, []string{"aborted", "aborted", "insufficient_balance"}, batchStatuses(response))
+	assert.True(t, decimal.NewFromInt(10).Equal(walletBalance(t, testUsers[0])), "nothing is applied")
+	assert.True(t, walletBalance(t, testUsers[1]).IsZero())
+
+	// A win earlier in the batch covers the later loss.
+	w, response = postBatch(t, apihandler.BatchTransactionRequest{Mode: "atomic", Items: []apihandler.BatchTransactionItem{
+		batchTransactionItem(testUsers[0], "lose", "4.00", "atomic-1"),
+		batchTransactionItem(testUsers[0], "win", "3.00", "atomic-4"),
+		batchTransactionItem(testUsers[0], "lose", "9.00", "atomic-3"),
+		batchTransactionItem(testUsers[1], "win", "3.00", "atomic-2"),
+	}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"processed", "processed", "processed", "processed"}, batchStatuses(response))
+	assert.True(t, walletBalance(t, testUsers[0]).IsZero())
+	assert.True(t, decimal.NewFromInt(3).Equal(walletBalance(t, testUsers[1])))
+	if assert.NotNil(t, response.Results[2].Transaction) {
+		assert.Equal(t, "9.00", response.Results[2].Transaction.BalanceBefore)
+		assert.Equal(t, "0.00", response.Results[2].Transaction.BalanceAfter)
+	}
+	assertLedgerConsistent(t, testUsers[0])
+	assertLedgerConsistent(t, testUsers[1])
+}
+
+func TestProcessTransactionBatch_InvalidRequest(t *testing.T) {
+	setupTest(t)
+
+	w, _ := postBatch(t, apihandler.BatchTransactionRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = postBatch(t, apihandler.BatchTransactionRequest{Mode: "all", Items: []apihandler.BatchTransactionItem{
+		batchTransactionItem(testUsers[0], "win", "1.00", "invalid-1"),
+	}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = postBatch(t, apihandler.BatchTransactionRequest{Items: []apihandler.BatchTransactionItem{
+		batchTransactionItem(testUsers[0], "win", "1.00001", "invalid-2"),
+	}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,218 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
+)
+
+const concurrentRequests = 50
+
+func postTransaction(userID uint64, sourceType string, body apihandler.TransactionRequest) *httptest.ResponseRecorder {
+	return postTransactionTo(router, userID, sourceType, body)
+}
+
+func postTransactionTo(r *gin.Engine, userID uint64, sourceType string, body apihandler.TransactionRequest) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest(
+		http.MethodPost,
+		fmt.Sprintf("/user/%d/transaction", userID),
+		bytes.NewBuffer(jsonBody),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Source-Type", sourceType)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestProcessTransaction_Concurrent_WinsProduceExactSum(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	var wg sync.WaitGroup
+	codes := make([]int, concurrentRequests)
+	for i := 0; i < concurrentRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := postTransaction(userID, "game", apihandler.TransactionRequest{
+				State:         "win",
+				Amount:        "1.25",
+				TransactionID: fmt.Sprintf("concurrent-win-%d", i),
+			})
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		assert.Equal(t, http.StatusOK, code, "request %d failed", i)
+	}
+
+	userBalance := walletBalance(t, userID)
+	expected := decimal.RequireFromString("1.25").Mul(decimal.NewFromInt(concurrentRequests))
+	assert.True(t, userBalance.Equal(expected), "expected %s, got %s", expected, userBalance)
+	assertLedgerConsistent(t, userID)
+}
+
+func TestProcessTransaction_Concurrent_MixedWinLoseProduceExactSum(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[1]
+	initialBalance := decimal.NewFromInt(1000)
+	setWalletBalance(t, userID, initialBalance)
+
+	var wg sync.WaitGroup
+	codes := make([]int, concurrentRequests)
+	for i := 0; i < concurrentRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			state, amount := "win", "3.00"
+			if i%2 == 1 {
+				state, amount = "lose", "5.00"
+			}
+			w := postTransaction(userID, "server", apihandler.TransactionRequest{
+				State:         state,
+				Amount:        amount,
+				TransactionID: fmt.Sprintf("concurrent-mixed-%d", i),
+			})
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		assert.Equal(t, http.StatusOK, code, "request %d failed", i)
+	}
+
+	wins := decimal.NewFromInt(3).Mul(decimal.NewFromInt(concurrentRequests / 2))
+	losses := decimal.NewFromInt(5).Mul(decimal.NewFromInt(concurrentRequests / 2))
+	expected := initialBalance.Add(wins).Sub(losses)
+
+	userBalance := walletBalance(t, userID)
+	assert.True(t, userBalance.Equal(expected), "expected %s, got %s", expected, userBalance)
+}
+
+func TestProcessTransaction_Concurrent_LosesNeverOverdraw(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[2]
+	initialBalance := decimal.NewFromInt(10)
+	setWalletBalance(t, userID, initialBalance)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded, rejected := 0, 0
+	for i := 0; i < concurrentRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := postTransaction(userID, "payment", apihandler.TransactionRequest{
+				State:         "lose",
+				Amount:        "1.00",
+				TransactionID: fmt.Sprintf("concurrent-lose-%d", i),
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch w.Code {
+			case http.StatusOK:
+				succeeded++
+			case http.StatusBadRequest:
+				rejected++
+			default:
+				t.Errorf("unexpected status %d for request %d", w.Code, i)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, succeeded)
+	assert.Equal(t, concurrentRequests-10, rejected)
+
+	userBalance := walletBalance(t, userID)
+	assert.True(t, userBalance.Equal(decimal.Zero), "expected 0, got %s", userBalance)
+
+	var count int64
+	err := testDB.Table("transactions").Where("user_id = ?", userID).Count(&count).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), count)
+}
+
+func TestProcessTransaction_Concurrent_OptimisticLockingProducesExactSum(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	// Retries are generous so that every request eventually wins the race under heavy contention.
+	optimisticService := services.NewTransactionService(userRepo, txnRepo,
+		services.WithLockingStrategy(services.LockingOptimistic, concurrentRequests))
+	optimisticRouter := newTestRouter(apihandler.NewHandler(optimisticService, services.NewLedgerService(ledgerRepo)), false)
+
+	var wg sync.WaitGroup
+	codes := make([]int, concurrentRequests)
+	for i := 0; i < concurrentRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := postTransactionTo(optimisticRouter, userID, "game", apihandler.TransactionRequest{
+				State:         "win",
+				Amount:        "2.00",
+				TransactionID: fmt.Sprintf("concurrent-optimistic-%d", i),
+			})
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		assert.Equal(t, http.StatusOK, code, "request %d failed", i)
+	}
+
+	wallet, err := userRepo.GetWallet(userID, services.DefaultCurrency)
+	assert.NoError(t, err)
+	expected := decimal.NewFromInt(2).Mul(decimal.NewFromInt(concurrentRequests))
+	assert.True(t, wallet.Balance.Equal(expected), "expected %s, got %s", expected, wallet.Balance)
+	assert.Equal(t, uint64(concurrentRequests), wallet.Version)
+	assertLedgerConsistent(t, userID)
+}
+
+func TestUpdateBalanceIfVersionMatches_ChecksTheWalletAtThatVersion(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	setWalletBalance(t, userID, decimal.NewFromInt(10))
+	wallet, err := userRepo.GetWallet(userID, services.DefaultCurrency)
+	assert.NoError(t, err)
+
+	// The caller skipped or got its own balance check wrong; the repository still refuses the overdraft.
+	_, err = userRepo.UpdateBalanceIfVersionMatches(userID, wallet.Version, user.BalanceUpdate{
+		Currency:    services.DefaultCurrency,
+		Delta:       decimal.NewFromInt(-20),
+		Transaction: &transaction.Transaction{TransactionID: "optimistic-overdraft", SourceType: "game", State: "lose", Currency: services.DefaultCurrency, Amount: decimal.NewFromInt(20)},
+	})
+	assert.True(t, appErrors.IsInsufficientBalanceError(err), "got %v", err)
+	assert.True(t, walletBalance(t, userID).Equal(decimal.NewFromInt(10)))
+
+	// Credit limit and status changes invalidate updates prepared before them.
+	updated, err := userRepo.SetCreditLimit(userID, services.DefaultCurrency, decimal.NewFromInt(15))
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Version+1, updated.Version)
+
+	_, err = userRepo.ChangeStatus(&user.StatusChange{UserID: userID, FromStatus: user.StatusActive, ToStatus: user.StatusFrozen, Reason: "chargeback under review"})
+	assert.NoError(t, err)
+	wallet, err = userRepo.GetWallet(userID, services.DefaultCurrency)
+	assert.NoError(t, err)
+	assert.Equal(t, updated.Version+1, wallet.Version)
+}
@@ -0,0 +1,112 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func linkExternalID(userID uint64, body apihandler.LinkExternalIDRequest) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := newAdminRequest(http.MethodPost, fmt.Sprintf("/users/%d/external-ids", userID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func postPlayerTransaction(provider, externalID, sourceType string, body apihandler.TransactionRequest) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/provider/%s/player/%s/transaction", provider, externalID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Source-Type", sourceType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func getPlayerBalance(t *testing.T, provider, externalID string) apihandler.BalanceResponse {
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/provider/%s/player/%s/balance", provider, externalID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response apihandler.BalanceResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func TestExternalID_LinkedPlayer(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	w := linkExternalID(userID, apihandler.LinkExternalIDRequest{Provider: "acme", ExternalID: "p-100"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = linkExternalID(userID, apihandler.LinkExternalIDRequest{Provider: "acme", ExternalID: "p-100"})
+	assert.Equal(t, http.StatusOK, w.Code, "repeating a mapping is idempotent")
+	w = linkExternalID(testUsers[1], apihandler.LinkExternalIDRequest{Provider: "acme", ExternalID: "p-100"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = linkExternalID(userID, apihandler.LinkExternalIDRequest{Provider: "Acme Games", ExternalID: "p-100"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postPlayerTransaction("acme", "p-100", "game", apihandler.TransactionRequest{State: "win", Amount: "12.50", TransactionID: "ext-win-1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var txn apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &txn))
+	assert.Equal(t, userID, txn.UserID)
+
+	// Both forms address the same wallets and transactions.
+	balance := getPlayerBalance(t, "acme", "p-100")
+	assert.Equal(t, userID, balance.UserID)
+	if assert.Len(t, balance.Wallets, 1) {
+		assert.Equal(t, "12.50", balance.Wallets[0].Balance)
+	}
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/transactions/ext-win-1", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	req = httptest.NewRequest(http.MethodGet, "/provider/acme/player/p-100/transactions", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var history apihandler.TransactionHistoryResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Len(t, history.Transactions, 1)
+
+	req = newAdminRequest(http.MethodGet, fmt.Sprintf("/users/%d/external-ids", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var links apihandler.ExternalIDsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &links))
+	if assert.Len(t, links.ExternalIDs, 1) {
+		assert.Equal(t, "acme", links.ExternalIDs[0].Provider)
+		assert.Equal(t, "p-100", links.ExternalIDs[0].ExternalID)
+	}
+}
+
+func TestExternalID_AutoProvisioning(t *testing.T) {
+	setupTest(t)
+
+	// The test service provisions unknown players on first sight.
+	first := getPlayerBalance(t, "acme", "new-player")
+	assert.Greater(t, first.UserID, testUsers[len(testUsers)-1])
+	if assert.Len(t, first.Wallets, 1) {
+		assert.Equal(t, "EUR", first.Wallets[0].Currency)
+		assert.Equal(t, "0.00", first.Wallets[0].Balance)
+	}
+	again := getPlayerBalance(t, "acme", "new-player")
+	assert.Equal(t, first.UserID, again.UserID)
+
+	// The same player ID of another provider is another player.
+	other := getPlayerBalance(t, "globex", "new-player")
+	assert.NotEqual(t, first.UserID, other.UserID)
+
+	w := postPlayerTransaction("acme", "new-player", "game", apihandler.TransactionRequest{State: "lose", Amount: "1.00", TransactionID: "ext-lose-1"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a provisioned user starts with an empty wallet")
+}
@@ -1,39 +1,115 @@
 package http
 
 import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/shopspring/decimal"
 	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/hold"
+	"github.com/zaynkorai/enlabs/internal/domain/ledger"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	"github.com/zaynkorai/enlabs/internal/domain/provider"
+	"github.com/zaynkorai/enlabs/internal/domain/source"
 	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/transfer"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
 	appErrors "github.com/zaynkorai/enlabs/pkg/errors"
 	"github.com/zaynkorai/enlabs/pkg/utils"
 )
 
 type Handler struct {
 	transactionService *services.TransactionService
+	ledgerService      *services.LedgerService
 	validator          *validator.Validate
 }
 
-func NewHandler(transactionService *services.TransactionService) *Handler {
+func NewHandler(transactionService *services.TransactionService, ledgerService *services.LedgerService) *Handler {
 	v := validator.New()
 
-	if err := v.RegisterValidation("decimal_2_places", validateDecimalTwoPlaces); err != nil {
+	if err := registerValidations(v); err != nil {
 		log.Fatalf("Failed to register custom validator: %v", err)
 	}
+	// Request bodies are validated by gin's binding engine, which needs the custom rules as well.
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		if err := registerValidations(engine); err != nil {
+			log.Fatalf("Failed to register custom binding validator: %v", err)
+		}
+	}
 
 	return &Handler{
 		transactionService: transactionService,
+		ledgerService:      ledgerService,
 		validator:          v,
 	}
 }
 
-func validateDecimalTwoPlaces(fl validator.FieldLevel) bool {
+func registerValidations(v *validator.Validate) error {
+	if err := v.RegisterValidation("decimal_amount", validateDecimalAmount); err != nil {
+		return err
+	}
+	return v.RegisterValidation("transaction_type", validateTransactionType)
+}
+
+// bindingErrorMessage turns a request binding error into a client-facing message, spelling out
+// amount format and state problems instead of exposing the raw validator output.
+func bindingErrorMessage(err error, amount string) string {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, fieldErr := range validationErrs {
+			if fieldErr.Field() == "Amount" && fieldErr.Tag() == "decimal_amount" {
+				if _, parseErr := utils.ParseDecimal(amount); parseErr != nil {
+					return "Invalid amount format. Must be a valid decimal string."
+				}
+				return amountPlacesMessage
+			}
+			if fieldErr.Field() == "State" && fieldErr.Tag() == "transaction_type" {
+				return stateMessage
+			}
+		}
+	}
+	return err.Error()
+}
+
+// stateMessage lists the transaction types requests may carry.
+var stateMessage = fmt.Sprintf("Invalid state. Must be one of %s.", quotedTypes(transaction.RequestTypes()))
+
+// validateTransactionType accepts the registered transaction types that may be sent in requests.
+func validateTransactionType(fl validator.FieldLevel) bool {
+	info, ok := transaction.LookupType(transaction.Type(fl.Field().String()))
+	return ok && !info.Internal
+}
+
+// quotedTypes renders types as a list of quoted names, e.g. 'win', 'lose', or 'cancel'.
+func quotedTypes(types []transaction.Type) string {
+	quoted := make([]string, len(types))
+	for i, t := range types {
+		quoted[i] = "'" + string(t) + "'"
+	}
+	if len(quoted) > 1 {
+		quoted[len(quoted)-1] = "or " + quoted[len(quoted)-1]
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// amountPlacesMessage is returned for amounts with more decimal places than any currency uses.
+// The precision of the transaction's currency is checked by the service.
+var amountPlacesMessage = fmt.Sprintf("Amount must be a valid number string with up to %d decimal places.", currency.MaxMinorUnits)
+
+// validateDecimalAmount accepts decimal strings with at most currency.MaxMinorUnits decimal places.
+func validateDecimalAmount(fl validator.FieldLevel) bool {
 	amountStr := fl.Field().String()
 	parts := strings.Split(amountStr, ".")
 
@@ -43,8 +119,8 @@ func validateDecimalTwoPlaces(fl validator.FieldLevel) bool {
 
 	if len(parts) == 2 { // Has a decimal part
 		decimalPart := parts[1]
-		if len(decimalPart) > 2 {
-			return false // More than 2 decimal places
+		if len(decimalPart) > currency.MaxMinorUnits {
+			return false // More decimal places than any currency has
 		}
 	}
 
@@ -54,40 +130,45 @@ func validateDecimalTwoPlaces(fl validator.FieldLevel) bool {
 
 // ProcessTransaction
 // @Summary Updates user balance based on a transaction
-// @Description Processes 'win' or 'lose' transactions and updates the user's balance, ensuring idempotency and non-negative balance.
+// @Description Processes a transaction and updates the balance of the user's wallet in the transaction currency, ensuring idempotency and a non-negative balance, or one within the wallet's credit limit.
+// @Description The state is the transaction type. 'win', 'lose' and 'cancel' are accepted from every Source-Type; 'deposit' and 'withdrawal' (real balance) only from payment; 'bonus_grant' (bonus balance) and 'adjustment' only from server; 'fee' and 'refund' from server and payment. 'lose', 'withdrawal' and 'fee' debit the balance, the others credit it.
+// @Description The wallet is opened on the first transaction in a currency; the amount may not have more decimal places than the currency uses.
+// @Description If walletCurrency differs from currency, the amount is converted into the wallet currency at the current exchange rate and the original amount, currency and rate are recorded.
+// @Description Unless bucket names the real or bonus balance, a 'lose' is paid from the sub-balances in the configured debit order and a 'win' is split in proportion to them.
+// @Description A 'cancel' transaction rolls back the transaction given in referenceTransactionId (same Source-Type); its amount must match the original.
+// @Description Idempotency is keyed by (Source-Type, transactionId), so different sources may reuse the same transactionId.
+// @Description A roundId links the stakes ('lose') and payouts ('win') of one game round, see GET /rounds/{roundId}; in strict mode a 'win' for an unknown round is rejected with 400 and stakes or payouts for a closed round with 409.
+// @Description The response carries the stored transaction and the balance after the operation; a request repeating an already processed transactionId with the same payload returns the original result with idempotentReplay set, while a differing payload is rejected with 409.
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param userId path int true "User ID"
-// @Param Source-Type header string true "Type of the transaction source (game, server, payment)" Enums(game, server, payment)
+// @Param provider path string true "Provider name"
+// @Param externalId path string true "The provider's player ID"
+// @Param Source-Type header string true "Registered source of the transaction, e.g. game, server or payment"
 // @Param transaction body TransactionRequest true "Transaction details"
-// @Success 200 {object} map[string]interface{} "Transaction processed successfully"
+// @Success 200 {object} ProcessTransactionResponse "Transaction processed successfully, or replayed if the transactionId was already processed"
 // @Failure 400 {object} map[string]interface{} "Bad Request: Invalid input or insufficient balance"
+// @Failure 403 {object} map[string]interface{} "Forbidden: A loss or deposit limit would be exceeded (code LIMIT_EXCEEDED), or the account status does not allow the transaction (code ACCOUNT_RESTRICTED)"
 // @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
-// @Failure 409 {object} map[string]interface{} "Conflict: Transaction with this ID already processed"
+// @Failure 409 {object} map[string]interface{} "Conflict: Transaction ID reused with a different payload, balance modified concurrently, or round closed or belonging to another user"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
 // @Router /user/{userId}/transaction [post]
+// @Router /provider/{provider}/player/{externalId}/transaction [post]
 func (h *Handler) ProcessTransaction(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := strconv.ParseUint(userIDStr, 10, 64)
-	if err != nil || userID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+	userID, ok := userIDParam(c)
+	if !ok {
 		return
 	}
 
-	sourceType := c.GetHeader("Source-Type")
-	if sourceType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing Source-Type header"})
-		return
-	}
-	if sourceType != "game" && sourceType != "server" && sourceType != "payment" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Source-Type header. Must be 'game', 'server', or 'payment'."})
+	sourceType, ok := h.requireSourceType(c)
+	if !ok {
 		return
 	}
 
 	var req TransactionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": bindingErrorMessage(err, req.Amount)})
 		return
 	}
 
@@ -100,8 +181,8 @@ func (h *Handler) ProcessTransaction(c *gin.Context) {
 			return
 		}
 		for _, err := range err.(validator.ValidationErrors) {
-			if err.Field() == "Amount" && err.Tag() == "decimal_2_places" {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be a valid number string with up to 2 decimal places."})
+			if err.Field() == "Amount" && err.Tag() == "decimal_amount" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": amountPlacesMessage})
 				return
 			}
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed for field '" + err.Field() + "': " + err.Tag()})
@@ -119,72 +200,2169 @@ func (h *Handler) ProcessTransaction(c *gin.Context) {
 		return
 	}
 
-	// Ensure amount is rounded to 2 decimal places before passing to service
-	// This adds an extra layer of safety, although `validateDecimalTwoPlaces` should prevent >2 places.
-	amount = amount.RoundBank(2)
+	if transaction.Type(req.State) == transaction.TypeCancel {
+		h.cancelTransaction(c, userID, sourceType, &req, amount)
+		return
+	}
+
+	newTransaction := newRequestTransaction(userID, sourceType, &req, amount)
+	result, err := h.transactionService.ProcessTransaction(userID, newTransaction)
+	if err != nil {
+		transactionError(c, err, fmt.Sprintf("processing transaction for user %d", userID))
+		return
+	}
+
+	c.JSON(http.StatusOK, ProcessTransactionResponse{
+		TransactionResponse: newTransactionResponse(result.Transaction),
+		IdempotentReplay:    result.IdempotentReplay,
+	})
+}
+
+// transactionError responds to a failed transaction or reversal; action describes it for the log.
+func transactionError(c *gin.Context, err error, action string) {
+	if appErrors.IsNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsLimitExceededError(err) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "LIMIT_EXCEEDED"})
+		return
+	}
+	if appErrors.IsAccountRestrictedError(err) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "ACCOUNT_RESTRICTED"})
+		return
+	}
+	if appErrors.IsConflictError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsValidationError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Error %s: %v", action, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
 
+// newRequestTransaction builds the transaction of a 'win' or 'lose' request. If the request names
+// a wallet currency other than the amount's, the amount is converted by the service.
+func newRequestTransaction(userID uint64, sourceType string, req *TransactionRequest, amount decimal.Decimal) *transaction.Transaction {
 	newTransaction := &transaction.Transaction{
 		UserID:        userID,
 		TransactionID: req.TransactionID,
 		SourceType:    sourceType,
-		State:         req.State,
+		State:         transaction.Type(req.State),
+		Currency:      strings.ToUpper(req.Currency),
 		Amount:        amount,
+		Bucket:        req.Bucket,
+		RoundID:       req.RoundID,
+		GameID:        req.GameID,
+	}
+	if walletCurrency := strings.ToUpper(req.WalletCurrency); walletCurrency != "" && walletCurrency != newTransaction.Currency {
+		if newTransaction.Currency != "" {
+			newTransaction.OriginalAmount = decimal.NewNullDecimal(amount)
+			newTransaction.OriginalCurrency = newTransaction.Currency
+			newTransaction.Amount = decimal.Zero // Set by the service from the exchange rate
+		}
+		newTransaction.Currency = walletCurrency
+	}
+	return newTransaction
+}
+
+// cancelTransaction handles a "cancel" TransactionRequest as a non-forced reversal of the referenced transaction.
+func (h *Handler) cancelTransaction(c *gin.Context, userID uint64, sourceType string, req *TransactionRequest, amount decimal.Decimal) {
+	if req.ReferenceTransactionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "referenceTransactionId is required for 'cancel' transactions."})
+		return
+	}
+
+	result, err := h.transactionService.ReverseTransaction(services.ReversalRequest{
+		SourceType:            sourceType,
+		OriginalTransactionID: req.ReferenceTransactionID,
+		TransactionID:         req.TransactionID,
+		UserID:                userID,
+		Amount:                &amount,
+	})
+	if err != nil {
+		transactionError(c, err, fmt.Sprintf("cancelling transaction %s for user %d", req.ReferenceTransactionID, userID))
+		return
+	}
+
+	c.JSON(http.StatusOK, ProcessTransactionResponse{
+		TransactionResponse: newTransactionResponse(result.Transaction),
+		IdempotentReplay:    result.IdempotentReplay,
+	})
+}
+
+// ReverseTransaction
+// @Summary Reverses a processed transaction
+// @Description Creates a compensating 'cancel' entry linked to the original transaction and applies the inverse balance change atomically.
+// @Description The reversal is idempotent on its own transactionId (derived from the original when omitted). A transaction can only be reversed once,
+// @Description and a reversal that would make the balance negative is refused unless force is set.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Param transactionId path string true "External ID of the transaction to reverse"
+// @Param Source-Type header string true "Source of the transaction to reverse"
+// @Param reversal body ReverseTransactionRequest false "Reversal options"
+// @Success 200 {object} ProcessTransactionResponse "Reversal processed successfully, or replayed"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid input, or reversal would make the balance negative"
+// @Failure 403 {object} map[string]interface{} "Forbidden: The account status does not allow the reversal (code ACCOUNT_RESTRICTED)"
+// @Failure 404 {object} map[string]interface{} "Not Found: Transaction does not exist"
+// @Failure 409 {object} map[string]interface{} "Conflict: Transaction already reversed, or reversal ID reused"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /transactions/{transactionId}/reverse [post]
+func (h *Handler) ReverseTransaction(c *gin.Context) {
+	transactionID := c.Param("transactionId")
+
+	sourceType, ok := h.requireSourceType(c)
+	if !ok {
+		return
+	}
+
+	var req ReverseTransactionRequest
+	// The body is optional: an empty request reverses with a derived reversal ID and no force.
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.transactionService.ReverseTransaction(services.ReversalRequest{
+		SourceType:            sourceType,
+		OriginalTransactionID: transactionID,
+		TransactionID:         req.TransactionID,
+		Force:                 req.Force,
+	})
+	if err != nil {
+		transactionError(c, err, "reversing transaction "+transactionID)
+		return
+	}
+
+	c.JSON(http.StatusOK, ProcessTransactionResponse{
+		TransactionResponse: newTransactionResponse(result.Transaction),
+		IdempotentReplay:    result.IdempotentReplay,
+	})
+}
+
+// GetUserBalance
+// @Summary Gets current user balance
+// @Description Retrieves the current balance of every wallet of the specified user. A user without transactions has no wallets.
+// @Description Each wallet's balance is broken down into real and bonus money; the available balance excludes funds reserved by active holds.
+// @Tags Users
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param provider path string true "Provider name"
+// @Param externalId path string true "The provider's player ID"
+// @Success 200 {object} BalanceResponse "Current user balance"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /user/{userId}/balance [get]
+// @Router /provider/{provider}/player/{externalId}/balance [get]
+func (h *Handler) GetUserBalance(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	if !h.checkProviderServesUser(c, userID) {
+		return
 	}
 
-	err = h.transactionService.ProcessTransaction(userID, newTransaction)
+	wallets, err := h.transactionService.GetUserBalance(userID)
 	if err != nil {
 		if appErrors.IsNotFoundError(err) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		if appErrors.IsConflictError(err) {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		log.Printf("Error getting balance for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := BalanceResponse{
+		UserID:  userID,
+		Wallets: make([]WalletResponse, 0, len(wallets)),
+	}
+	for _, w := range wallets {
+		response.Wallets = append(response.Wallets, newWalletResponse(&w))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// checkProviderServesUser checks, if provider authentication is enabled, that the provider that
+// signed the request serves the user, see services.ProviderServesUser. It writes a 404 response
+// and returns false if not, so that providers cannot tell other users from unknown ones.
+func (h *Handler) checkProviderServesUser(c *gin.Context, userID uint64) bool {
+	p, ok := authenticatedProvider(c)
+	if !ok {
+		return true
+	}
+	serves, err := h.transactionService.ProviderServesUser(p, userID)
+	if err != nil {
+		log.Printf("Error checking whether provider %s serves user %d: %v", p.Name, userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return false
+	}
+	if !serves {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("user with ID %d not found", userID)})
+		return false
+	}
+	return true
+}
+
+func newWalletResponse(w *user.Wallet) WalletResponse {
+	return WalletResponse{
+		Currency:         w.Currency,
+		Balance:          currency.Format(w.Currency, w.Balance),
+		AvailableBalance: currency.Format(w.Currency, w.Available()),
+		RealBalance:      currency.Format(w.Currency, w.RealBalance()),
+		BonusBalance:     currency.Format(w.Currency, w.BonusBalance),
+		CreditLimit:      currency.Format(w.Currency, w.CreditLimit),
+	}
+}
+
+// SetCreditLimit
+// @Summary Sets the credit limit of a user's wallet
+// @Description Allows the wallet's balance to be drawn down to minus the credit limit by losses, holds and reversals. The default limit of 0 keeps balances from going negative.
+// @Description Lowering the limit below what is already drawn blocks further debits but leaves the balance unchanged.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param limit body SetCreditLimitRequest true "Credit limit"
+// @Success 200 {object} WalletResponse "Updated wallet"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId, currency or limit"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /admin/user/{userId}/credit-limit [put]
+func (h *Handler) SetCreditLimit(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+		return
+	}
+
+	var req SetCreditLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, err := utils.ParseDecimal(req.CreditLimit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid creditLimit format. Must be a valid decimal string."})
+		return
+	}
+
+	wallet, err := h.transactionService.SetCreditLimit(userID, strings.ToUpper(req.Currency), limit)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
 		if appErrors.IsValidationError(err) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		log.Printf("Error processing transaction for user %d: %v", userID, err)
+		log.Printf("Error setting credit limit for user %d: %v", userID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
+	c.JSON(http.StatusOK, newWalletResponse(wallet))
+}
+
+// SetUserStatus
+// @Summary Changes the account status of a user
+// @Description Moves the account through its status machine: active may become frozen, self_excluded or closed; frozen may become active or closed; a self-exclusion may only be extended or the account closed until it ends, after which the account is active again; closed is final.
+// @Description Frozen and closed accounts reject all transactions, self-excluded accounts reject debits. Every change is recorded with its reason in the audit trail.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param status body SetUserStatusRequest true "New status and reason"
+// @Success 200 {object} UserStatusResponse "Updated status with the audit trail"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId, status, end date or missing reason"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 409 {object} map[string]interface{} "Conflict: The status machine does not allow the change"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /admin/user/{userId}/status [put]
+func (h *Handler) SetUserStatus(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+		return
+	}
+
+	var req SetUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{})
+	_, err = h.transactionService.SetUserStatus(userID, services.StatusRequest{
+		Status: user.Status(req.Status),
+		Until:  req.Until,
+		Reason: req.Reason,
+	})
+	if err != nil {
+		statusError(c, err, "changing", userID)
+		return
+	}
+	h.respondUserStatus(c, userID)
 }
 
-// GetUserBalance
-// @Summary Gets current user balance
-// @Description Retrieves the current balance for a specified user.
-// @Tags Users
+// GetUserStatus
+// @Summary Gets the account status of a user
+// @Description Returns the current account status together with the audit trail of status changes, oldest first.
+// @Tags Admin
 // @Produce json
 // @Param userId path int true "User ID"
-// @Success 200 {object} BalanceResponse "Current user balance"
+// @Success 200 {object} UserStatusResponse "Status with the audit trail"
 // @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
 // @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
-// @Router /user/{userId}/balance [get]
-func (h *Handler) GetUserBalance(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+// @Security AdminKey
+// @Router /admin/user/{userId}/status [get]
+func (h *Handler) GetUserStatus(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
 	if err != nil || userID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive number."})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+		return
+	}
+	h.respondUserStatus(c, userID)
+}
+
+func (h *Handler) respondUserStatus(c *gin.Context, userID uint64) {
+	u, changes, err := h.transactionService.GetUserStatus(userID)
+	if err != nil {
+		statusError(c, err, "getting", userID)
+		return
+	}
+
+	response := UserStatusResponse{
+		UserID:  u.ID,
+		Status:  string(u.CurrentStatus(time.Now())),
+		History: make([]StatusChangeResponse, 0, len(changes)),
+	}
+	if response.Status == string(user.StatusSelfExcluded) {
+		response.SelfExcludedUntil = u.SelfExcludedUntil
+	}
+	for _, change := range changes {
+		response.History = append(response.History, StatusChangeResponse{
+			FromStatus:        string(change.FromStatus),
+			ToStatus:          string(change.ToStatus),
+			SelfExcludedUntil: change.SelfExcludedUntil,
+			Reason:            change.Reason,
+			ChangedAt:         change.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func statusError(c *gin.Context, err error, action string, userID uint64) {
+	if appErrors.IsNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsConflictError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsValidationError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	log.Printf("Error %s account status of user %d: %v", action, userID, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
+
+// ListSourceTypes
+// @Summary Lists the Source-Types
+// @Description Returns every Source-Type providers may send transactions with, enabled or not, with its settings.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} SourceTypesResponse "Source-Types ordered by name"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /admin/source-types [get]
+func (h *Handler) ListSourceTypes(c *gin.Context) {
+	sources, err := h.transactionService.ListSources()
+	if err != nil {
+		log.Printf("Error listing Source-Types: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := SourceTypesResponse{SourceTypes: make([]SourceTypeResponse, 0, len(sources))}
+	for i := range sources {
+		response.SourceTypes = append(response.SourceTypes, newSourceTypeResponse(&sources[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSourceType
+// @Summary Gets a Source-Type
+// @Description Returns the settings of one Source-Type.
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Source-Type name"
+// @Success 200 {object} SourceTypeResponse "Source-Type settings"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: Source-Type does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /admin/source-types/{name} [get]
+func (h *Handler) GetSourceType(c *gin.Context) {
+	h.respondSourceType(c, c.Param("name"))
+}
+
+// SaveSourceType
+// @Summary Onboards or reconfigures a Source-Type
+// @Description Creates the Source-Type if it does not exist yet, or replaces its settings. The settings apply to the transactions and holds sent from then on; transactions already processed are not affected.
+// @Description Disabled sources, transaction types not in states and amounts above maxAmount, converted into maxAmountCurrency at the current rate, are rejected with 400. Without checkBalance, debits of the source are booked even if they overdraw the balance, e.g. for chargebacks.
+// @Description Names are lower case letters, digits, '_' and '-', starting with a letter; 'transfer' is reserved. Source-Types cannot be deleted, only disabled.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Source-Type name"
+// @Param source body SourceTypeRequest true "Source-Type settings"
+// @Success 200 {object} SourceTypeResponse "Saved Source-Type settings"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid name, state, maxAmount or maxAmountCurrency"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /admin/source-types/{name} [put]
+func (h *Handler) SaveSourceType(c *gin.Context) {
+	name := c.Param("name")
+
+	var req SourceTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	src := &source.Source{Name: name, Enabled: *req.Enabled, States: source.States{}, CheckBalance: *req.CheckBalance}
+	for _, state := range req.States {
+		src.States = append(src.States, transaction.Type(state))
+	}
+	if req.MaxAmount != "" {
+		maxAmount, err := utils.ParseDecimal(req.MaxAmount)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maxAmount format. Must be a valid decimal string."})
+			return
+		}
+		src.MaxAmount = decimal.NewNullDecimal(maxAmount)
+	}
+	src.MaxAmountCurrency = strings.ToUpper(req.MaxAmountCurrency)
+
+	if err := h.transactionService.SaveSource(src); err != nil {
+		if appErrors.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error saving Source-Type %s: %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	h.respondSourceType(c, name)
+}
 
-	user, err := h.transactionService.GetUserBalance(userID)
+func (h *Handler) respondSourceType(c *gin.Context, name string) {
+	src, err := h.transactionService.GetSource(name)
 	if err != nil {
 		if appErrors.IsNotFoundError(err) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		log.Printf("Error getting balance for user %d: %v", userID, err)
+		log.Printf("Error getting Source-Type %s: %v", name, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
+	c.JSON(http.StatusOK, newSourceTypeResponse(src))
+}
+
+func newSourceTypeResponse(src *source.Source) SourceTypeResponse {
+	response := SourceTypeResponse{
+		Name:         src.Name,
+		Enabled:      src.Enabled,
+		States:       make([]string, len(src.States)),
+		CheckBalance: src.CheckBalance,
+		UpdatedAt:    src.UpdatedAt,
+	}
+	for i, state := range src.States {
+		response.States[i] = string(state)
+	}
+	if src.MaxAmount.Valid {
+		response.MaxAmount = src.MaxAmount.Decimal.String()
+		response.MaxAmountCurrency = src.MaxAmountCurrency
+	}
+	return response
+}
+
+// Headers of signed provider requests; see provider.Sign for what is signed.
+const (
+	providerKeyHeader       = "X-Provider-Key"
+	providerTimestampHeader = "X-Timestamp"
+	providerSignatureHeader = "X-Signature"
+)
+
+// adminKeyHeader carries the operators' key on the admin routes.
+const adminKeyHeader = "X-Admin-Key"
+
+// AuthenticateAdmin returns the middleware of the routes meant for operators. It rejects requests
+// whose X-Admin-Key header is not apiKey, comparing in constant time.
+func AuthenticateAdmin(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader(adminKeyHeader)), []byte(apiKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid admin key."})
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticatedProviderKey is the context key under which AuthenticateProvider stores the provider.
+const authenticatedProviderKey = "authenticatedProvider"
+
+// authenticatedProvider returns the provider that signed the request, if provider authentication is enabled.
+func authenticatedProvider(c *gin.Context) (*provider.Provider, bool) {
+	p, ok := c.Get(authenticatedProviderKey)
+	if !ok {
+		return nil, false
+	}
+	return p.(*provider.Provider), true
+}
+
+// AuthenticateProvider is the middleware of the routes providers call when provider authentication
+// is enabled. It verifies the request's signature and timestamp, and checks that the signing
+// provider may use the request's Source-Type header, which requests other than lookups must send,
+// and, on the player routes, its provider path parameter. Lookups only see the provider's own
+// users and transactions.
+func (h *Handler) AuthenticateProvider(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body."})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
 
-	c.JSON(http.StatusOK, BalanceResponse{
-		UserID:  user.ID,
-		Balance: user.Balance.StringFixed(2), // Round to 2 decimal places
+	p, err := h.transactionService.AuthenticateProvider(services.SignedRequest{
+		KeyID:     c.GetHeader(providerKeyHeader),
+		Timestamp: c.GetHeader(providerTimestampHeader),
+		Signature: c.GetHeader(providerSignatureHeader),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.RequestURI(),
+		Body:      body,
 	})
+	if err != nil {
+		if appErrors.IsUnauthorizedError(err) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error authenticating provider request with key %s: %v", c.GetHeader(providerKeyHeader), err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	sourceType := c.GetHeader("Source-Type")
+	if sourceType == "" && c.Request.Method != http.MethodGet {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Missing Source-Type header"})
+		return
+	}
+	if sourceType != "" && !p.AllowsSource(sourceType) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Provider %s may not send Source-Type %s.", p.Name, sourceType)})
+		return
+	}
+	if name := c.Param("provider"); name != "" && name != p.Name {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Provider %s may not act for provider %s.", p.Name, name)})
+		return
+	}
+	c.Set(authenticatedProviderKey, p)
+	c.Next()
+}
+
+// ListProviders
+// @Summary Lists the providers
+// @Description Returns every provider with its settings; the keys of a provider are returned by GET /admin/providers/{name}.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} ProvidersResponse "Providers ordered by name"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Provider authentication is not available"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /admin/providers [get]
+func (h *Handler) ListProviders(c *gin.Context) {
+	providers, err := h.transactionService.ListProviders()
+	if err != nil {
+		if appErrors.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error listing providers: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := ProvidersResponse{Providers: make([]ProviderResponse, 0, len(providers))}
+	for i := range providers {
+		response.Providers = append(response.Providers, newProviderResponse(&providers[i], nil))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetProvider
+// @Summary Gets a provider
+// @Description Returns the settings of a provider and the IDs of its active signing keys.
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Provider name"
+// @Success 200 {object} ProviderResponse "Provider settings and keys"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Provider authentication is not available"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: Provider does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /admin/providers/{name} [get]
+func (h *Handler) GetProvider(c *gin.Context) {
+	h.respondProvider(c, c.Param("name"))
+}
+
+// SaveProvider
+// @Summary Onboards or reconfigures a provider
+// @Description Creates the provider if it does not exist yet, or replaces its settings. A provider may only send requests with the Source-Types it is bound to; its name is also the only provider it may address players of on the /provider/{provider}/player routes.
+// @Description A new provider has no keys yet; create one with POST /admin/providers/{name}/keys.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Provider name"
+// @Param provider body ProviderRequest true "Provider settings"
+// @Success 200 {object} ProviderResponse "Saved provider settings and keys"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid name or Source-Type, or provider authentication is not available"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /admin/providers/{name} [put]
+func (h *Handler) SaveProvider(c *gin.Context) {
+	name := c.Param("name")
+
+	var req ProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p := &provider.Provider{Name: name, Enabled: *req.Enabled, SourceTypes: provider.Names(req.SourceTypes)}
+	if err := h.transactionService.SaveProvider(p); err != nil {
+		providerError(c, err, "saving", name)
+		return
+	}
+	h.respondProvider(c, name)
+}
+
+// RotateProviderKey
+// @Summary Creates a signing key for a provider
+// @Description Creates a key and returns its secret, which is not shown again. The provider's previous key stays valid so that the provider can switch to the new key without rejected requests; any older key is retired.
+// @Description Revoke the previous key once the provider signs with the new one.
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Provider name"
+// @Success 201 {object} ProviderKeyCreatedResponse "New key with its secret"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Provider authentication is not available"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: Provider does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /admin/providers/{name}/keys [post]
+func (h *Handler) RotateProviderKey(c *gin.Context) {
+	name := c.Param("name")
+	key, err := h.transactionService.RotateProviderKey(name)
+	if err != nil {
+		providerError(c, err, "rotating the key of", name)
+		return
+	}
+	c.JSON(http.StatusCreated, ProviderKeyCreatedResponse{KeyID: key.ID, Secret: key.Secret, CreatedAt: key.CreatedAt})
+}
+
+// RevokeProviderKey
+// @Summary Revokes a signing key of a provider
+// @Description Deletes the key; requests signed with it are rejected from then on.
+// @Tags Admin
+// @Param name path string true "Provider name"
+// @Param keyId path string true "Key ID"
+// @Success 204 "Key revoked"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Provider authentication is not available"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: Provider has no such key"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /admin/providers/{name}/keys/{keyId} [delete]
+func (h *Handler) RevokeProviderKey(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.transactionService.RevokeProviderKey(name, c.Param("keyId")); err != nil {
+		providerError(c, err, "revoking a key of", name)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) respondProvider(c *gin.Context, name string) {
+	p, keys, err := h.transactionService.GetProvider(name)
+	if err != nil {
+		providerError(c, err, "getting", name)
+		return
+	}
+	c.JSON(http.StatusOK, newProviderResponse(p, keys))
+}
+
+func newProviderResponse(p *provider.Provider, keys []provider.Key) ProviderResponse {
+	response := ProviderResponse{
+		Name:        p.Name,
+		Enabled:     p.Enabled,
+		SourceTypes: append([]string{}, p.SourceTypes...),
+		UpdatedAt:   p.UpdatedAt,
+	}
+	for _, key := range keys {
+		response.Keys = append(response.Keys, ProviderKeyResponse{KeyID: key.ID, CreatedAt: key.CreatedAt})
+	}
+	return response
+}
+
+func providerError(c *gin.Context, err error, action, name string) {
+	if appErrors.IsNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsValidationError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Error %s provider %s: %v", action, name, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
+
+// VerifyUserBalance
+// @Summary Verifies user balance against the ledger
+// @Description Compares the stored balance of each of the user's wallets with the sum of the postings on the wallet's ledger account.
+// @Tags Ledger
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} UserBalanceVerificationResponse "Stored and ledger balance per wallet"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /user/{userId}/balance/verify [get]
+func (h *Handler) VerifyUserBalance(c *gin.Context) {
+	userIDStr := c.Param("userId")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive number."})
+		return
+	}
+
+	reconciliations, err := h.ledgerService.VerifyUserBalance(userID)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error verifying balance for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := UserBalanceVerificationResponse{
+		UserID:     userID,
+		Consistent: true,
+		Wallets:    make([]BalanceVerificationResponse, 0, len(reconciliations)),
+	}
+	for _, r := range reconciliations {
+		response.Consistent = response.Consistent && r.Consistent()
+		response.Wallets = append(response.Wallets, newBalanceVerificationResponse(r))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// AuditLedger
+// @Summary Audits the ledger
+// @Description Lists journal entries that do not balance and wallets whose stored balance differs from the ledger.
+// @Tags Ledger
+// @Produce json
+// @Success 200 {object} LedgerAuditResponse "Audit result"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /ledger/audit [get]
+func (h *Handler) AuditLedger(c *gin.Context) {
+	report, err := h.ledgerService.Audit()
+	if err != nil {
+		log.Printf("Error auditing ledger: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := LedgerAuditResponse{
+		Consistent:        report.Consistent(),
+		UnbalancedEntries: make([]UnbalancedEntryResponse, 0, len(report.UnbalancedEntries)),
+		MismatchedWallets: make([]BalanceVerificationResponse, 0, len(report.MismatchedWallets)),
+	}
+	for _, e := range report.UnbalancedEntries {
+		response.UnbalancedEntries = append(response.UnbalancedEntries, UnbalancedEntryResponse{
+			JournalEntryID: e.JournalEntryID,
+			Sum:            e.Sum.String(),
+		})
+	}
+	for _, r := range report.MismatchedWallets {
+		response.MismatchedWallets = append(response.MismatchedWallets, newBalanceVerificationResponse(r))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyTransactionChain
+// @Summary Checks the user's balance timeline
+// @Description Walks the user's transactions in the order they were applied and reports, per wallet, every row whose balanceBefore does not equal the previous row's balanceAfter or that did not move the balance by its amount.
+// @Tags Ledger
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} TransactionChainResponse "Chain check result"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /user/{userId}/balance/chain [get]
+func (h *Handler) VerifyTransactionChain(c *gin.Context) {
+	userIDStr := c.Param("userId")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive number."})
+		return
+	}
+
+	report, err := h.transactionService.VerifyTransactionChain(userID)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error checking balance chain for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := TransactionChainResponse{
+		UserID:       report.UserID,
+		Consistent:   report.Consistent(),
+		CheckedCount: report.Checked,
+		Wallets:      make([]WalletChainResponse, 0, len(report.Wallets)),
+		Breaks:       make([]ChainBreakResponse, 0, len(report.Breaks)),
+	}
+	for _, w := range report.Wallets {
+		wallet := WalletChainResponse{Currency: w.Currency, Consistent: w.Consistent()}
+		if w.Balance.Valid {
+			wallet.Balance = currency.Format(w.Currency, w.Balance.Decimal)
+		}
+		if w.LastBalanceAfter.Valid {
+			wallet.LastBalanceAfter = currency.Format(w.Currency, w.LastBalanceAfter.Decimal)
+		}
+		response.Wallets = append(response.Wallets, wallet)
+	}
+	for _, b := range report.Breaks {
+		response.Breaks = append(response.Breaks, ChainBreakResponse{ID: b.ID, TransactionID: b.TransactionID, Currency: b.Currency, Reason: b.Reason})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func newBalanceVerificationResponse(r ledger.Reconciliation) BalanceVerificationResponse {
+	return BalanceVerificationResponse{
+		UserID:        r.UserID,
+		Currency:      r.Currency,
+		Balance:       currency.Format(r.Currency, r.Balance),
+		LedgerBalance: currency.Format(r.Currency, r.LedgerBalance),
+		Consistent:    r.Consistent(),
+	}
+}
+
+// GetUserTransactions
+// @Summary Lists user transaction history
+// @Description Returns the user's transactions newest first using cursor-based pagination over (processedAt, id), with optional filters.
+// @Tags Users
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param provider path string true "Provider name"
+// @Param externalId path string true "The provider's player ID"
+// @Param state query string false "Filter by transaction state" Enums(win, lose, cancel, deposit, withdrawal, bonus_grant, adjustment, fee, refund, debit, credit)
+// @Param sourceType query string false "Filter by Source-Type, a registered source or transfer"
+// @Param currency query string false "Filter by ISO 4217 currency code"
+// @Param minAmount query string false "Minimum amount (inclusive)"
+// @Param maxAmount query string false "Maximum amount (inclusive)"
+// @Param from query string false "Start of the time window, RFC3339 (inclusive)"
+// @Param to query string false "End of the time window, RFC3339 (exclusive)"
+// @Param cursor query string false "Cursor returned as nextCursor by the previous page"
+// @Param limit query int false "Page size (default 50, max 100)"
+// @Success 200 {object} TransactionHistoryResponse "Page of transactions"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId or filter"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /user/{userId}/transactions [get]
+// @Router /provider/{provider}/player/{externalId}/transactions [get]
+func (h *Handler) GetUserTransactions(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	filter, err := parseTransactionListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.SourceType != "" && filter.SourceType != transfer.SourceType && !h.checkSourceType(c, filter.SourceType, "sourceType filter") {
+		return
+	}
+	if p, ok := authenticatedProvider(c); ok {
+		filter.SourceTypes = p.SourceTypes
+	}
+
+	transactions, nextCursor, err := h.transactionService.ListUserTransactions(userID, filter)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error listing transactions for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := TransactionHistoryResponse{
+		Transactions: make([]TransactionResponse, 0, len(transactions)),
+		NextCursor:   nextCursor,
+	}
+	for _, t := range transactions {
+		response.Transactions = append(response.Transactions, newTransactionResponse(&t))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func newTransactionResponse(t *transaction.Transaction) TransactionResponse {
+	response := TransactionResponse{
+		ID:            t.ID,
+		ReversesID:    t.ReversesID,
+		TransferID:    t.TransferID,
+		RoundID:       t.RoundID,
+		GameID:        t.GameID,
+		UserID:        t.UserID,
+		TransactionID: t.TransactionID,
+		SourceType:    t.SourceType,
+		State:         string(t.State),
+		Currency:      t.Currency,
+		Amount:        currency.Format(t.Currency, t.Amount),
+		ProcessedAt:   t.ProcessedAt,
+		Bucket:        t.Bucket,
+		BonusAmount:   currency.Format(t.Currency, t.BonusAmount),
+	}
+	if t.BalanceBefore.Valid {
+		response.BalanceBefore = currency.Format(t.Currency, t.BalanceBefore.Decimal)
+	}
+	if t.BalanceAfter.Valid {
+		response.BalanceAfter = currency.Format(t.Currency, t.BalanceAfter.Decimal)
+	}
+	if t.Converted() {
+		response.OriginalAmount = currency.Format(t.OriginalCurrency, t.OriginalAmount.Decimal)
+		response.OriginalCurrency = t.OriginalCurrency
+		response.ExchangeRate = t.ExchangeRate.Decimal.String()
+		response.RateTimestamp = t.RateTimestamp
+	}
+	return response
+}
+
+// GetTransaction
+// @Summary Gets a transaction by its external ID
+// @Description Looks up a processed transaction by the transactionId supplied by the provider.
+// @Tags Transactions
+// @Produce json
+// @Param transactionId path string true "External transaction ID"
+// @Param Source-Type header string false "Source the transactionId belongs to; required when the ID is used by several sources"
+// @Success 200 {object} TransactionResponse "Stored transaction"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid Source-Type"
+// @Failure 404 {object} map[string]interface{} "Not Found: Transaction does not exist"
+// @Failure 409 {object} map[string]interface{} "Conflict: transactionId is ambiguous without Source-Type"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /transactions/{transactionId} [get]
+func (h *Handler) GetTransaction(c *gin.Context) {
+	transactionID := c.Param("transactionId")
+	sourceType, ok := h.optionalSourceType(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.transactionService.GetTransaction(sourceType, transactionID)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsConflictError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error getting transaction %s: %v", transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if p, ok := authenticatedProvider(c); ok && !p.AllowsSource(t.SourceType) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("transaction with ID %s not found", transactionID)})
+		return
+	}
+
+	c.JSON(http.StatusOK, newTransactionResponse(t))
+}
+
+// GetUserTransaction
+// @Summary Gets a user's transaction by its external ID
+// @Description Looks up a processed transaction by the transactionId supplied by the provider, scoped to the given user.
+// @Tags Users
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param provider path string true "Provider name"
+// @Param externalId path string true "The provider's player ID"
+// @Param transactionId path string true "External transaction ID"
+// @Param Source-Type header string false "Source the transactionId belongs to; required when the ID is used by several sources"
+// @Success 200 {object} TransactionResponse "Stored transaction"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId or Source-Type"
+// @Failure 404 {object} map[string]interface{} "Not Found: Transaction does not exist for this user"
+// @Failure 409 {object} map[string]interface{} "Conflict: transactionId is ambiguous without Source-Type"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /user/{userId}/transactions/{transactionId} [get]
+// @Router /provider/{provider}/player/{externalId}/transactions/{transactionId} [get]
+func (h *Handler) GetUserTransaction(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	transactionID := c.Param("transactionId")
+	sourceType, ok := h.optionalSourceType(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.transactionService.GetUserTransaction(userID, sourceType, transactionID)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsConflictError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error getting transaction %s for user %d: %v", transactionID, userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if p, ok := authenticatedProvider(c); ok && !p.AllowsSource(t.SourceType) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("transaction with ID %s not found for user %d", transactionID, userID)})
+		return
+	}
+
+	c.JSON(http.StatusOK, newTransactionResponse(t))
+}
+
+// requireSourceType reads the Source-Type header of a request that records transactions or holds.
+// It writes a 400 response and returns false if the header is missing or names no registered source.
+func (h *Handler) requireSourceType(c *gin.Context) (string, bool) {
+	sourceType := c.GetHeader("Source-Type")
+	if sourceType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing Source-Type header"})
+		return "", false
+	}
+	return sourceType, h.checkSourceType(c, sourceType, "Source-Type header")
+}
+
+// optionalSourceType reads the Source-Type header on lookup endpoints, where it may be omitted and
+// may also name the transfer source. It writes a 400 response and returns false if the header is
+// present but invalid.
+func (h *Handler) optionalSourceType(c *gin.Context) (string, bool) {
+	sourceType := c.GetHeader("Source-Type")
+	if sourceType == "" || sourceType == transfer.SourceType {
+		return sourceType, true
+	}
+	return sourceType, h.checkSourceType(c, sourceType, "Source-Type header")
+}
+
+// checkSourceType checks that sourceType is a registered source, enabled or not, so that the
+// transactions of a disabled source can still be looked up. It writes an error response and
+// returns false if not; what names the value in the 400 message.
+func (h *Handler) checkSourceType(c *gin.Context, sourceType, what string) bool {
+	_, err := h.transactionService.GetSource(sourceType)
+	if appErrors.IsNotFoundError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s %q. Must be a registered Source-Type.", what, sourceType)})
+		return false
+	}
+	if err != nil {
+		log.Printf("Error getting Source-Type %s: %v", sourceType, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return false
+	}
+	return true
+}
+
+func parseTransactionListFilter(c *gin.Context) (transaction.ListFilter, error) {
+	var filter transaction.ListFilter
+
+	filter.State = transaction.Type(c.Query("state"))
+	if _, ok := transaction.LookupType(filter.State); filter.State != "" && !ok {
+		var types []transaction.Type
+		for _, info := range transaction.Types() {
+			types = append(types, info.Type)
+		}
+		return filter, fmt.Errorf("Invalid state filter. Must be %s.", quotedTypes(types))
+	}
+	// The sourceType filter is checked against the registered sources by the caller.
+	filter.SourceType = c.Query("sourceType")
+	filter.Currency = strings.ToUpper(c.Query("currency"))
+	if filter.Currency != "" && !currency.Valid(filter.Currency) {
+		return filter, errors.New("Invalid currency filter. Must be an ISO 4217 currency code.")
+	}
+
+	if v := c.Query("minAmount"); v != "" {
+		amount, err := utils.ParseDecimal(v)
+		if err != nil {
+			return filter, errors.New("Invalid minAmount. Must be a valid decimal string.")
+		}
+		filter.MinAmount = &amount
+	}
+	if v := c.Query("maxAmount"); v != "" {
+		amount, err := utils.ParseDecimal(v)
+		if err != nil {
+			return filter, errors.New("Invalid maxAmount. Must be a valid decimal string.")
+		}
+		filter.MaxAmount = &amount
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && filter.MinAmount.GreaterThan(*filter.MaxAmount) {
+		return filter, errors.New("minAmount must not be greater than maxAmount.")
+	}
+
+	// The bounds may carry any offset; they are compared as instants in UTC.
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("Invalid from. Must be an RFC3339 timestamp.")
+		}
+		from = from.UTC()
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("Invalid to. Must be an RFC3339 timestamp.")
+		}
+		to = to.UTC()
+		filter.To = &to
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := transaction.DecodeCursor(v)
+		if err != nil {
+			return filter, errors.New("Invalid cursor.")
+		}
+		filter.After = cursor
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > services.MaxHistoryPageSize {
+			return filter, fmt.Errorf("Invalid limit. Must be between 1 and %d.", services.MaxHistoryPageSize)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// PlaceHold
+// @Summary Reserves funds of a user
+// @Description Reserves an amount of the user's available balance, e.g. the stake of a bet, until the hold is captured, released or expires after its TTL.
+// @Description Idempotency is keyed by (Source-Type, holdId); placing the same hold again returns it with idempotentReplay set, while a differing payload is rejected with 409.
+// @Description The held amount counts towards the user's loss limits from the moment the hold is placed.
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param Source-Type header string true "Registered source of the transaction, e.g. game, server or payment"
+// @Param hold body PlaceHoldRequest true "Hold details"
+// @Success 200 {object} PlaceHoldResponse "Hold placed, or replayed if the holdId was already placed"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid input or insufficient available balance"
+// @Failure 403 {object} map[string]interface{} "Forbidden: A loss limit would be exceeded (code LIMIT_EXCEEDED), or the account status does not allow debits (code ACCOUNT_RESTRICTED)"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 409 {object} map[string]interface{} "Conflict: Hold ID reused with a different payload"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /user/{userId}/holds [post]
+func (h *Handler) PlaceHold(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+		return
+	}
+
+	sourceType, ok := h.requireSourceType(c)
+	if !ok {
+		return
+	}
+
+	var req PlaceHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": bindingErrorMessage(err, req.Amount)})
+		return
+	}
+	amount, err := utils.ParseDecimal(req.Amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount format. Must be a valid decimal string."})
+		return
+	}
+	if amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be positive."})
+		return
+	}
+
+	result, err := h.transactionService.PlaceHold(userID, &hold.Hold{
+		HoldID:     req.HoldID,
+		SourceType: sourceType,
+		Currency:   strings.ToUpper(req.Currency),
+		Amount:     amount,
+	}, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsConflictError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsLimitExceededError(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "LIMIT_EXCEEDED"})
+			return
+		}
+		if appErrors.IsAccountRestrictedError(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "ACCOUNT_RESTRICTED"})
+			return
+		}
+		log.Printf("Error placing hold %s for user %d: %v", req.HoldID, userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PlaceHoldResponse{
+		HoldResponse:     newHoldResponse(result.Hold),
+		IdempotentReplay: result.IdempotentReplay,
+	})
+}
+
+// GetHold
+// @Summary Gets a hold of a user
+// @Tags Holds
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param holdId path string true "External hold ID"
+// @Param Source-Type header string true "Source that placed the hold"
+// @Success 200 {object} HoldResponse "Stored hold"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId or Source-Type"
+// @Failure 404 {object} map[string]interface{} "Not Found: Hold does not exist for this user"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /user/{userId}/holds/{holdId} [get]
+func (h *Handler) GetHold(c *gin.Context) {
+	userID, sourceType, ok := h.holdRequestParams(c)
+	if !ok {
+		return
+	}
+	holdID := c.Param("holdId")
+
+	result, err := h.transactionService.GetHold(userID, sourceType, holdID)
+	if err != nil {
+		h.holdError(c, err, "getting", holdID)
+		return
+	}
+	c.JSON(http.StatusOK, newHoldResponse(result))
+}
+
+// CaptureHold
+// @Summary Settles a hold as a loss
+// @Description Converts an active hold into a 'lose' transaction for the held amount and frees the reservation atomically.
+// @Description The capture is idempotent on its transactionId (derived from the hold when omitted); repeating it returns the stored transaction with idempotentReplay set.
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param holdId path string true "External hold ID"
+// @Param Source-Type header string true "Source that placed the hold"
+// @Param capture body CaptureHoldRequest false "Capture options"
+// @Success 200 {object} ProcessTransactionResponse "Hold captured, or replayed"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid input"
+// @Failure 403 {object} map[string]interface{} "Forbidden: The account status does not allow debits (code ACCOUNT_RESTRICTED)"
+// @Failure 404 {object} map[string]interface{} "Not Found: Hold does not exist for this user"
+// @Failure 409 {object} map[string]interface{} "Conflict: Hold is no longer active or has expired"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /user/{userId}/holds/{holdId}/capture [post]
+func (h *Handler) CaptureHold(c *gin.Context) {
+	userID, sourceType, ok := h.holdRequestParams(c)
+	if !ok {
+		return
+	}
+	holdID := c.Param("holdId")
+
+	var req CaptureHoldRequest
+	// The body is optional: an empty request captures with a derived transaction ID.
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.transactionService.CaptureHold(userID, sourceType, holdID, req.TransactionID)
+	if err != nil {
+		h.holdError(c, err, "capturing", holdID)
+		return
+	}
+	c.JSON(http.StatusOK, ProcessTransactionResponse{
+		TransactionResponse: newTransactionResponse(result.Transaction),
+		IdempotentReplay:    result.IdempotentReplay,
+	})
+}
+
+// ReleaseHold
+// @Summary Releases a hold
+// @Description Frees the reserved funds without moving money. Releasing a hold that was already released or has expired returns it unchanged.
+// @Tags Holds
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param holdId path string true "External hold ID"
+// @Param Source-Type header string true "Source that placed the hold"
+// @Success 200 {object} HoldResponse "Released hold"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId or Source-Type"
+// @Failure 404 {object} map[string]interface{} "Not Found: Hold does not exist for this user"
+// @Failure 409 {object} map[string]interface{} "Conflict: Hold has already been captured"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /user/{userId}/holds/{holdId}/release [post]
+func (h *Handler) ReleaseHold(c *gin.Context) {
+	userID, sourceType, ok := h.holdRequestParams(c)
+	if !ok {
+		return
+	}
+	holdID := c.Param("holdId")
+
+	result, err := h.transactionService.ReleaseHold(userID, sourceType, holdID)
+	if err != nil {
+		h.holdError(c, err, "releasing", holdID)
+		return
+	}
+	c.JSON(http.StatusOK, newHoldResponse(result))
+}
+
+// holdRequestParams reads the user ID and Source-Type shared by the routes of an existing hold,
+// answering with 400 and returning false if either is invalid.
+func (h *Handler) holdRequestParams(c *gin.Context) (uint64, string, bool) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+		return 0, "", false
+	}
+	sourceType, ok := h.requireSourceType(c)
+	if !ok {
+		return 0, "", false
+	}
+	return userID, sourceType, true
+}
+
+func (h *Handler) holdError(c *gin.Context, err error, action, holdID string) {
+	if appErrors.IsNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsConflictError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsValidationError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsAccountRestrictedError(err) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "ACCOUNT_RESTRICTED"})
+		return
+	}
+	log.Printf("Error %s hold %s: %v", action, holdID, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
+
+func newHoldResponse(hd *hold.Hold) HoldResponse {
+	return HoldResponse{
+		ID:            hd.ID,
+		HoldID:        hd.HoldID,
+		UserID:        hd.UserID,
+		SourceType:    hd.SourceType,
+		Currency:      hd.Currency,
+		Amount:        currency.Format(hd.Currency, hd.Amount),
+		Status:        string(hd.Status),
+		ExpiresAt:     hd.ExpiresAt,
+		TransactionID: hd.TransactionID,
+		CreatedAt:     hd.CreatedAt,
+	}
+}
+
+// GetLimits
+// @Summary Lists the loss and deposit limits of a user
+// @Description Returns the responsible-gaming loss and deposit limits in force, including raises and removals that are still waiting out their cooling-off period.
+// @Tags Limits
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} LimitsResponse "Limits of the user"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /user/{userId}/limits [get]
+func (h *Handler) GetLimits(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+		return
+	}
+
+	limits, err := h.transactionService.GetLimits(userID)
+	if err != nil {
+		limitError(c, err, "getting limits", userID)
+		return
+	}
+
+	response := LimitsResponse{UserID: userID, Limits: make([]LimitResponse, 0, len(limits))}
+	for i := range limits {
+		response.Limits = append(response.Limits, newLimitResponse(&limits[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetLimit
+// @Summary Sets a loss or deposit limit of a user
+// @Description Caps the sum of the user's losses (kind loss, the default) or deposits (kind deposit) in one currency over a rolling window of 24 hours (daily), 7 days (weekly) or 30 days (monthly). Losses or deposits beyond the limit are rejected with 403 and code LIMIT_EXCEEDED.
+// @Description A new or lower limit applies immediately. A higher limit is scheduled and only applies after the cooling-off period; until then the current limit stays in force.
+// @Tags Limits
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param period path string true "Length of the rolling window" Enums(daily, weekly, monthly)
+// @Param limit body SetLimitRequest true "Loss or deposit limit"
+// @Success 200 {object} LimitResponse "Limit in force, with any scheduled raise"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId, kind, period, currency or amount"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /user/{userId}/limits/{period} [put]
+func (h *Handler) SetLimit(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+		return
+	}
+
+	var req SetLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": bindingErrorMessage(err, req.Amount)})
+		return
+	}
+	amount, err := utils.ParseDecimal(req.Amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount format. Must be a valid decimal string."})
+		return
+	}
+
+	l, err := h.transactionService.SetLimit(userID, limitKind(req.Kind), strings.ToUpper(req.Currency), limit.Period(c.Param("period")), amount)
+	if err != nil {
+		limitError(c, err, "setting limit", userID)
+		return
+	}
+	c.JSON(http.StatusOK, newLimitResponse(l))
+}
+
+// RemoveLimit
+// @Summary Removes a loss or deposit limit of a user
+// @Description Schedules the removal of the limit after the cooling-off period; the limit stays in force until then and is returned with pendingRemoval set. Answers 204 if the limit was removed immediately.
+// @Tags Limits
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param period path string true "Length of the rolling window" Enums(daily, weekly, monthly)
+// @Param kind query string false "Kind of the limit; loss when omitted" Enums(loss, deposit)
+// @Param currency query string false "ISO 4217 code; the configured default currency when omitted"
+// @Success 200 {object} LimitResponse "Limit in force until its removal"
+// @Success 204 "Limit removed"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId or kind"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: User or limit does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /user/{userId}/limits/{period} [delete]
+func (h *Handler) RemoveLimit(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+		return
+	}
+
+	l, err := h.transactionService.RemoveLimit(userID, limitKind(c.Query("kind")), strings.ToUpper(c.Query("currency")), limit.Period(c.Param("period")))
+	if err != nil {
+		limitError(c, err, "removing limit", userID)
+		return
+	}
+	if l == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, newLimitResponse(l))
+}
+
+// limitKind returns the requested kind of limit; loss limits are the default.
+func limitKind(kind string) limit.Kind {
+	if kind == "" {
+		return limit.KindLoss
+	}
+	return limit.Kind(kind)
+}
+
+func limitError(c *gin.Context, err error, action string, userID uint64) {
+	if appErrors.IsNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsValidationError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Error %s for user %d: %v", action, userID, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
+
+func newLimitResponse(l *limit.Limit) LimitResponse {
+	response := LimitResponse{
+		Kind:           string(l.Kind),
+		Period:         string(l.Period),
+		Currency:       l.Currency,
+		Amount:         currency.Format(l.Currency, l.Amount),
+		PendingRemoval: l.PendingRemoval,
+		PendingFrom:    l.PendingFrom,
+	}
+	if l.PendingAmount.Valid {
+		response.PendingAmount = currency.Format(l.Currency, l.PendingAmount.Decimal)
+	}
+	return response
+}
+
+// CreateUser
+// @Summary Creates a user
+// @Description Provisions a user with an empty wallet in the given currency (the configured default currency when omitted).
+// @Description The account starts active unless another initial status is given; a self-excluded account needs an end date.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param user body CreateUserRequest false "User details"
+// @Success 201 {object} UserResponse "Created user"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid currency, status or end date"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 409 {object} map[string]interface{} "Conflict: External reference already in use"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /users [post]
+func (h *Handler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	// The body is optional: an empty request creates an active user with a default-currency wallet.
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, err := h.transactionService.CreateUser(services.NewUserRequest{
+		ExternalRef: req.ExternalRef,
+		Currency:    strings.ToUpper(req.Currency),
+		Status:      user.Status(req.Status),
+		Until:       req.Until,
+	})
+	if err != nil {
+		if appErrors.IsConflictError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error creating user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	h.respondUser(c, http.StatusCreated, u)
+}
+
+// GetUser
+// @Summary Gets a user
+// @Description Returns the user's account details and wallets.
+// @Tags Users
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} UserResponse "User"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /users/{userId} [get]
+func (h *Handler) GetUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+		return
+	}
+
+	u, err := h.transactionService.GetUser(userID)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error getting user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	h.respondUser(c, http.StatusOK, u)
+}
+
+// ListUsers
+// @Summary Lists users
+// @Description Returns users ordered by ID using cursor-based pagination.
+// @Tags Users
+// @Produce json
+// @Param status query string false "Filter by account status" Enums(active, frozen, self_excluded, closed)
+// @Param cursor query string false "Cursor returned as nextCursor by the previous page"
+// @Param limit query int false "Page size (default 50, max 100)"
+// @Success 200 {object} UserListResponse "Page of users"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid filter"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /users [get]
+func (h *Handler) ListUsers(c *gin.Context) {
+	filter := user.ListFilter{Status: user.Status(c.Query("status"))}
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor."})
+			return
+		}
+		filter.AfterID = afterID
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit. Must be a positive integer."})
+			return
+		}
+		filter.Limit = n
+	}
+
+	users, nextCursor, err := h.transactionService.ListUsers(filter)
+	if err != nil {
+		if appErrors.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error listing users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := UserListResponse{
+		Users:      make([]UserResponse, 0, len(users)),
+		NextCursor: nextCursor,
+	}
+	for i := range users {
+		response.Users = append(response.Users, newUserResponse(&users[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// respondUser answers with the user and their wallets.
+func (h *Handler) respondUser(c *gin.Context, status int, u *user.User) {
+	wallets, err := h.transactionService.GetUserBalance(u.ID)
+	if err != nil {
+		log.Printf("Error getting wallets of user %d: %v", u.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := newUserResponse(u)
+	response.Wallets = make([]WalletResponse, 0, len(wallets))
+	for i := range wallets {
+		response.Wallets = append(response.Wallets, newWalletResponse(&wallets[i]))
+	}
+	c.JSON(status, response)
+}
+
+func newUserResponse(u *user.User) UserResponse {
+	response := UserResponse{
+		UserID:    u.ID,
+		Status:    string(u.CurrentStatus(time.Now())),
+		CreatedAt: u.CreatedAt,
+	}
+	if u.ExternalRef != nil {
+		response.ExternalRef = *u.ExternalRef
+	}
+	if response.Status == string(user.StatusSelfExcluded) {
+		response.SelfExcludedUntil = u.SelfExcludedUntil
+	}
+	return response
+}
+
+// resolvedUserIDKey is the context key under which ResolveExternalPlayer stores the resolved user ID.
+const resolvedUserIDKey = "resolvedUserId"
+
+// ResolveExternalPlayer is the middleware of the /provider/:provider/player/:externalId routes. It
+// resolves the provider's player ID to the user it is mapped to, provisioning the user if enabled,
+// so that the handlers shared with the /user/:userId routes can serve the request.
+func (h *Handler) ResolveExternalPlayer(c *gin.Context) {
+	provider, externalID := c.Param("provider"), c.Param("externalId")
+	userID, err := h.transactionService.ResolveExternalUser(provider, externalID)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsValidationError(err) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error resolving player %s of provider %s: %v", externalID, provider, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	c.Set(resolvedUserIDKey, userID)
+	c.Next()
+}
+
+// userIDParam returns the user ID resolved by ResolveExternalPlayer or, on /user routes, the userId
+// path parameter. It writes a 400 response and returns false if the parameter is invalid.
+func userIDParam(c *gin.Context) (uint64, bool) {
+	if userID, ok := c.Get(resolvedUserIDKey); ok {
+		return userID.(uint64), true
+	}
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId. Must be a positive integer."})
+		return 0, false
+	}
+	return userID, true
+}
+
+// LinkExternalID
+// @Summary Maps a provider's player ID to a user
+// @Description Maps the ID a provider uses for a player to an existing user, so that the provider can address the user on the /provider/{provider}/player/{externalId} routes.
+// @Description A player ID belongs to at most one user per provider; repeating a mapping is idempotent.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param mapping body LinkExternalIDRequest true "Provider and player ID"
+// @Success 200 {object} ExternalIDResponse "Player ID mapped to the user"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId, provider or player ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 409 {object} map[string]interface{} "Conflict: Player ID already mapped to another user"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /users/{userId}/external-ids [post]
+func (h *Handler) LinkExternalID(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req LinkExternalIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := h.transactionService.LinkExternalID(userID, req.Provider, req.ExternalID)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsConflictError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error mapping player %s of provider %s to user %d: %v", req.ExternalID, req.Provider, userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, newExternalIDResponse(link))
+}
+
+// ListExternalIDs
+// @Summary Lists the player IDs of a user
+// @Description Returns the providers' player IDs mapped to the user, ordered by provider.
+// @Tags Users
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} ExternalIDsResponse "Player IDs of the user"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid userId"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: User does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /users/{userId}/external-ids [get]
+func (h *Handler) ListExternalIDs(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	links, err := h.transactionService.ListExternalIDs(userID)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error listing player IDs of user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := ExternalIDsResponse{
+		UserID:      userID,
+		ExternalIDs: make([]ExternalIDResponse, 0, len(links)),
+	}
+	for i := range links {
+		response.ExternalIDs = append(response.ExternalIDs, newExternalIDResponse(&links[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func newExternalIDResponse(link *user.ExternalID) ExternalIDResponse {
+	return ExternalIDResponse{
+		Provider:   link.Provider,
+		ExternalID: link.ExternalID,
+		UserID:     link.UserID,
+		CreatedAt:  link.CreatedAt,
+	}
+}
+
+// ProcessTransactionBatch
+// @Summary Processes a batch of transactions
+// @Description Processes up to 500 transactions of any users in one request, each with the idempotency semantics of POST /user/{userId}/transaction; 'cancel' is not supported in batches.
+// @Description In best_effort mode (the default) every item is processed on its own. In atomic mode either all items are applied in one database transaction or, if any item fails, none; the other items are then reported as aborted.
+// @Description Every item gets a result in request order with a status: processed, replayed, insufficient_balance, not_found, rejected, conflict, limit_exceeded, account_restricted, aborted or failed.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Param Source-Type header string true "Registered source of the transaction, e.g. game, server or payment"
+// @Param batch body BatchTransactionRequest true "Batch mode and items"
+// @Success 200 {object} BatchTransactionResponse "Results of the items; in atomic mode all items were applied"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Malformed batch or item"
+// @Failure 422 {object} BatchTransactionResponse "Unprocessable Entity: An item of an atomic batch failed and nothing was applied"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /transactions/batch [post]
+func (h *Handler) ProcessTransactionBatch(c *gin.Context) {
+	sourceType, ok := h.requireSourceType(c)
+	if !ok {
+		return
+	}
+
+	var req BatchTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var amount string
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			for _, fieldErr := range validationErrs {
+				if fieldErr.Field() == "Amount" {
+					amount, _ = fieldErr.Value().(string)
+					break
+				}
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": bindingErrorMessage(err, amount)})
+		return
+	}
+	mode := services.BatchMode(req.Mode)
+	if mode == "" {
+		mode = services.BatchBestEffort
+	}
+
+	items := make([]services.BatchItem, len(req.Items))
+	for i := range req.Items {
+		item := &req.Items[i]
+		amount, err := utils.ParseDecimal(item.Amount)
+		if err != nil || !amount.IsPositive() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Item %d: Amount must be a positive decimal string.", i)})
+			return
+		}
+		items[i] = services.BatchItem{
+			UserID:      item.UserID,
+			Transaction: newRequestTransaction(item.UserID, sourceType, &item.TransactionRequest, amount),
+		}
+	}
+
+	results, err := h.transactionService.ProcessBatch(mode, items)
+	if err != nil {
+		if appErrors.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error processing batch of %d transactions: %v", len(items), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response := BatchTransactionResponse{
+		Mode:    string(mode),
+		Results: make([]BatchItemResponse, len(results)),
+	}
+	status := http.StatusOK
+	for i, result := range results {
+		item := newBatchItemResponse(i, &req.Items[i], result)
+		if result.Err == nil {
+			response.Succeeded++
+		} else {
+			response.Failed++
+			if mode == services.BatchAtomic {
+				status = http.StatusUnprocessableEntity
+			}
+		}
+		response.Results[i] = item
+	}
+	c.JSON(status, response)
+}
+
+func newBatchItemResponse(index int, item *BatchTransactionItem, result services.BatchItemResult) BatchItemResponse {
+	response := BatchItemResponse{
+		Index:         index,
+		UserID:        item.UserID,
+		TransactionID: item.TransactionID,
+	}
+	if result.Err == nil {
+		response.Status = "processed"
+		if result.Result.IdempotentReplay {
+			response.Status = "replayed"
+		}
+		transaction := newTransactionResponse(result.Result.Transaction)
+		response.Transaction = &transaction
+		return response
+	}
+
+	response.Error = result.Err.Error()
+	switch {
+	case errors.Is(result.Err, services.ErrBatchAborted):
+		response.Status = "aborted"
+	case appErrors.IsInsufficientBalanceError(result.Err):
+		response.Status = "insufficient_balance"
+	case appErrors.IsNotFoundError(result.Err):
+		response.Status = "not_found"
+	case appErrors.IsValidationError(result.Err):
+		response.Status = "rejected"
+	case appErrors.IsConflictError(result.Err):
+		response.Status = "conflict"
+	case appErrors.IsLimitExceededError(result.Err):
+		response.Status = "limit_exceeded"
+	case appErrors.IsAccountRestrictedError(result.Err):
+		response.Status = "account_restricted"
+	default:
+		log.Printf("Error processing batch item %d, transaction %s for user %d: %v", index, item.TransactionID, item.UserID, result.Err)
+		response.Status = "failed"
+		response.Error = "Internal server error"
+	}
+	return response
+}
+
+// CreateTransfer
+// @Summary Transfers funds between users
+// @Description Moves real money from one user's wallet to another's, e.g. an affiliate payout or a tournament prize. The debit of the sender and the credit of the receiver are applied in one database transaction and recorded as two transactions of Source-Type 'transfer' linked to the transfer.
+// @Description Idempotency is keyed by transferId; repeating a transfer returns it with idempotentReplay set, while a differing payload is rejected with 409.
+// @Tags Transfers
+// @Accept json
+// @Produce json
+// @Param transfer body TransferRequest true "Transfer details"
+// @Success 200 {object} TransferResponse "Transfer executed, or replayed if the transferId was already processed"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid input or insufficient balance of the sender"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 403 {object} map[string]interface{} "Forbidden: The status of the sender or receiver does not allow the transfer (code ACCOUNT_RESTRICTED)"
+// @Failure 404 {object} map[string]interface{} "Not Found: Sender or receiver does not exist"
+// @Failure 409 {object} map[string]interface{} "Conflict: Transfer ID reused with a different payload"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /transfers [post]
+func (h *Handler) CreateTransfer(c *gin.Context) {
+	var req TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": bindingErrorMessage(err, req.Amount)})
+		return
+	}
+	amount, err := utils.ParseDecimal(req.Amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount format. Must be a valid decimal string."})
+		return
+	}
+	if amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be positive."})
+		return
+	}
+
+	result, err := h.transactionService.Transfer(&transfer.Transfer{
+		TransferID: req.TransferID,
+		FromUserID: req.FromUserID,
+		ToUserID:   req.ToUserID,
+		Currency:   strings.ToUpper(req.Currency),
+		Amount:     amount,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		transferError(c, err, "executing", req.TransferID)
+		return
+	}
+	c.JSON(http.StatusOK, newTransferResponse(result))
+}
+
+// GetTransfer
+// @Summary Gets a transfer by its external ID
+// @Tags Transfers
+// @Produce json
+// @Param transferId path string true "External transfer ID"
+// @Success 200 {object} TransferResponse "Stored transfer with its legs"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: Missing or invalid admin key"
+// @Failure 404 {object} map[string]interface{} "Not Found: Transfer does not exist"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Security AdminKey
+// @Router /transfers/{transferId} [get]
+func (h *Handler) GetTransfer(c *gin.Context) {
+	transferID := c.Param("transferId")
+	result, err := h.transactionService.GetTransfer(transferID)
+	if err != nil {
+		transferError(c, err, "getting", transferID)
+		return
+	}
+	c.JSON(http.StatusOK, newTransferResponse(result))
+}
+
+func transferError(c *gin.Context, err error, action, transferID string) {
+	if appErrors.IsNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsConflictError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsValidationError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if appErrors.IsAccountRestrictedError(err) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "ACCOUNT_RESTRICTED"})
+		return
+	}
+	log.Printf("Error %s transfer %s: %v", action, transferID, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
+
+func newTransferResponse(result *services.TransferResult) TransferResponse {
+	t := result.Transfer
+	return TransferResponse{
+		ID:               t.ID,
+		TransferID:       t.TransferID,
+		FromUserID:       t.FromUserID,
+		ToUserID:         t.ToUserID,
+		Currency:         t.Currency,
+		Amount:           currency.Format(t.Currency, t.Amount),
+		Reason:           t.Reason,
+		CreatedAt:        t.CreatedAt,
+		Debit:            newTransactionResponse(result.Debit),
+		Credit:           newTransactionResponse(result.Credit),
+		IdempotentReplay: result.IdempotentReplay,
+	}
+}
+
+// GetRound
+// @Summary Gets a game round by its external ID
+// @Description Looks up a round by the roundId the provider sent with its transactions, with its stakes, payouts and reversals.
+// @Tags Rounds
+// @Produce json
+// @Param roundId path string true "External round ID"
+// @Param Source-Type header string false "Source the roundId belongs to; required when the ID is used by several sources"
+// @Success 200 {object} RoundResponse "Stored round with its transactions"
+// @Failure 400 {object} map[string]interface{} "Bad Request: Invalid Source-Type"
+// @Failure 404 {object} map[string]interface{} "Not Found: Round does not exist"
+// @Failure 409 {object} map[string]interface{} "Conflict: roundId is ambiguous without Source-Type"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /rounds/{roundId} [get]
+func (h *Handler) GetRound(c *gin.Context) {
+	roundID := c.Param("roundId")
+	sourceType, ok := h.optionalSourceType(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.transactionService.GetRound(sourceType, roundID)
+	if err != nil {
+		if appErrors.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsConflictError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if appErrors.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error getting round %s: %v", roundID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if p, ok := authenticatedProvider(c); ok && !p.AllowsSource(result.Round.SourceType) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("round with ID %s not found", roundID)})
+		return
+	}
+
+	r := result.Round
+	response := RoundResponse{
+		ID:           r.ID,
+		RoundID:      r.RoundID,
+		SourceType:   r.SourceType,
+		UserID:       r.UserID,
+		GameID:       r.GameID,
+		Currency:     r.Currency,
+		Status:       string(r.Status),
+		StakeAmount:  currency.Format(r.Currency, r.StakeAmount),
+		PayoutAmount: currency.Format(r.Currency, r.PayoutAmount),
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+		Transactions: make([]TransactionResponse, 0, len(result.Transactions)),
+	}
+	for i := range result.Transactions {
+		response.Transactions = append(response.Transactions, newTransactionResponse(&result.Transactions[i]))
+	}
+	c.JSON(http.StatusOK, response)
 }
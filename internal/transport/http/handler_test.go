@@ -2,34 +2,57 @@ package http_test
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/server"
 	"github.com/zaynkorai/enlabs/internal/app/services"
+	"github.com/zaynkorai/enlabs/internal/domain/ledger"
+	"github.com/zaynkorai/enlabs/internal/domain/source"
 	"github.com/zaynkorai/enlabs/internal/domain/user"
 	"github.com/zaynkorai/enlabs/internal/platform/persistence"
+	"github.com/zaynkorai/enlabs/internal/platform/rates"
 	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
 	"github.com/zaynkorai/enlabs/pkg/config"
 	"github.com/zaynkorai/enlabs/pkg/database"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
-	testDB    *gorm.DB
-	router    *gin.Engine
-	userRepo  *persistence.UserRepository
-	txnRepo   *persistence.TransactionRepository
-	testUsers = []uint64{1, 2, 3} // Predefined users
+	testDB       *gorm.DB
+	testConfig   *config.Config
+	testHandler  *apihandler.Handler
+	router       *gin.Engine // Serves requests without provider signatures
+	userRepo     *persistence.UserRepository
+	txnRepo      *persistence.TransactionRepository
+	ledgerRepo   *persistence.LedgerRepository
+	holdRepo     *persistence.HoldRepository
+	limitRepo    *persistence.LimitRepository
+	transferRepo *persistence.TransferRepository
+	roundRepo    *persistence.RoundRepository
+	sourceRepo   *persistence.SourceRepository
+	providerRepo *persistence.ProviderRepository
+	testUsers    = []uint64{1, 2, 3} // Predefined users
 )
 
+// testAdminKey is the admin key of the test servers.
+const testAdminKey = "test-admin-key"
+
 func TestMain(m *testing.M) {
+	os.Setenv("ADMIN_API_KEY", testAdminKey)
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load test configuration: %v\n", err)
@@ -45,13 +68,31 @@ func TestMain(m *testing.M) {
 
 	userRepo = persistence.NewUserRepository(testDB)
 	txnRepo = persistence.NewTransactionRepository(testDB)
-	transactionService := services.NewTransactionService(userRepo, txnRepo)
+	ledgerRepo = persistence.NewLedgerRepository(testDB)
+	holdRepo = persistence.NewHoldRepository(testDB)
+	limitRepo = persistence.NewLimitRepository(testDB)
+	transferRepo = persistence.NewTransferRepository(testDB)
+	roundRepo = persistence.NewRoundRepository(testDB)
+	sourceRepo = persistence.NewSourceRepository(testDB)
+	providerRepo = persistence.NewProviderRepository(testDB)
+	rateProvider, err := rates.NewStaticProvider(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), map[string]decimal.Decimal{
+		"USD/EUR": decimal.RequireFromString("0.9137"),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to build test exchange rates: %v\n", err)
+		os.Exit(1)
+	}
+	transactionService := services.NewTransactionService(userRepo, txnRepo, services.WithRateProvider(rateProvider),
+		services.WithHolds(holdRepo, services.DefaultHoldTTL, services.MaxHoldTTL),
+		services.WithLimits(limitRepo, services.DefaultLimitCooldown), services.WithAutoProvisioning(),
+		services.WithTransfers(transferRepo), services.WithRounds(roundRepo, false),
+		services.WithSources(sourceRepo), services.WithProviders(providerRepo, services.DefaultProviderAuthWindow))
+	ledgerService := services.NewLedgerService(ledgerRepo)
 
 	gin.SetMode(gin.TestMode)
-	router = gin.Default()
-	handler := apihandler.NewHandler(transactionService)
-	router.POST("/user/:userId/transaction", handler.ProcessTransaction)
-	router.GET("/user/:userId/balance", handler.GetUserBalance)
+	testConfig = cfg
+	testHandler = apihandler.NewHandler(transactionService, ledgerService)
+	router = newTestRouter(testHandler, false)
 
 	exitCode := m.Run()
 
@@ -63,17 +104,93 @@ func TestMain(m *testing.M) {
 	os.Exit(exitCode)
 }
 
+// newTestRouter returns the server's router for handler, with provider authentication on or off.
+func newTestRouter(handler *apihandler.Handler, providerAuth bool) *gin.Engine {
+	cfg := *testConfig
+	cfg.ProviderAuth = providerAuth
+	cfg.AllowUnsignedRequests = !providerAuth
+	return server.NewServer(&cfg, handler).Engine()
+}
+
+// newAdminRequest returns a request to an admin route carrying the admin key.
+func newAdminRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("X-Admin-Key", testAdminKey)
+	return req
+}
+
 func setupTest(t *testing.T) {
-	err := testDB.Exec("TRUNCATE TABLE transactions RESTART IDENTITY CASCADE").Error
+	err := testDB.Exec("TRUNCATE TABLE ledger_postings, ledger_journal_entries, ledger_accounts RESTART IDENTITY CASCADE").Error
+	assert.NoError(t, err, "Failed to truncate ledger tables")
+	err = testDB.Exec("TRUNCATE TABLE transactions RESTART IDENTITY CASCADE").Error
 	assert.NoError(t, err, "Failed to truncate transactions table")
+	err = testDB.Exec("TRUNCATE TABLE rounds RESTART IDENTITY").Error
+	assert.NoError(t, err, "Failed to truncate rounds table")
+	err = testDB.Exec("TRUNCATE TABLE transfers RESTART IDENTITY").Error
+	assert.NoError(t, err, "Failed to truncate transfers table")
+	err = testDB.Exec("TRUNCATE TABLE holds RESTART IDENTITY").Error
+	assert.NoError(t, err, "Failed to truncate holds table")
+	err = testDB.Exec("TRUNCATE TABLE loss_limits RESTART IDENTITY").Error
+	assert.NoError(t, err, "Failed to truncate loss_limits table")
+	err = testDB.Exec("TRUNCATE TABLE status_changes RESTART IDENTITY").Error
+	assert.NoError(t, err, "Failed to truncate status_changes table")
+	err = testDB.Exec("TRUNCATE TABLE user_external_ids RESTART IDENTITY").Error
+	assert.NoError(t, err, "Failed to truncate user_external_ids table")
+	err = testDB.Exec("TRUNCATE TABLE wallets").Error
+	assert.NoError(t, err, "Failed to truncate wallets table")
 	err = testDB.Exec("TRUNCATE TABLE users RESTART IDENTITY CASCADE").Error
 	assert.NoError(t, err, "Failed to truncate users table")
+	err = testDB.Exec("TRUNCATE TABLE provider_signatures, provider_keys, providers").Error
+	assert.NoError(t, err, "Failed to truncate provider tables")
+	err = testDB.Exec("TRUNCATE TABLE source_types").Error
+	assert.NoError(t, err, "Failed to truncate source_types table")
+
+	for _, src := range source.Defaults() {
+		err := sourceRepo.Save(&src)
+		assert.NoError(t, err, "Failed to re-seed Source-Type %s", src.Name)
+	}
 
 	for _, id := range testUsers {
-		initialUser := user.User{ID: id, Balance: decimal.NewFromInt(0)}
+		initialUser := user.User{ID: id}
 		err := userRepo.Create(&initialUser)
 		assert.NoError(t, err, "Failed to re-seed user %d", id)
 	}
+	err = testDB.Exec("SELECT setval(pg_get_serial_sequence('users', 'id'), (SELECT MAX(id) FROM users))").Error
+	assert.NoError(t, err, "Failed to advance the user ID sequence")
+}
+
+// setWalletBalance overwrites the balance of the user's wallet in the default currency, opening it if needed.
+func setWalletBalance(t *testing.T, userID uint64, balance decimal.Decimal) {
+	t.Helper()
+	wallet := user.Wallet{UserID: userID, Currency: services.DefaultCurrency, Balance: balance}
+	err := testDB.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"balance"})}).Create(&wallet).Error
+	assert.NoError(t, err)
+}
+
+// walletBalance returns the balance of the user's wallet in the default currency, zero if it is not open.
+func walletBalance(t *testing.T, userID uint64) decimal.Decimal {
+	t.Helper()
+	wallet, err := userRepo.GetWallet(userID, services.DefaultCurrency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return decimal.Zero
+	}
+	assert.NoError(t, err)
+	return wallet.Balance
+}
+
+// assertLedgerConsistent checks that the stored balance of each of the user's wallets equals its
+// ledger balance and that every journal entry balances.
+func assertLedgerConsistent(t *testing.T, userID uint64) {
+	t.Helper()
+	reconciliations, err := ledgerRepo.ReconcileUser(userID)
+	assert.NoError(t, err)
+	for _, r := range reconciliations {
+		assert.True(t, r.Consistent(), "stored %s balance %s, ledger balance %s", r.Currency, r.Balance, r.LedgerBalance)
+	}
+
+	unbalanced, err := ledgerRepo.UnbalancedEntries()
+	assert.NoError(t, err)
+	assert.Empty(t, unbalanced)
 }
 
 func TestProcessTransaction_Win_Success(t *testing.T) {
@@ -101,19 +218,23 @@ func TestProcessTransaction_Win_Success(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, "{}", w.Body.String())
-
-	userBalance, err := userRepo.GetByID(userID)
+	var responseBody apihandler.ProcessTransactionResponse
+	err := json.Unmarshal(w.Body.Bytes(), &responseBody)
 	assert.NoError(t, err)
-	assert.True(t, userBalance.Balance.Equal(decimal.NewFromFloat(10.50)))
+	assert.NotZero(t, responseBody.ID)
+	assert.Equal(t, transactionID, responseBody.TransactionID)
+	assert.Equal(t, "10.50", responseBody.BalanceAfter)
+	assert.False(t, responseBody.IdempotentReplay)
+
+	userBalance := walletBalance(t, userID)
+	assert.True(t, userBalance.Equal(decimal.NewFromFloat(10.50)))
 }
 
 func TestProcessTransaction_Lose_Success(t *testing.T) {
 	setupTest(t)
 	userID := testUsers[1]
 	initialBalance := decimal.NewFromFloat(50.00)
-	err := testDB.Model(&user.User{}).Where("id = ?", userID).Update("balance", initialBalance).Error
-	assert.NoError(t, err)
+	setWalletBalance(t, userID, initialBalance)
 
 	amount := "25.75"
 	transactionID := "test-txn-lose-1"
@@ -138,10 +259,9 @@ func TestProcessTransaction_Lose_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	userBalance, err := userRepo.GetByID(userID)
-	assert.NoError(t, err)
+	userBalance := walletBalance(t, userID)
 	expectedBalance := initialBalance.Sub(decimal.NewFromFloat(25.75))
-	assert.True(t, userBalance.Balance.Equal(expectedBalance))
+	assert.True(t, userBalance.Equal(expectedBalance))
 }
 
 func TestProcessTransaction_Lose_InsufficientBalance(t *testing.T) {
@@ -174,9 +294,8 @@ func TestProcessTransaction_Lose_InsufficientBalance(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, responseBody["error"], "insufficient balance")
 	assert.Contains(t, responseBody["error"], "remains 0.00")
-	userBalance, err := userRepo.GetByID(userID)
-	assert.NoError(t, err)
-	assert.True(t, userBalance.Balance.Equal(decimal.Zero))
+	userBalance := walletBalance(t, userID)
+	assert.True(t, userBalance.Equal(decimal.Zero))
 }
 
 func TestProcessTransaction_DuplicateTransactionID(t *testing.T) {
@@ -207,17 +326,15 @@ func TestProcessTransaction_DuplicateTransactionID(t *testing.T) {
 	err := json.Unmarshal(w2.Body.Bytes(), &responseBody)
 	assert.NoError(t, err)
 	assert.Contains(t, responseBody["error"], "transaction with this ID has already been processed")
-	userBalance, err := userRepo.GetByID(userID)
-	assert.NoError(t, err)
-	assert.True(t, userBalance.Balance.Equal(decimal.NewFromFloat(5.00)))
+	userBalance := walletBalance(t, userID)
+	assert.True(t, userBalance.Equal(decimal.NewFromFloat(5.00)))
 }
 
 func TestGetUserBalance_Success(t *testing.T) {
 	setupTest(t)
 	userID := testUsers[0]
 	initialBalance := decimal.NewFromFloat(99.99)
-	err := testDB.Model(&user.User{}).Where("id = ?", userID).Update("balance", initialBalance).Error
-	assert.NoError(t, err)
+	setWalletBalance(t, userID, initialBalance)
 
 	req := httptest.NewRequest(
 		http.MethodGet,
@@ -229,10 +346,163 @@ func TestGetUserBalance_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	var responseBody apihandler.BalanceResponse
-	err = json.Unmarshal(w.Body.Bytes(), &responseBody)
+	err := json.Unmarshal(w.Body.Bytes(), &responseBody)
 	assert.NoError(t, err)
 	assert.Equal(t, userID, responseBody.UserID)
-	assert.Equal(t, "99.99", responseBody.Balance)
+	assert.Equal(t, []apihandler.WalletResponse{{Currency: "EUR", Balance: "99.99", AvailableBalance: "99.99", RealBalance: "99.99", BonusBalance: "0.00", CreditLimit: "0.00"}}, responseBody.Wallets)
+}
+
+func TestProcessTransaction_MultiCurrencyWallets(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	w := postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "10.50", TransactionID: "mc-eur"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "2.125", Currency: "kwd", TransactionID: "mc-kwd"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "500", Currency: "JPY", TransactionID: "mc-jpy"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var jpy apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &jpy))
+	assert.Equal(t, "JPY", jpy.Currency)
+	assert.Equal(t, "500", jpy.BalanceAfter)
+
+	// Each wallet is checked on its own: the JPY wallet cannot cover a loss funded only in EUR.
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "600", Currency: "JPY", TransactionID: "mc-jpy-lose"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Amounts may not be more precise than their currency.
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "1.5", Currency: "JPY", TransactionID: "mc-jpy-fraction"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "1.00", Currency: "ABC", TransactionID: "mc-unknown"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/balance", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var balance apihandler.BalanceResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &balance))
+	assert.Equal(t, []apihandler.WalletResponse{
+		{Currency: "EUR", Balance: "10.50", AvailableBalance: "10.50", RealBalance: "10.50", BonusBalance: "0.00", CreditLimit: "0.00"},
+		{Currency: "JPY", Balance: "500", AvailableBalance: "500", RealBalance: "500", BonusBalance: "0", CreditLimit: "0"},
+		{Currency: "KWD", Balance: "2.125", AvailableBalance: "2.125", RealBalance: "2.125", BonusBalance: "0.000", CreditLimit: "0.000"},
+	}, balance.Wallets)
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/transactions?currency=JPY", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var history apihandler.TransactionHistoryResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Len(t, history.Transactions, 1)
+
+	assertLedgerConsistent(t, userID)
+}
+
+func TestProcessTransaction_ConvertsToWalletCurrency(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	body := apihandler.TransactionRequest{State: "win", Amount: "10.00", Currency: "USD", WalletCurrency: "EUR", TransactionID: "fx-usd-win"}
+	w := postTransaction(userID, "game", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "EUR", response.Currency)
+	assert.Equal(t, "9.14", response.Amount)
+	assert.Equal(t, "USD", response.OriginalCurrency)
+	assert.Equal(t, "10.00", response.OriginalAmount)
+	assert.Equal(t, "0.9137", response.ExchangeRate)
+	assert.NotNil(t, response.RateTimestamp)
+	assert.True(t, walletBalance(t, userID).Equal(decimal.RequireFromString("9.14")))
+
+	// A replay is matched on the amount as sent, not on the converted amount.
+	w = postTransaction(userID, "game", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.IdempotentReplay)
+
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "10.00", Currency: "GBP", WalletCurrency: "EUR", TransactionID: "fx-gbp-win"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assertLedgerConsistent(t, userID)
+}
+
+func TestProcessTransaction_BonusBalance(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[1]
+
+	w := postTransaction(userID, "payment", apihandler.TransactionRequest{State: "win", Amount: "30.00", TransactionID: "bonus-deposit"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postTransaction(userID, "server", apihandler.TransactionRequest{State: "win", Amount: "10.00", Bucket: "bonus", TransactionID: "bonus-grant"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Losses are paid from real money first.
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "35.00", TransactionID: "bonus-lose"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var lose apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &lose))
+	assert.Equal(t, "5.00", lose.BonusAmount)
+
+	// Wins are split in proportion to what is left: nothing real, 5.00 bonus.
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "4.00", TransactionID: "bonus-win"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "1.00", Bucket: "real", TransactionID: "bonus-lose-real"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/balance", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var balance apihandler.BalanceResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &balance))
+	assert.Equal(t, []apihandler.WalletResponse{
+		{Currency: "EUR", Balance: "9.00", AvailableBalance: "9.00", RealBalance: "0.00", BonusBalance: "9.00", CreditLimit: "0.00"},
+	}, balance.Wallets)
+
+	// Reversing the loss restores both sub-balances.
+	req = httptest.NewRequest(http.MethodPost, "/transactions/bonus-lose/reverse", nil)
+	req.Header.Set("Source-Type", "game")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	wallet, err := userRepo.GetWallet(userID, services.DefaultCurrency)
+	assert.NoError(t, err)
+	assert.True(t, wallet.RealBalance().Equal(decimal.NewFromInt(30)), "real balance %s", wallet.RealBalance())
+	assert.True(t, wallet.BonusBalance.Equal(decimal.NewFromInt(14)), "bonus balance %s", wallet.BonusBalance)
+
+	assertLedgerConsistent(t, userID)
+}
+
+func TestProcessTransaction_CreditLimit(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[2]
+	setWalletBalance(t, userID, decimal.NewFromInt(10))
+
+	w := postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "30.00", TransactionID: "credit-lose-1"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	body, _ := json.Marshal(apihandler.SetCreditLimitRequest{CreditLimit: "25.00"})
+	req := newAdminRequest(http.MethodPut, fmt.Sprintf("/admin/user/%d/credit-limit", userID), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var wallet apihandler.WalletResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &wallet))
+	assert.Equal(t, "25.00", wallet.CreditLimit)
+
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "30.00", TransactionID: "credit-lose-2"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, walletBalance(t, userID).Equal(decimal.NewFromInt(-20)))
+
+	// The remaining credit line is 5.00.
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "5.01", TransactionID: "credit-lose-3"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "5.00", TransactionID: "credit-lose-4"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assertLedgerConsistent(t, userID)
 }
 
 func TestProcessTransaction_InvalidUserID(t *testing.T) {
@@ -306,7 +576,7 @@ func TestProcessTransaction_InvalidStateInBody(t *testing.T) {
 	var responseBody map[string]string
 	err := json.Unmarshal(w.Body.Bytes(), &responseBody)
 	assert.NoError(t, err)
-	assert.Contains(t, responseBody["error"], "Error:Field validation for 'State' failed on the 'oneof' tag")
+	assert.Contains(t, responseBody["error"], "Invalid state. Must be one of 'win', 'lose', 'cancel'")
 }
 
 func TestProcessTransaction_InvalidAmountFormat(t *testing.T) {
@@ -370,3 +640,474 @@ func TestProcessTransaction_NegativeOrZeroAmount(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUserTransactions_PaginatesNewestFirst(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	for i := 1; i <= 5; i++ {
+		w := postTransaction(userID, "game", apihandler.TransactionRequest{
+			State:         "win",
+			Amount:        fmt.Sprintf("%d.00", i),
+			TransactionID: fmt.Sprintf("history-txn-%d", i),
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	var seen []string
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		url := fmt.Sprintf("/user/%d/transactions?limit=2", userID)
+		if cursor != "" {
+			url += "&cursor=" + cursor
+		}
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var responseBody apihandler.TransactionHistoryResponse
+		err := json.Unmarshal(w.Body.Bytes(), &responseBody)
+		assert.NoError(t, err)
+		for _, txn := range responseBody.Transactions {
+			seen = append(seen, txn.TransactionID)
+		}
+		cursor = responseBody.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, []string{"history-txn-5", "history-txn-4", "history-txn-3", "history-txn-2", "history-txn-1"}, seen)
+	assert.Empty(t, cursor)
+}
+
+func TestGetUserTransactions_Filters(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[1]
+
+	postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "100.00", TransactionID: "filter-win-game"})
+	postTransaction(userID, "payment", apihandler.TransactionRequest{State: "win", Amount: "5.00", TransactionID: "filter-win-payment"})
+	postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "20.00", TransactionID: "filter-lose-game"})
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"State", "state=lose", []string{"filter-lose-game"}},
+		{"SourceType", "sourceType=payment", []string{"filter-win-payment"}},
+		{"AmountRange", "minAmount=10&maxAmount=50", []string{"filter-lose-game"}},
+		{"Combined", "state=win&sourceType=game", []string{"filter-win-game"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/transactions?%s", userID, tc.query), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var responseBody apihandler.TransactionHistoryResponse
+			err := json.Unmarshal(w.Body.Bytes(), &responseBody)
+			assert.NoError(t, err)
+			var ids []string
+			for _, txn := range responseBody.Transactions {
+				ids = append(ids, txn.TransactionID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestGetUserTransactions_TimeRangeWithOffsets(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[1]
+
+	w := postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "1.00", TransactionID: "offset-win"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var processed apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &processed))
+	at := processed.ProcessedAt
+
+	karachi := time.FixedZone("+05:00", 5*60*60)
+	denver := time.FixedZone("-07:00", -7*60*60)
+	testCases := []struct {
+		name     string
+		from, to time.Time
+		found    bool
+	}{
+		{"Around in other offsets", at.Add(-time.Minute).In(karachi), at.Add(time.Minute).In(denver), true},
+		{"Window ending before", at.Add(-2 * time.Hour).In(denver), at.Add(-time.Hour).In(karachi), false},
+		{"Window starting after", at.Add(time.Hour).In(karachi), at.Add(2 * time.Hour).In(denver), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query := url.Values{"from": {tc.from.Format(time.RFC3339)}, "to": {tc.to.Format(time.RFC3339)}}
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/transactions?%s", userID, query.Encode()), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var history apihandler.TransactionHistoryResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+			if tc.found {
+				assert.Len(t, history.Transactions, 1)
+			} else {
+				assert.Empty(t, history.Transactions)
+			}
+		})
+	}
+}
+
+func TestGetUserTransactions_InvalidFilter(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	for _, query := range []string{"state=draw", "minAmount=abc", "from=yesterday", "cursor=not-a-cursor", "limit=1000"} {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/transactions?%s", userID, query), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "query %s", query)
+	}
+}
+
+func TestGetUserTransactions_UserNotFound(t *testing.T) {
+	setupTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/user/999/transactions", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetTransaction_Success(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	w := postTransaction(userID, "server", apihandler.TransactionRequest{State: "win", Amount: "12.34", TransactionID: "lookup-txn-1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "2.34", TransactionID: "lookup-txn-2"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/transactions/lookup-txn-2", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var responseBody apihandler.TransactionResponse
+	err := json.Unmarshal(w.Body.Bytes(), &responseBody)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, responseBody.UserID)
+	assert.Equal(t, "lookup-txn-2", responseBody.TransactionID)
+	assert.Equal(t, "game", responseBody.SourceType)
+	assert.Equal(t, "lose", responseBody.State)
+	assert.Equal(t, "2.34", responseBody.Amount)
+	assert.Equal(t, "10.00", responseBody.BalanceAfter)
+	assert.False(t, responseBody.ProcessedAt.IsZero())
+}
+
+func TestGetTransaction_NotFound(t *testing.T) {
+	setupTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/transactions/does-not-exist", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetUserTransaction_ScopedToUser(t *testing.T) {
+	setupTest(t)
+
+	w := postTransaction(testUsers[0], "game", apihandler.TransactionRequest{State: "win", Amount: "1.00", TransactionID: "scoped-txn"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/transactions/scoped-txn", testUsers[0]), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/transactions/scoped-txn", testUsers[1]), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProcessTransaction_ReplayReturnsOriginalResult(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	body := apihandler.TransactionRequest{State: "win", Amount: "7.00", TransactionID: "replayed-txn"}
+
+	w1 := postTransaction(userID, "game", body)
+	assert.Equal(t, http.StatusOK, w1.Code)
+	var first apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w1.Body.Bytes(), &first))
+	assert.False(t, first.IdempotentReplay)
+
+	// Change the balance in between so a replay cannot be mistaken for a re-application.
+	w := postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "1.00", TransactionID: "other-txn"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w2 := postTransaction(userID, "game", body)
+	assert.Equal(t, http.StatusOK, w2.Code)
+	var second apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w2.Body.Bytes(), &second))
+	assert.True(t, second.IdempotentReplay)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, "7.00", second.BalanceAfter)
+
+	userBalance := walletBalance(t, userID)
+	assert.True(t, userBalance.Equal(decimal.NewFromFloat(8.00)))
+}
+
+func TestProcessTransaction_ReplayForDifferentUser(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	body := apihandler.TransactionRequest{State: "win", Amount: "3.00", TransactionID: "user-mismatch-txn"}
+
+	w := postTransaction(userID, "game", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postTransaction(testUsers[1], "game", body)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	userBalance := walletBalance(t, testUsers[1])
+	assert.True(t, userBalance.Equal(decimal.Zero))
+}
+
+func TestProcessTransaction_SameTransactionIDFromDifferentSources(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	body := apihandler.TransactionRequest{State: "win", Amount: "3.00", TransactionID: "shared-provider-txn"}
+
+	w := postTransaction(userID, "game", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postTransaction(userID, "payment", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var responseBody apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &responseBody))
+	assert.False(t, responseBody.IdempotentReplay)
+	assert.Equal(t, "payment", responseBody.SourceType)
+
+	userBalance := walletBalance(t, userID)
+	assert.True(t, userBalance.Equal(decimal.NewFromFloat(6.00)))
+
+	// Without a Source-Type the lookup is ambiguous.
+	req := httptest.NewRequest(http.MethodGet, "/transactions/shared-provider-txn", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/transactions/shared-provider-txn", nil)
+	req.Header.Set("Source-Type", "payment")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var lookup apihandler.TransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &lookup))
+	assert.Equal(t, "payment", lookup.SourceType)
+}
+
+func postReversal(transactionID, sourceType string, body *apihandler.ReverseTransactionRequest) *httptest.ResponseRecorder {
+	var payload *bytes.Buffer
+	if body != nil {
+		jsonBody, _ := json.Marshal(body)
+		payload = bytes.NewBuffer(jsonBody)
+	} else {
+		payload = bytes.NewBuffer(nil)
+	}
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/transactions/%s/reverse", transactionID), payload)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Source-Type", sourceType)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestReverseTransaction_Success(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	w := postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "20.00", TransactionID: "reverse-me"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var original apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &original))
+
+	w = postReversal("reverse-me", "game", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var reversal apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &reversal))
+	assert.Equal(t, "cancel", reversal.State)
+	assert.Equal(t, original.ID, *reversal.ReversesID)
+	assert.Equal(t, "0.00", reversal.BalanceAfter)
+	assert.False(t, reversal.IdempotentReplay)
+
+	// Repeating the reversal is idempotent.
+	w = postReversal("reverse-me", "game", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var replay apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &replay))
+	assert.True(t, replay.IdempotentReplay)
+	assert.Equal(t, reversal.ID, replay.ID)
+
+	// A second reversal under a different ID is refused.
+	w = postReversal("reverse-me", "game", &apihandler.ReverseTransactionRequest{TransactionID: "another-reversal"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	userBalance := walletBalance(t, userID)
+	assert.True(t, userBalance.Equal(decimal.Zero))
+}
+
+func TestReverseTransaction_NegativeBalanceRequiresForce(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[1]
+
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "10.00", TransactionID: "forced-win"}).Code)
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "8.00", TransactionID: "forced-lose"}).Code)
+
+	w := postReversal("forced-win", "game", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postReversal("forced-win", "game", &apihandler.ReverseTransactionRequest{Force: true})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	userBalance := walletBalance(t, userID)
+	assert.True(t, userBalance.Equal(decimal.NewFromFloat(-8.00)))
+}
+
+func TestProcessTransaction_CancelState(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[2]
+
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "10.00", TransactionID: "stake-1"}).Code)
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "4.00", TransactionID: "stake-2"}).Code)
+
+	w := postTransaction(userID, "game", apihandler.TransactionRequest{State: "cancel", Amount: "4.00", TransactionID: "rollback-1"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "cancel", Amount: "3.00", TransactionID: "rollback-1", ReferenceTransactionID: "stake-2"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postTransaction(testUsers[0], "game", apihandler.TransactionRequest{State: "cancel", Amount: "4.00", TransactionID: "rollback-1", ReferenceTransactionID: "stake-2"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "cancel", Amount: "4.00", TransactionID: "rollback-1", ReferenceTransactionID: "stake-2"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var responseBody apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &responseBody))
+	assert.Equal(t, "cancel", responseBody.State)
+	assert.Equal(t, "10.00", responseBody.BalanceAfter)
+}
+
+func TestLedger_PostingsTrackBalance(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "30.00", TransactionID: "ledger-win"}).Code)
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "payment", apihandler.TransactionRequest{State: "lose", Amount: "12.50", TransactionID: "ledger-lose"}).Code)
+	assert.Equal(t, http.StatusOK, postReversal("ledger-lose", "payment", nil).Code)
+	assertLedgerConsistent(t, userID)
+
+	var postings []ledger.Posting
+	assert.NoError(t, testDB.Order("id").Find(&postings).Error)
+	assert.Len(t, postings, 6)
+	assert.Equal(t, "house:game:EUR", postings[0].AccountCode)
+	assert.True(t, postings[0].Amount.Equal(decimal.NewFromFloat(-30.00)))
+	assert.Equal(t, "user:1:EUR", postings[1].AccountCode)
+	assert.True(t, postings[1].Amount.Equal(decimal.NewFromFloat(30.00)))
+
+	req := newAdminRequest(http.MethodGet, fmt.Sprintf("/user/%d/balance/verify", userID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var verification apihandler.UserBalanceVerificationResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &verification))
+	assert.True(t, verification.Consistent)
+	assert.Len(t, verification.Wallets, 1)
+	assert.Equal(t, "EUR", verification.Wallets[0].Currency)
+	assert.Equal(t, "30.00", verification.Wallets[0].Balance)
+	assert.Equal(t, "30.00", verification.Wallets[0].LedgerBalance)
+}
+
+func TestLedger_AuditReportsMismatchedBalance(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[1]
+
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "5.00", TransactionID: "audit-win"}).Code)
+	// Simulate an out-of-band edit that bypasses the ledger.
+	setWalletBalance(t, userID, decimal.NewFromInt(7))
+
+	req := newAdminRequest(http.MethodGet, "/ledger/audit", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report apihandler.LedgerAuditResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.False(t, report.Consistent)
+	assert.Empty(t, report.UnbalancedEntries)
+	assert.Len(t, report.MismatchedWallets, 1)
+	assert.Equal(t, userID, report.MismatchedWallets[0].UserID)
+	assert.Equal(t, "EUR", report.MismatchedWallets[0].Currency)
+	assert.Equal(t, "7.00", report.MismatchedWallets[0].Balance)
+	assert.Equal(t, "5.00", report.MismatchedWallets[0].LedgerBalance)
+
+	req = newAdminRequest(http.MethodGet, "/user/999/balance/verify", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func getTransactionChain(userID uint64) (*httptest.ResponseRecorder, apihandler.TransactionChainResponse) {
+	req := newAdminRequest(http.MethodGet, fmt.Sprintf("/user/%d/balance/chain", userID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var responseBody apihandler.TransactionChainResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &responseBody)
+	return w, responseBody
+}
+
+func TestTransactionChain_SnapshotsChain(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "10.00", TransactionID: "chain-1"}).Code)
+	w := postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "3.50", TransactionID: "chain-2"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var lose apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &lose))
+	assert.Equal(t, "10.00", lose.BalanceBefore)
+	assert.Equal(t, "6.50", lose.BalanceAfter)
+	assert.Equal(t, http.StatusOK, postReversal("chain-1", "game", &apihandler.ReverseTransactionRequest{Force: true}).Code)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/transactions", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var history apihandler.TransactionHistoryResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Len(t, history.Transactions, 3)
+	assert.Equal(t, "6.50", history.Transactions[0].BalanceBefore)
+	assert.Equal(t, "-3.50", history.Transactions[0].BalanceAfter)
+
+	w, chain := getTransactionChain(userID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, chain.Consistent)
+	assert.Equal(t, 3, chain.CheckedCount)
+	assert.Len(t, chain.Wallets, 1)
+	assert.Equal(t, "-3.50", chain.Wallets[0].Balance)
+	assert.Empty(t, chain.Breaks)
+
+	// Tampering with a snapshot breaks the chain at the following row.
+	assert.NoError(t, testDB.Exec("UPDATE transactions SET balance_after = 11.00 WHERE transaction_id = 'chain-1'").Error)
+	_, chain = getTransactionChain(userID)
+	assert.False(t, chain.Consistent)
+	assert.Len(t, chain.Breaks, 2)
+	assert.Equal(t, "chain-1", chain.Breaks[0].TransactionID)
+	assert.Equal(t, "chain-2", chain.Breaks[1].TransactionID)
+
+	w, _ = getTransactionChain(999)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,146 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func postHoldRequest(userID uint64, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&payload).Encode(body)
+	}
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/user/%d/holds%s", userID, path), &payload)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Source-Type", "game")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func getWalletResponse(t *testing.T, userID uint64) apihandler.WalletResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/balance", userID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var balance apihandler.BalanceResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &balance))
+	if assert.Len(t, balance.Wallets, 1) {
+		return balance.Wallets[0]
+	}
+	return apihandler.WalletResponse{}
+}
+
+func TestHolds_PlaceCaptureRelease(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	setWalletBalance(t, userID, decimal.NewFromInt(20))
+
+	w := postHoldRequest(userID, "", apihandler.PlaceHoldRequest{HoldID: "bet-1", Amount: "15.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var placed apihandler.PlaceHoldResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &placed))
+	assert.Equal(t, "active", placed.Status)
+
+	wallet := getWalletResponse(t, userID)
+	assert.Equal(t, "20.00", wallet.Balance)
+	assert.Equal(t, "5.00", wallet.AvailableBalance)
+
+	// Held funds can be neither held again nor lost.
+	w = postHoldRequest(userID, "", apihandler.PlaceHoldRequest{HoldID: "bet-2", Amount: "6.00"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "6.00", TransactionID: "hold-lose"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postHoldRequest(userID, "/bet-1/capture", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var captured apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &captured))
+	assert.Equal(t, "lose", captured.State)
+	assert.Equal(t, "capture:bet-1", captured.TransactionID)
+
+	// Capturing again replays the transaction; releasing a captured hold is refused.
+	w = postHoldRequest(userID, "/bet-1/capture", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &captured))
+	assert.True(t, captured.IdempotentReplay)
+	w = postHoldRequest(userID, "/bet-1/release", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	wallet = getWalletResponse(t, userID)
+	assert.Equal(t, "5.00", wallet.Balance)
+	assert.Equal(t, "5.00", wallet.AvailableBalance)
+
+	w = postHoldRequest(userID, "", apihandler.PlaceHoldRequest{HoldID: "bet-3", Amount: "5.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postHoldRequest(userID, "/bet-3/release", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var released apihandler.HoldResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &released))
+	assert.Equal(t, "released", released.Status)
+	w = postHoldRequest(userID, "/bet-3/capture", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	wallet = getWalletResponse(t, userID)
+	assert.Equal(t, "5.00", wallet.AvailableBalance)
+	assertLedgerConsistent(t, userID)
+}
+
+func TestHolds_ExpireAfterTTL(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[1]
+	setWalletBalance(t, userID, decimal.NewFromInt(10))
+
+	w := postHoldRequest(userID, "", apihandler.PlaceHoldRequest{HoldID: "bet-ttl", Amount: "10.00", TTLSeconds: 60})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	service := services.NewTransactionService(userRepo, txnRepo, services.WithHolds(holdRepo, services.DefaultHoldTTL, services.MaxHoldTTL))
+	expired, err := service.ExpireHolds(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, expired)
+	expired, err = service.ExpireHolds(time.Now().Add(2 * time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	wallet := getWalletResponse(t, userID)
+	assert.Equal(t, "10.00", wallet.AvailableBalance)
+	w = postHoldRequest(userID, "/bet-ttl/capture", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestHolds_LossLimits(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	setWalletBalance(t, userID, decimal.NewFromInt(100))
+	w := limitRequest(http.MethodPut, userID, "daily", apihandler.SetLimitRequest{Amount: "20.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postHoldRequest(userID, "", apihandler.PlaceHoldRequest{HoldID: "limit-bet-1", Amount: "21.00"})
+	assert.Equal(t, http.StatusForbidden, w.Code, "a hold over the limit is rejected when it is placed")
+	w = postHoldRequest(userID, "", apihandler.PlaceHoldRequest{HoldID: "limit-bet-2", Amount: "15.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The held amount counts as lost, for further holds and losses alike.
+	w = postHoldRequest(userID, "", apihandler.PlaceHoldRequest{HoldID: "limit-bet-3", Amount: "6.00"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "6.00", TransactionID: "limit-hold-lose-1"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Capturing does not check the limit again; the captured loss keeps counting.
+	w = postHoldRequest(userID, "/limit-bet-2/capture", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "5.00", TransactionID: "limit-hold-lose-2"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "0.01", TransactionID: "limit-hold-lose-3"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,195 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func limitRequest(method string, userID uint64, period string, body interface{}) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&payload).Encode(body)
+	}
+	req := newAdminRequest(method, fmt.Sprintf("/user/%d/limits/%s", userID, period), &payload)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestLossLimits(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	setWalletBalance(t, userID, decimal.NewFromInt(100))
+
+	w := limitRequest(http.MethodPut, userID, "daily", apihandler.SetLimitRequest{Amount: "20.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var l apihandler.LimitResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &l))
+	assert.Equal(t, apihandler.LimitResponse{Kind: "loss", Period: "daily", Currency: "EUR", Amount: "20.00"}, l)
+
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "15.00", TransactionID: "limit-lose-1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "5.01", TransactionID: "limit-lose-2"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var errResp map[string]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, "LIMIT_EXCEEDED", errResp["code"])
+
+	// A reversed loss no longer counts towards the limit.
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "cancel", Amount: "15.00", TransactionID: "limit-cancel-1", ReferenceTransactionID: "limit-lose-1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "20.00", TransactionID: "limit-lose-3"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Raising the limit is only scheduled.
+	w = limitRequest(http.MethodPut, userID, "daily", apihandler.SetLimitRequest{Amount: "50.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &l))
+	assert.Equal(t, "20.00", l.Amount)
+	assert.Equal(t, "50.00", l.PendingAmount)
+	assert.NotNil(t, l.PendingFrom)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "1.00", TransactionID: "limit-lose-4"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = limitRequest(http.MethodDelete, userID, "daily", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &l))
+	assert.True(t, l.PendingRemoval)
+	assert.Empty(t, l.PendingAmount)
+
+	req := newAdminRequest(http.MethodGet, fmt.Sprintf("/user/%d/limits", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var limits apihandler.LimitsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &limits))
+	if assert.Len(t, limits.Limits, 1) {
+		assert.Equal(t, "20.00", limits.Limits[0].Amount)
+		assert.True(t, limits.Limits[0].PendingRemoval)
+	}
+
+	w = limitRequest(http.MethodPut, userID, "yearly", apihandler.SetLimitRequest{Amount: "50.00"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = limitRequest(http.MethodDelete, userID, "weekly", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLossLimits_AtomicBatch(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	setWalletBalance(t, userID, decimal.NewFromInt(100))
+	w := limitRequest(http.MethodPut, userID, "daily", apihandler.SetLimitRequest{Amount: "20.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The losses of earlier items count towards the limit of later ones.
+	w, response := postBatch(t, apihandler.BatchTransactionRequest{Mode: "atomic", Items: []apihandler.BatchTransactionItem{
+		batchTransactionItem(userID, "lose", "15.00", "limit-batch-1"),
+		batchTransactionItem(userID, "lose", "6.00", "limit-batch-2"),
+	}})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, []string{"aborted", "limit_exceeded"}, batchStatuses(response))
+	assert.True(t, decimal.NewFromInt(100).Equal(walletBalance(t, userID)), "nothing is applied")
+}
+
+func TestLossLimits_ConcurrentLossesNeverExceedLimit(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[2]
+	setWalletBalance(t, userID, decimal.NewFromInt(100))
+	w := limitRequest(http.MethodPut, userID, "daily", apihandler.SetLimitRequest{Amount: "10.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded, rejected := 0, 0
+	for i := 0; i < concurrentRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := postTransaction(userID, "game", apihandler.TransactionRequest{
+				State:         "lose",
+				Amount:        "1.00",
+				TransactionID: fmt.Sprintf("limit-concurrent-%d", i),
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch w.Code {
+			case http.StatusOK:
+				succeeded++
+			case http.StatusForbidden:
+				rejected++
+			default:
+				t.Errorf("unexpected status %d for request %d", w.Code, i)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, succeeded)
+	assert.Equal(t, concurrentRequests-10, rejected)
+	assert.True(t, decimal.NewFromInt(90).Equal(walletBalance(t, userID)))
+}
+
+func TestDepositLimits(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	deposit := func(id, amount string) *httptest.ResponseRecorder {
+		return postTransaction(userID, "payment", apihandler.TransactionRequest{State: "deposit", Amount: amount, TransactionID: id})
+	}
+
+	w := limitRequest(http.MethodPut, userID, "weekly", apihandler.SetLimitRequest{Kind: "deposit", Amount: "100.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var l apihandler.LimitResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &l))
+	assert.Equal(t, apihandler.LimitResponse{Kind: "deposit", Period: "weekly", Currency: "EUR", Amount: "100.00"}, l)
+
+	assert.Equal(t, http.StatusOK, deposit("limit-deposit-1", "60.00").Code)
+	w = deposit("limit-deposit-2", "40.01")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var errResp map[string]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, "LIMIT_EXCEEDED", errResp["code"])
+
+	// Deposit limits leave losses alone, and loss limits deposits.
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "50.00", TransactionID: "limit-deposit-lose-1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = limitRequest(http.MethodPut, userID, "weekly", apihandler.SetLimitRequest{Amount: "10.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusOK, deposit("limit-deposit-3", "40.00").Code)
+
+	// Raising is only scheduled, and removing needs the kind.
+	w = limitRequest(http.MethodPut, userID, "weekly", apihandler.SetLimitRequest{Kind: "deposit", Amount: "500.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &l))
+	assert.Equal(t, "100.00", l.Amount)
+	assert.Equal(t, "500.00", l.PendingAmount)
+	assert.Equal(t, http.StatusForbidden, deposit("limit-deposit-4", "0.01").Code)
+
+	req := newAdminRequest(http.MethodDelete, fmt.Sprintf("/user/%d/limits/weekly?kind=deposit", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &l))
+	assert.Equal(t, "deposit", l.Kind)
+	assert.True(t, l.PendingRemoval)
+
+	req = newAdminRequest(http.MethodGet, fmt.Sprintf("/user/%d/limits", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var limits apihandler.LimitsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &limits))
+	assert.Len(t, limits.Limits, 2)
+
+	w = limitRequest(http.MethodPut, userID, "weekly", apihandler.SetLimitRequest{Kind: "wager", Amount: "10.00"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
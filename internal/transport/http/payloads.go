@@ -1,16 +1,434 @@
 package http
 
+import "time"
+
 // TransactionRequest represents the incoming JSON payload for a transaction.
 // @Description Details for a new transaction to update user balance.
 type TransactionRequest struct {
-	State         string `json:"state" binding:"required,oneof=win lose"`
-	Amount        string `json:"amount" binding:"required,decimal_2_places"`
+	// State is the transaction type: win, lose, cancel, deposit, withdrawal, bonus_grant,
+	// adjustment, fee or refund. Each type is only accepted from some Source-Types.
+	State         string `json:"state" binding:"required,transaction_type"`
+	Amount        string `json:"amount" binding:"required,decimal_amount"`
 	TransactionID string `json:"transactionId" binding:"required"`
+	Currency      string `json:"currency,omitempty"` // ISO 4217 code of the amount; the configured default currency when omitted
+	// WalletCurrency selects the wallet when it differs from the amount's currency; the amount is
+	// then converted at the current exchange rate. Defaults to the amount's currency.
+	WalletCurrency string `json:"walletCurrency,omitempty"`
+	// Bucket books the whole amount on the "real" or "bonus" balance. When omitted, losses are paid
+	// in the configured debit order and wins are split in proportion to the current balances.
+	Bucket string `json:"bucket,omitempty" binding:"omitempty,oneof=real bonus"`
+	// RoundID links the stakes and payouts of one game round; GameID optionally names the game.
+	RoundID string `json:"roundId,omitempty" binding:"max=255"`
+	GameID  string `json:"gameId,omitempty" binding:"max=255"`
+
+	ReferenceTransactionID string `json:"referenceTransactionId,omitempty"` // Required for "cancel": the transaction being rolled back
+}
+
+// BatchTransactionRequest represents the JSON payload of a batch of transactions.
+// @Description Transactions processed in one request, in order.
+type BatchTransactionRequest struct {
+	Mode  string                 `json:"mode,omitempty" binding:"omitempty,oneof=atomic best_effort"` // best_effort when omitted
+	Items []BatchTransactionItem `json:"items" binding:"required,min=1,max=500,dive"`
+}
+
+// BatchTransactionItem is one transaction of a batch: a transaction request for the given user.
+type BatchTransactionItem struct {
+	UserID uint64 `json:"userId" binding:"required"`
+	TransactionRequest
+}
+
+// TransferRequest represents the JSON payload for moving funds between users.
+// @Description Details of a transfer of real money from one user to another.
+type TransferRequest struct {
+	TransferID string `json:"transferId" binding:"required,max=200"` // Idempotency key of the transfer
+	FromUserID uint64 `json:"fromUserId" binding:"required"`
+	ToUserID   uint64 `json:"toUserId" binding:"required"`
+	Amount     string `json:"amount" binding:"required,decimal_amount"`
+	Currency   string `json:"currency,omitempty"`                 // ISO 4217 code; the configured default currency when omitted
+	Reason     string `json:"reason,omitempty" binding:"max=255"` // E.g. "affiliate payout"
+}
+
+// ReverseTransactionRequest represents the optional JSON payload for reversing a transaction.
+// @Description Options for reversing a transaction.
+type ReverseTransactionRequest struct {
+	// TransactionID is the idempotency key of the reversal; derived from the original transaction when omitted.
+	TransactionID string `json:"transactionId,omitempty"`
+	// Force allows the reversal even if it makes the balance negative.
+	Force bool `json:"force,omitempty"`
+}
+
+// CreateUserRequest represents the optional JSON payload for creating a user.
+// @Description Details of a new user; every field is optional.
+type CreateUserRequest struct {
+	ExternalRef string     `json:"externalRef,omitempty"`                                                  // Reference in the operator's own systems, unique if set
+	Currency    string     `json:"currency,omitempty"`                                                     // ISO 4217 code of the wallet to open; the configured default currency when omitted
+	Status      string     `json:"status,omitempty" binding:"omitempty,oneof=active frozen self_excluded"` // Initial account status; active when omitted
+	Until       *time.Time `json:"until,omitempty"`                                                        // End of the self-exclusion when status is self_excluded
+}
+
+// UserResponse represents a user.
+// @Description Account details of a user; wallets are only included for a single user.
+type UserResponse struct {
+	UserID            uint64           `json:"userId"`
+	ExternalRef       string           `json:"externalRef,omitempty"`
+	Status            string           `json:"status"`
+	SelfExcludedUntil *time.Time       `json:"selfExcludedUntil,omitempty"`
+	CreatedAt         time.Time        `json:"createdAt"`
+	Wallets           []WalletResponse `json:"wallets,omitempty"`
+}
+
+// LinkExternalIDRequest represents the JSON payload for mapping a provider's player ID to a user.
+type LinkExternalIDRequest struct {
+	Provider   string `json:"provider" binding:"required"`   // Lowercase provider name
+	ExternalID string `json:"externalId" binding:"required"` // The provider's ID of the player
+}
+
+// ExternalIDResponse represents a provider's player ID mapped to a user.
+type ExternalIDResponse struct {
+	Provider   string    `json:"provider"`
+	ExternalID string    `json:"externalId"`
+	UserID     uint64    `json:"userId"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ExternalIDsResponse lists the player IDs mapped to a user.
+type ExternalIDsResponse struct {
+	UserID      uint64               `json:"userId"`
+	ExternalIDs []ExternalIDResponse `json:"externalIds"`
+}
+
+// UserListResponse represents one page of users.
+// @Description A page of users ordered by ID. Pass nextCursor as the cursor query parameter to fetch the next page.
+type UserListResponse struct {
+	Users      []UserResponse `json:"users"`
+	NextCursor string         `json:"nextCursor,omitempty"`
 }
 
 // BalanceResponse represents the JSON payload for getting user balance.
-// @Description Current user balance information.
+// @Description Current balance of every wallet of the user, ordered by currency.
 type BalanceResponse struct {
-	UserID  uint64 `json:"userId"`
-	Balance string `json:"balance"`
+	UserID  uint64           `json:"userId"`
+	Wallets []WalletResponse `json:"wallets"`
+}
+
+// WalletResponse represents the balance of one wallet.
+type WalletResponse struct {
+	Currency         string `json:"currency"`
+	Balance          string `json:"balance"`          // Total, formatted with the currency's number of decimal places
+	AvailableBalance string `json:"availableBalance"` // Part of balance not reserved by active holds
+	RealBalance      string `json:"realBalance"`      // Part of balance that is real money
+	BonusBalance     string `json:"bonusBalance"`     // Part of balance that is bonus money
+	CreditLimit      string `json:"creditLimit"`      // How far the balance may be drawn below zero
+}
+
+// SetCreditLimitRequest represents the JSON payload for setting a wallet's credit limit.
+// @Description The credit limit of one wallet; 0 restores the default of not allowing a negative balance.
+type SetCreditLimitRequest struct {
+	Currency    string `json:"currency,omitempty"` // ISO 4217 code of the wallet; the configured default currency when omitted
+	CreditLimit string `json:"creditLimit" binding:"required,decimal_amount"`
+}
+
+// SetUserStatusRequest represents the JSON payload for changing an account status.
+// @Description The new account status and why it is changed.
+type SetUserStatusRequest struct {
+	Status string     `json:"status" binding:"required,oneof=active frozen self_excluded closed"`
+	Until  *time.Time `json:"until,omitempty"`           // End of a self-exclusion; required for, and only allowed with, self_excluded
+	Reason string     `json:"reason" binding:"required"` // Recorded in the audit trail
+}
+
+// UserStatusResponse represents the account status of a user.
+// @Description The current account status and the audit trail of its changes, oldest first.
+type UserStatusResponse struct {
+	UserID            uint64                 `json:"userId"`
+	Status            string                 `json:"status"`                      // "active", "frozen", "self_excluded" or "closed"
+	SelfExcludedUntil *time.Time             `json:"selfExcludedUntil,omitempty"` // Set while self-excluded
+	History           []StatusChangeResponse `json:"history"`
+}
+
+// StatusChangeResponse represents one entry of the account status audit trail.
+type StatusChangeResponse struct {
+	FromStatus        string     `json:"fromStatus"`
+	ToStatus          string     `json:"toStatus"`
+	SelfExcludedUntil *time.Time `json:"selfExcludedUntil,omitempty"`
+	Reason            string     `json:"reason"`
+	ChangedAt         time.Time  `json:"changedAt"`
+}
+
+// PlaceHoldRequest represents the JSON payload for reserving funds.
+// @Description Details of a new hold.
+type PlaceHoldRequest struct {
+	HoldID     string `json:"holdId" binding:"required"` // Idempotency key, unique per Source-Type
+	Amount     string `json:"amount" binding:"required,decimal_amount"`
+	Currency   string `json:"currency,omitempty"`                             // ISO 4217 code; the configured default currency when omitted
+	TTLSeconds int64  `json:"ttlSeconds,omitempty" binding:"omitempty,min=1"` // Lifetime of the hold; the configured default when omitted
+}
+
+// CaptureHoldRequest represents the optional JSON payload for capturing a hold.
+// @Description Options for capturing a hold.
+type CaptureHoldRequest struct {
+	// TransactionID is the idempotency key of the resulting 'lose' transaction; derived from the hold when omitted.
+	TransactionID string `json:"transactionId,omitempty"`
+}
+
+// HoldResponse represents a stored hold.
+// @Description A reservation of funds; only active holds reduce the available balance.
+type HoldResponse struct {
+	ID            uint64    `json:"id"`
+	HoldID        string    `json:"holdId"`
+	UserID        uint64    `json:"userId"`
+	SourceType    string    `json:"sourceType"`
+	Currency      string    `json:"currency"`
+	Amount        string    `json:"amount"`
+	Status        string    `json:"status"` // "active", "captured", "released" or "expired"
+	ExpiresAt     time.Time `json:"expiresAt"`
+	TransactionID *uint64   `json:"transactionId,omitempty"` // Internal ID of the 'lose' transaction that captured the hold
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// PlaceHoldResponse is returned when a hold is placed.
+type PlaceHoldResponse struct {
+	HoldResponse
+	IdempotentReplay bool `json:"idempotentReplay"` // True when the holdId had already been placed and nothing was changed
+}
+
+// SetLimitRequest represents the JSON payload for setting a loss or deposit limit.
+// @Description The maximum a user may lose or deposit in one currency over the period's rolling window.
+type SetLimitRequest struct {
+	Kind     string `json:"kind,omitempty"`     // "loss" (the default) or "deposit"
+	Currency string `json:"currency,omitempty"` // ISO 4217 code; the configured default currency when omitted
+	Amount   string `json:"amount" binding:"required,decimal_amount"`
+}
+
+// LimitResponse represents a responsible-gaming loss or deposit limit.
+// @Description A limit in force, with any scheduled raise or removal.
+type LimitResponse struct {
+	Kind     string `json:"kind"`   // "loss" or "deposit"
+	Period   string `json:"period"` // "daily", "weekly" or "monthly"
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"` // The limit in force now
+
+	// Set while a raise or removal waits out its cooling-off period.
+	PendingAmount  string     `json:"pendingAmount,omitempty"`
+	PendingRemoval bool       `json:"pendingRemoval,omitempty"`
+	PendingFrom    *time.Time `json:"pendingFrom,omitempty"`
+}
+
+// LimitsResponse represents all loss and deposit limits of a user.
+type LimitsResponse struct {
+	UserID uint64          `json:"userId"`
+	Limits []LimitResponse `json:"limits"`
+}
+
+// TransactionResponse represents a stored transaction.
+// @Description A processed transaction record.
+type TransactionResponse struct {
+	ID            uint64    `json:"id"`
+	ReversesID    *uint64   `json:"reversesId,omitempty"` // For 'cancel' transactions, the internal ID of the reversed transaction
+	TransferID    *uint64   `json:"transferId,omitempty"` // For 'debit' and 'credit' transactions, the internal ID of the transfer
+	RoundID       string    `json:"roundId,omitempty"`    // Game round the transaction belongs to, if any
+	GameID        string    `json:"gameId,omitempty"`
+	UserID        uint64    `json:"userId"`
+	TransactionID string    `json:"transactionId"`
+	SourceType    string    `json:"sourceType"`
+	State         string    `json:"state"`
+	Currency      string    `json:"currency"`
+	Amount        string    `json:"amount"`
+	BalanceBefore string    `json:"balanceBefore,omitempty"` // Empty for transactions recorded before balances were tracked
+	BalanceAfter  string    `json:"balanceAfter,omitempty"`  // Empty for transactions recorded before balances were tracked
+	ProcessedAt   time.Time `json:"processedAt"`
+	Bucket        string    `json:"bucket,omitempty"` // Sub-balance the request booked the whole amount on, if it named one
+	BonusAmount   string    `json:"bonusAmount"`      // Part of amount booked on the bonus balance
+
+	// Set only for transactions converted from another currency; amount is in the wallet currency.
+	OriginalAmount   string     `json:"originalAmount,omitempty"`
+	OriginalCurrency string     `json:"originalCurrency,omitempty"`
+	ExchangeRate     string     `json:"exchangeRate,omitempty"`
+	RateTimestamp    *time.Time `json:"rateTimestamp,omitempty"`
+}
+
+// TransactionHistoryResponse represents one page of a user's transaction history.
+// @Description A page of transactions, newest first. Pass nextCursor as the cursor query parameter to fetch the next page.
+type TransactionHistoryResponse struct {
+	Transactions []TransactionResponse `json:"transactions"`
+	NextCursor   string                `json:"nextCursor,omitempty"`
+}
+
+// ProcessTransactionResponse represents the result of processing a transaction.
+// @Description The processed transaction, including the resulting balance.
+type ProcessTransactionResponse struct {
+	TransactionResponse
+	IdempotentReplay bool `json:"idempotentReplay"` // True when the transactionId had already been processed and nothing was changed
+}
+
+// RoundResponse represents a game round and its transactions.
+// @Description A game round: stakes open it, a payout settles it and a reversal cancels it.
+type RoundResponse struct {
+	ID           uint64                `json:"id"`
+	RoundID      string                `json:"roundId"`
+	SourceType   string                `json:"sourceType"`
+	UserID       uint64                `json:"userId"`
+	GameID       string                `json:"gameId,omitempty"`
+	Currency     string                `json:"currency"`
+	Status       string                `json:"status"`       // "open", "settled" or "cancelled"
+	StakeAmount  string                `json:"stakeAmount"`  // Sum of the round's 'lose' transactions
+	PayoutAmount string                `json:"payoutAmount"` // Sum of the round's 'win' transactions
+	CreatedAt    time.Time             `json:"createdAt"`
+	UpdatedAt    time.Time             `json:"updatedAt"`
+	Transactions []TransactionResponse `json:"transactions"` // In the order they were applied
+}
+
+// TransferResponse represents a transfer and the transactions recording its legs.
+// @Description A transfer; its debit and credit legs are transactions of Source-Type 'transfer' whose transferId is the transfer's id.
+type TransferResponse struct {
+	ID               uint64              `json:"id"`
+	TransferID       string              `json:"transferId"`
+	FromUserID       uint64              `json:"fromUserId"`
+	ToUserID         uint64              `json:"toUserId"`
+	Currency         string              `json:"currency"`
+	Amount           string              `json:"amount"`
+	Reason           string              `json:"reason,omitempty"`
+	CreatedAt        time.Time           `json:"createdAt"`
+	Debit            TransactionResponse `json:"debit"`
+	Credit           TransactionResponse `json:"credit"`
+	IdempotentReplay bool                `json:"idempotentReplay"` // True when the transferId had already been processed and nothing was changed
+}
+
+// BatchTransactionResponse represents the results of a batch of transactions.
+// @Description One result per item, in request order.
+type BatchTransactionResponse struct {
+	Mode      string              `json:"mode"`
+	Succeeded int                 `json:"succeeded"` // Items processed or replayed
+	Failed    int                 `json:"failed"`    // Items that failed or, in atomic mode, were aborted
+	Results   []BatchItemResponse `json:"results"`
+}
+
+// BatchItemResponse represents the result of one item of a batch.
+type BatchItemResponse struct {
+	Index         int    `json:"index"`
+	UserID        uint64 `json:"userId"`
+	TransactionID string `json:"transactionId"`
+	// Status is processed, replayed, insufficient_balance, not_found, rejected, conflict,
+	// limit_exceeded, account_restricted, aborted or failed.
+	Status      string               `json:"status"`
+	Error       string               `json:"error,omitempty"`
+	Transaction *TransactionResponse `json:"transaction,omitempty"` // The stored transaction of processed and replayed items
+}
+
+// UserBalanceVerificationResponse represents the comparison of all of a user's wallets with the ledger.
+// @Description The result for each wallet; consistent is true if every wallet matches its ledger account.
+type UserBalanceVerificationResponse struct {
+	UserID     uint64                        `json:"userId"`
+	Consistent bool                          `json:"consistent"`
+	Wallets    []BalanceVerificationResponse `json:"wallets"`
+}
+
+// BalanceVerificationResponse represents the comparison of a wallet's balance with the ledger.
+// @Description The stored balance next to the sum of the postings on the wallet's ledger account.
+type BalanceVerificationResponse struct {
+	UserID        uint64 `json:"userId"`
+	Currency      string `json:"currency"`
+	Balance       string `json:"balance"`
+	LedgerBalance string `json:"ledgerBalance"`
+	Consistent    bool   `json:"consistent"`
+}
+
+// UnbalancedEntryResponse represents a journal entry whose postings do not sum to zero.
+type UnbalancedEntryResponse struct {
+	JournalEntryID uint64 `json:"journalEntryId"`
+	Sum            string `json:"sum"`
+}
+
+// LedgerAuditResponse represents the result of a full ledger audit.
+// @Description Discrepancies found in the ledger; both lists are empty when the ledger is consistent.
+type LedgerAuditResponse struct {
+	Consistent        bool                          `json:"consistent"`
+	UnbalancedEntries []UnbalancedEntryResponse     `json:"unbalancedEntries"`
+	MismatchedWallets []BalanceVerificationResponse `json:"mismatchedWallets"`
+}
+
+// ChainBreakResponse represents a transaction whose balance snapshot does not follow from its predecessor.
+type ChainBreakResponse struct {
+	ID            uint64 `json:"id"`
+	TransactionID string `json:"transactionId"`
+	Currency      string `json:"currency"`
+	Reason        string `json:"reason"`
+}
+
+// WalletChainResponse compares the end of a wallet's chain with its balance.
+type WalletChainResponse struct {
+	Currency         string `json:"currency"`
+	Consistent       bool   `json:"consistent"`
+	Balance          string `json:"balance,omitempty"`          // Empty if the balance kept changing during the check
+	LastBalanceAfter string `json:"lastBalanceAfter,omitempty"` // Empty if the latest transaction has no snapshot
+}
+
+// TransactionChainResponse represents the result of checking a user's balance timeline.
+// @Description Whether, per wallet, every transaction's balanceBefore equals the previous transaction's balanceAfter and the last one matches the current balance.
+type TransactionChainResponse struct {
+	UserID       uint64                `json:"userId"`
+	Consistent   bool                  `json:"consistent"`
+	CheckedCount int                   `json:"checkedCount"` // Transactions with balance snapshots that were checked
+	Wallets      []WalletChainResponse `json:"wallets"`
+	Breaks       []ChainBreakResponse  `json:"breaks"`
+}
+
+// SourceTypeRequest represents the JSON payload for onboarding or reconfiguring a Source-Type.
+// @Description The settings of a Source-Type; they replace the current settings as a whole.
+type SourceTypeRequest struct {
+	Enabled           *bool    `json:"enabled" binding:"required"`                             // Transactions and holds of disabled sources are rejected
+	States            []string `json:"states" binding:"required,dive,transaction_type"`        // Transaction types the source may send
+	MaxAmount         string   `json:"maxAmount,omitempty" binding:"omitempty,decimal_amount"` // Cap on a single transaction's amount; no cap when omitted
+	MaxAmountCurrency string   `json:"maxAmountCurrency,omitempty"`                            // ISO 4217 code of maxAmount, to which other currencies are converted; the configured default currency when omitted
+	CheckBalance      *bool    `json:"checkBalance" binding:"required"`                        // Whether debits that overdraw the balance are rejected
+}
+
+// SourceTypeResponse represents a Source-Type and its settings.
+type SourceTypeResponse struct {
+	Name              string    `json:"name"`
+	Enabled           bool      `json:"enabled"`
+	States            []string  `json:"states"`
+	MaxAmount         string    `json:"maxAmount,omitempty"`         // Empty if amounts are not capped
+	MaxAmountCurrency string    `json:"maxAmountCurrency,omitempty"` // ISO 4217 code of maxAmount
+	CheckBalance      bool      `json:"checkBalance"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// SourceTypesResponse lists all Source-Types.
+type SourceTypesResponse struct {
+	SourceTypes []SourceTypeResponse `json:"sourceTypes"`
+}
+
+// ProviderRequest represents the JSON payload for onboarding or reconfiguring a provider.
+// @Description The settings of a provider; they replace the current settings as a whole.
+type ProviderRequest struct {
+	Enabled     *bool    `json:"enabled" binding:"required"`     // Requests of disabled providers are rejected
+	SourceTypes []string `json:"sourceTypes" binding:"required"` // Registered Source-Types the provider may send requests with
+}
+
+// ProviderKeyResponse represents an active signing key of a provider, without its secret.
+type ProviderKeyResponse struct {
+	KeyID     string    `json:"keyId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ProviderResponse represents a provider, its settings and its active keys.
+type ProviderResponse struct {
+	Name        string                `json:"name"`
+	Enabled     bool                  `json:"enabled"`
+	SourceTypes []string              `json:"sourceTypes"`
+	Keys        []ProviderKeyResponse `json:"keys,omitempty"` // Oldest first, at most two; only returned for a single provider
+	UpdatedAt   time.Time             `json:"updatedAt"`
+}
+
+// ProvidersResponse lists all providers.
+type ProvidersResponse struct {
+	Providers []ProviderResponse `json:"providers"`
+}
+
+// ProviderKeyCreatedResponse represents a newly created signing key.
+// @Description The new key with its secret, which is only returned once. The provider signs requests with the secret and sends the key ID.
+type ProviderKeyCreatedResponse struct {
+	KeyID     string    `json:"keyId"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"createdAt"`
 }
@@ -0,0 +1,229 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/domain/provider"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func saveProvider(t *testing.T, name string, sourceTypes ...string) {
+	t.Helper()
+	enabled := true
+	jsonBody, _ := json.Marshal(apihandler.ProviderRequest{Enabled: &enabled, SourceTypes: sourceTypes})
+	req := newAdminRequest(http.MethodPut, "/admin/providers/"+name, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func rotateProviderKey(t *testing.T, name string) apihandler.ProviderKeyCreatedResponse {
+	t.Helper()
+	req := newAdminRequest(http.MethodPost, "/admin/providers/"+name+"/keys", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var key apihandler.ProviderKeyCreatedResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &key))
+	return key
+}
+
+// postSigned posts body to path with the Source-Type, signed with the key at the given time.
+func postSigned(r *gin.Engine, path, sourceType string, key apihandler.ProviderKeyCreatedResponse, at time.Time, body any) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Source-Type", sourceType)
+	req.Header.Set("X-Provider-Key", key.KeyID)
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("X-Signature", provider.Sign(key.Secret, http.MethodPost, path, timestamp, jsonBody))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// getSigned gets path, signed with the key at the current time.
+func getSigned(r *gin.Engine, path string, key apihandler.ProviderKeyCreatedResponse) *httptest.ResponseRecorder {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("X-Provider-Key", key.KeyID)
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("X-Signature", provider.Sign(key.Secret, http.MethodGet, path, timestamp, nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestProviderAuth_SignedRequests(t *testing.T) {
+	setupTest(t)
+	authRouter := newTestRouter(testHandler, true)
+	path := fmt.Sprintf("/user/%d/transaction", testUsers[0])
+	win := func(id string) apihandler.TransactionRequest {
+		return apihandler.TransactionRequest{State: "win", Amount: "1.00", TransactionID: id}
+	}
+
+	saveProvider(t, "acme", "game")
+	key := rotateProviderKey(t, "acme")
+
+	signedAt := time.Now()
+	assert.Equal(t, http.StatusOK, postSigned(authRouter, path, "game", key, signedAt, win("signed-1")).Code)
+	w := postSigned(authRouter, path, "game", key, signedAt, win("signed-1"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "a signed request is accepted once")
+	w = postSigned(authRouter, "/provider/acme/player/p-1/transaction", "game", key, time.Now(), win("player-1"))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestProviderAuth_RejectedRequests(t *testing.T) {
+	setupTest(t)
+	authRouter := newTestRouter(testHandler, true)
+	path := fmt.Sprintf("/user/%d/transaction", testUsers[0])
+	win := func(id string) apihandler.TransactionRequest {
+		return apihandler.TransactionRequest{State: "win", Amount: "1.00", TransactionID: id}
+	}
+
+	saveProvider(t, "acme", "game")
+	saveProvider(t, "other", "game")
+	key := rotateProviderKey(t, "acme")
+	otherKey := rotateProviderKey(t, "other")
+	forged := key
+	forged.Secret = "not-the-secret"
+	unknown := key
+	unknown.KeyID = "pk_unknown"
+	wrongKey := key
+	wrongKey.KeyID = otherKey.KeyID
+
+	tests := []struct {
+		name       string
+		send       func(id string) *httptest.ResponseRecorder
+		wantStatus int
+	}{
+		{"unsigned", func(id string) *httptest.ResponseRecorder {
+			return postTransactionTo(authRouter, testUsers[0], "game", win(id))
+		}, http.StatusUnauthorized},
+		{"stale timestamp", func(id string) *httptest.ResponseRecorder {
+			return postSigned(authRouter, path, "game", key, time.Now().Add(-time.Hour), win(id))
+		}, http.StatusUnauthorized},
+		{"future timestamp", func(id string) *httptest.ResponseRecorder {
+			return postSigned(authRouter, path, "game", key, time.Now().Add(time.Hour), win(id))
+		}, http.StatusUnauthorized},
+		{"wrong secret", func(id string) *httptest.ResponseRecorder {
+			return postSigned(authRouter, path, "game", forged, time.Now(), win(id))
+		}, http.StatusUnauthorized},
+		{"unknown key", func(id string) *httptest.ResponseRecorder {
+			return postSigned(authRouter, path, "game", unknown, time.Now(), win(id))
+		}, http.StatusUnauthorized},
+		{"key of another provider", func(id string) *httptest.ResponseRecorder {
+			return postSigned(authRouter, path, "game", wrongKey, time.Now(), win(id))
+		}, http.StatusUnauthorized},
+		{"wrong Source-Type", func(id string) *httptest.ResponseRecorder {
+			return postSigned(authRouter, path, "payment", key, time.Now(), win(id))
+		}, http.StatusForbidden},
+		{"another provider's players", func(id string) *httptest.ResponseRecorder {
+			return postSigned(authRouter, "/provider/other/player/p-1/transaction", "game", key, time.Now(), win(id))
+		}, http.StatusForbidden},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.send(fmt.Sprintf("rejected-%d", i))
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+		})
+	}
+	assert.True(t, walletBalance(t, testUsers[0]).IsZero(), "no rejected request changed the balance")
+}
+
+func TestAdminAuth(t *testing.T) {
+	setupTest(t)
+
+	for _, adminKey := range []string{"", "not-the-admin-key"} {
+		for _, path := range []string{"/admin/providers/acme/keys", "/users", "/transfers"} {
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			req.Header.Set("X-Admin-Key", adminKey)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "POST %s with admin key %q", path, adminKey)
+		}
+	}
+}
+
+func TestProviderAuth_KeyRotation(t *testing.T) {
+	setupTest(t)
+	authRouter := newTestRouter(testHandler, true)
+	path := fmt.Sprintf("/user/%d/transaction", testUsers[0])
+	win := func(id string) apihandler.TransactionRequest {
+		return apihandler.TransactionRequest{State: "win", Amount: "1.00", TransactionID: id}
+	}
+
+	saveProvider(t, "acme", "game")
+	first := rotateProviderKey(t, "acme")
+	second := rotateProviderKey(t, "acme")
+
+	// Both keys are active while the provider switches.
+	assert.Equal(t, http.StatusOK, postSigned(authRouter, path, "game", first, time.Now(), win("rotation-1")).Code)
+	assert.Equal(t, http.StatusOK, postSigned(authRouter, path, "game", second, time.Now(), win("rotation-2")).Code)
+
+	// A third key retires the first.
+	third := rotateProviderKey(t, "acme")
+	assert.Equal(t, http.StatusUnauthorized, postSigned(authRouter, path, "game", first, time.Now(), win("rotation-3")).Code)
+	assert.Equal(t, http.StatusOK, postSigned(authRouter, path, "game", third, time.Now(), win("rotation-4")).Code)
+
+	req := newAdminRequest(http.MethodDelete, "/admin/providers/acme/keys/"+second.KeyID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, postSigned(authRouter, path, "game", second, time.Now(), win("rotation-5")).Code)
+
+	req = newAdminRequest(http.MethodGet, "/admin/providers/acme", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response apihandler.ProviderResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Keys, 1) {
+		assert.Equal(t, third.KeyID, response.Keys[0].KeyID)
+	}
+	assert.NotContains(t, w.Body.String(), third.Secret)
+}
+
+func TestProviderAuth_ScopedLookups(t *testing.T) {
+	setupTest(t)
+	authRouter := newTestRouter(testHandler, true)
+	served, unserved := testUsers[0], testUsers[1]
+
+	saveProvider(t, "acme", "game")
+	key := rotateProviderKey(t, "acme")
+	w := postSigned(authRouter, fmt.Sprintf("/user/%d/transaction", served), "game", key, time.Now(),
+		apihandler.TransactionRequest{State: "win", Amount: "1.00", TransactionID: "acme-1"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = postTransactionTo(router, served, "server", apihandler.TransactionRequest{State: "win", Amount: "2.00", TransactionID: "server-1"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = postSigned(authRouter, fmt.Sprintf("/user/%d/transaction", served), "", key, time.Now(),
+		apihandler.TransactionRequest{State: "win", Amount: "1.00", TransactionID: "acme-2"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "requests that move money must name their Source-Type")
+
+	w = getSigned(authRouter, fmt.Sprintf("/user/%d/transactions", served), key)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var history apihandler.TransactionHistoryResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	if assert.Len(t, history.Transactions, 1, "only the provider's transactions are listed") {
+		assert.Equal(t, "acme-1", history.Transactions[0].TransactionID)
+	}
+	assert.Equal(t, http.StatusNotFound, getSigned(authRouter, "/transactions/server-1", key).Code)
+	assert.Equal(t, http.StatusNotFound, getSigned(authRouter, fmt.Sprintf("/user/%d/transactions/server-1", served), key).Code)
+	assert.Equal(t, http.StatusOK, getSigned(authRouter, "/transactions/acme-1", key).Code)
+
+	assert.Equal(t, http.StatusOK, getSigned(authRouter, fmt.Sprintf("/user/%d/balance", served), key).Code)
+	assert.Equal(t, http.StatusNotFound, getSigned(authRouter, fmt.Sprintf("/user/%d/balance", unserved), key).Code,
+		"providers only see the balances of their own users")
+}
@@ -0,0 +1,79 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func getRound(t *testing.T, roundID string) (*httptest.ResponseRecorder, apihandler.RoundResponse) {
+	req := httptest.NewRequest(http.MethodGet, "/rounds/"+roundID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response apihandler.RoundResponse
+	if w.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	}
+	return w, response
+}
+
+func TestProcessTransaction_Round(t *testing.T) {
+	setupTest(t)
+	setWalletBalance(t, testUsers[0], decimal.NewFromInt(20))
+
+	w := postTransaction(testUsers[0], "game", apihandler.TransactionRequest{State: "lose", Amount: "5.00", TransactionID: "stake-1", RoundID: "round-1", GameID: "slots"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w, response := getRound(t, "round-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "open", response.Status)
+
+	w = postTransaction(testUsers[0], "game", apihandler.TransactionRequest{State: "win", Amount: "12.00", TransactionID: "payout-1", RoundID: "round-1"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w, response = getRound(t, "round-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "settled", response.Status)
+	assert.Equal(t, "slots", response.GameID)
+	assert.Equal(t, "5.00", response.StakeAmount)
+	assert.Equal(t, "12.00", response.PayoutAmount)
+	if assert.Len(t, response.Transactions, 2) {
+		assert.Equal(t, "stake-1", response.Transactions[0].TransactionID)
+		assert.Equal(t, "round-1", response.Transactions[1].RoundID)
+	}
+
+	// A round belongs to one user.
+	w = postTransaction(testUsers[1], "game", apihandler.TransactionRequest{State: "win", Amount: "1.00", TransactionID: "payout-2", RoundID: "round-1"})
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	w, _ = getRound(t, "round-2")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assertLedgerConsistent(t, testUsers[0])
+}
+
+func TestProcessTransaction_StrictRounds(t *testing.T) {
+	setupTest(t)
+	setWalletBalance(t, testUsers[0], decimal.NewFromInt(20))
+
+	strictService := services.NewTransactionService(userRepo, txnRepo, services.WithRounds(roundRepo, true))
+	strictRouter := newTestRouter(apihandler.NewHandler(strictService, services.NewLedgerService(ledgerRepo)), false)
+
+	w := postTransactionTo(strictRouter, testUsers[0], "game", apihandler.TransactionRequest{State: "win", Amount: "3.00", TransactionID: "orphan-payout", RoundID: "round-1"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a payout needs a stake first")
+
+	w = postTransactionTo(strictRouter, testUsers[0], "game", apihandler.TransactionRequest{State: "lose", Amount: "2.00", TransactionID: "stake-1", RoundID: "round-1"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = postTransactionTo(strictRouter, testUsers[0], "game", apihandler.TransactionRequest{State: "win", Amount: "3.00", TransactionID: "payout-1", RoundID: "round-1"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = postTransactionTo(strictRouter, testUsers[0], "game", apihandler.TransactionRequest{State: "win", Amount: "3.00", TransactionID: "payout-2", RoundID: "round-1"})
+	assert.Equal(t, http.StatusConflict, w.Code, "the round is already settled")
+
+	assert.True(t, decimal.NewFromInt(21).Equal(walletBalance(t, testUsers[0])))
+	assertLedgerConsistent(t, testUsers[0])
+}
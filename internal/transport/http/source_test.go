@@ -0,0 +1,106 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/app/services"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func putSourceType(name string, body apihandler.SourceTypeRequest) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := newAdminRequest(http.MethodPut, "/admin/source-types/"+name, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSourceTypes_Onboarding(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	enabled, disabled := true, false
+
+	w := postTransaction(userID, "crypto", apihandler.TransactionRequest{State: "deposit", Amount: "10.00", TransactionID: "crypto-1"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "unknown sources are rejected")
+
+	w = putSourceType("crypto", apihandler.SourceTypeRequest{Enabled: &enabled, States: []string{"deposit", "withdrawal"}, MaxAmount: "100.00", CheckBalance: &enabled})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var saved apihandler.SourceTypeResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &saved))
+	assert.Equal(t, []string{"deposit", "withdrawal"}, saved.States)
+	assert.Equal(t, "100", saved.MaxAmount)
+	assert.Equal(t, services.DefaultCurrency, saved.MaxAmountCurrency, "caps are in the default currency unless given")
+
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "crypto", apihandler.TransactionRequest{State: "deposit", Amount: "10.00", TransactionID: "crypto-1"}).Code)
+	w = postTransaction(userID, "crypto", apihandler.TransactionRequest{State: "deposit", Amount: "100.01", TransactionID: "crypto-2"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "amounts above the cap are rejected")
+	w = postTransaction(userID, "crypto", apihandler.TransactionRequest{State: "win", Amount: "1.00", TransactionID: "crypto-3"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "types the source may not send are rejected")
+
+	// Without the balance check the source may overdraw the balance.
+	w = putSourceType("crypto", apihandler.SourceTypeRequest{Enabled: &enabled, States: []string{"deposit", "withdrawal"}, CheckBalance: &disabled})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "crypto", apihandler.TransactionRequest{State: "withdrawal", Amount: "15.00", TransactionID: "crypto-4"}).Code)
+	assert.True(t, decimal.NewFromInt(-5).Equal(walletBalance(t, userID)))
+	assertLedgerConsistent(t, userID)
+
+	// A disabled source is rejected, but its transactions can still be looked up.
+	w = putSourceType("crypto", apihandler.SourceTypeRequest{Enabled: &disabled, States: []string{"deposit"}, CheckBalance: &enabled})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = postTransaction(userID, "crypto", apihandler.TransactionRequest{State: "deposit", Amount: "10.00", TransactionID: "crypto-5"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/transactions?sourceType=crypto", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var history apihandler.TransactionHistoryResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Len(t, history.Transactions, 2)
+
+	req = newAdminRequest(http.MethodGet, "/admin/source-types", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list apihandler.SourceTypesResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	names := make([]string, len(list.SourceTypes))
+	for i, src := range list.SourceTypes {
+		names[i] = src.Name
+	}
+	assert.Equal(t, []string{"crypto", "game", "payment", "server"}, names)
+}
+
+func TestSourceTypes_InvalidSettings(t *testing.T) {
+	setupTest(t)
+	enabled := true
+
+	tests := []struct {
+		name string
+		body apihandler.SourceTypeRequest
+	}{
+		{"Crypto", apihandler.SourceTypeRequest{Enabled: &enabled, States: []string{"deposit"}, CheckBalance: &enabled}},
+		{"transfer", apihandler.SourceTypeRequest{Enabled: &enabled, States: []string{"deposit"}, CheckBalance: &enabled}},
+		{"crypto", apihandler.SourceTypeRequest{Enabled: &enabled, States: []string{"debit"}, CheckBalance: &enabled}},
+		{"crypto", apihandler.SourceTypeRequest{Enabled: &enabled, States: []string{"deposit"}, MaxAmount: "0", CheckBalance: &enabled}},
+		{"crypto", apihandler.SourceTypeRequest{Enabled: &enabled, States: []string{"deposit"}, MaxAmount: "100", MaxAmountCurrency: "XYZ", CheckBalance: &enabled}},
+		{"crypto", apihandler.SourceTypeRequest{Enabled: &enabled, States: []string{"deposit"}, MaxAmountCurrency: "USD", CheckBalance: &enabled}},
+		{"crypto", apihandler.SourceTypeRequest{States: []string{"deposit"}, CheckBalance: &enabled}},
+	}
+	for _, tt := range tests {
+		w := putSourceType(tt.name, tt.body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "%s: %s", tt.name, w.Body.String())
+	}
+
+	req := newAdminRequest(http.MethodGet, "/admin/source-types/crypto", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,97 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func putUserStatus(userID uint64, body apihandler.SetUserStatusRequest) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := newAdminRequest(http.MethodPut, fmt.Sprintf("/admin/user/%d/status", userID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUserStatus_FreezeAndSelfExclude(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+	setWalletBalance(t, userID, decimal.NewFromInt(50))
+
+	w := putUserStatus(userID, apihandler.SetUserStatusRequest{Status: "frozen"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a reason is required")
+
+	w = putUserStatus(userID, apihandler.SetUserStatusRequest{Status: "frozen", Reason: "chargeback under review"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "5.00", TransactionID: "status-win-1"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var errResp map[string]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, "ACCOUNT_RESTRICTED", errResp["code"])
+
+	w = putUserStatus(userID, apihandler.SetUserStatusRequest{Status: "active", Reason: "chargeback resolved"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	until := time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	w = putUserStatus(userID, apihandler.SetUserStatusRequest{Status: "self_excluded", Until: &until, Reason: "requested by user"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var status apihandler.UserStatusResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, "self_excluded", status.Status)
+	if assert.NotNil(t, status.SelfExcludedUntil) {
+		assert.True(t, until.Equal(*status.SelfExcludedUntil))
+	}
+	if assert.Len(t, status.History, 3) {
+		assert.Equal(t, "active", status.History[2].FromStatus)
+		assert.Equal(t, "self_excluded", status.History[2].ToStatus)
+		assert.Equal(t, "requested by user", status.History[2].Reason)
+	}
+
+	// Self-excluded accounts still receive credits but cannot be debited.
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "5.00", TransactionID: "status-win-2"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "lose", Amount: "5.00", TransactionID: "status-lose-1"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = putUserStatus(userID, apihandler.SetUserStatusRequest{Status: "active", Reason: "changed my mind"})
+	assert.Equal(t, http.StatusConflict, w.Code, "a self-exclusion cannot be lifted early")
+
+	w = putUserStatus(userID, apihandler.SetUserStatusRequest{Status: "closed", Reason: "requested by user"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = putUserStatus(userID, apihandler.SetUserStatusRequest{Status: "active", Reason: "reopen"})
+	assert.Equal(t, http.StatusConflict, w.Code, "closed is final")
+
+	assert.True(t, walletBalance(t, userID).Equal(decimal.NewFromInt(55)))
+}
+
+func TestUserStatus_FrozenAccountRejectsReversals(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	w := postTransaction(userID, "game", apihandler.TransactionRequest{State: "win", Amount: "5.00", TransactionID: "status-reversed-win"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = putUserStatus(userID, apihandler.SetUserStatusRequest{Status: "frozen", Reason: "chargeback under review"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postReversal("status-reversed-win", "game", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var errResp map[string]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, "ACCOUNT_RESTRICTED", errResp["code"])
+
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "cancel", Amount: "5.00", TransactionID: "status-cancel-1", ReferenceTransactionID: "status-reversed-win"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	assert.True(t, walletBalance(t, userID).Equal(decimal.NewFromInt(5)))
+}
@@ -0,0 +1,59 @@
+package http_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func TestProcessTransaction_Types(t *testing.T) {
+	setupTest(t)
+	userID := testUsers[0]
+
+	w := postTransaction(userID, "payment", apihandler.TransactionRequest{State: "deposit", Amount: "50.00", TransactionID: "dep-1"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var deposit apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &deposit))
+	assert.Equal(t, "deposit", deposit.State)
+	assert.Equal(t, "real", deposit.Bucket)
+
+	w = postTransaction(userID, "server", apihandler.TransactionRequest{State: "bonus_grant", Amount: "10.00", TransactionID: "bonus-1"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var grant apihandler.ProcessTransactionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &grant))
+	assert.Equal(t, "10.00", grant.BonusAmount)
+
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "payment", apihandler.TransactionRequest{State: "fee", Amount: "2.50", TransactionID: "fee-1"}).Code)
+	assert.Equal(t, http.StatusOK, postTransaction(userID, "payment", apihandler.TransactionRequest{State: "withdrawal", Amount: "20.00", TransactionID: "wd-1"}).Code)
+
+	// Bonus money cannot be withdrawn.
+	w = postTransaction(userID, "payment", apihandler.TransactionRequest{State: "withdrawal", Amount: "30.00", TransactionID: "wd-2"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "deposit", Amount: "5.00", TransactionID: "dep-2"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "deposits are only accepted from payment providers")
+	w = postTransaction(userID, "game", apihandler.TransactionRequest{State: "debit", Amount: "5.00", TransactionID: "debit-1"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "transfer legs cannot be requested")
+
+	assert.True(t, decimal.RequireFromString("37.50").Equal(walletBalance(t, userID)))
+	assertLedgerConsistent(t, userID)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/user/%d/transactions?state=withdrawal", userID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var history apihandler.TransactionHistoryResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	if assert.Len(t, history.Transactions, 1) {
+		assert.Equal(t, "wd-1", history.Transactions[0].TransactionID)
+	}
+
+	// A reversed fee is credited back.
+	assert.Equal(t, http.StatusOK, postReversal("fee-1", "payment", nil).Code)
+	assert.True(t, decimal.NewFromInt(40).Equal(walletBalance(t, userID)))
+}
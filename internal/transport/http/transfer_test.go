@@ -0,0 +1,130 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func postTransfer(t *testing.T, body apihandler.TransferRequest) (*httptest.ResponseRecorder, apihandler.TransferResponse) {
+	jsonBody, _ := json.Marshal(body)
+	req := newAdminRequest(http.MethodPost, "/transfers", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response apihandler.TransferResponse
+	if w.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	}
+	return w, response
+}
+
+func TestCreateTransfer(t *testing.T) {
+	setupTest(t)
+	setWalletBalance(t, testUsers[0], decimal.NewFromInt(20))
+
+	body := apihandler.TransferRequest{TransferID: "payout-1", FromUserID: testUsers[0], ToUserID: testUsers[1], Amount: "7.50", Reason: "affiliate payout"}
+	w, response := postTransfer(t, body)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.False(t, response.IdempotentReplay)
+	assert.Equal(t, "debit", response.Debit.State)
+	assert.Equal(t, "12.50", response.Debit.BalanceAfter)
+	assert.Equal(t, "credit", response.Credit.State)
+	assert.Equal(t, "7.50", response.Credit.BalanceAfter)
+	if assert.NotNil(t, response.Debit.TransferID) && assert.NotNil(t, response.Credit.TransferID) {
+		assert.Equal(t, response.ID, *response.Debit.TransferID)
+		assert.Equal(t, response.ID, *response.Credit.TransferID)
+	}
+
+	w, replay := postTransfer(t, body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, replay.IdempotentReplay)
+	assert.Equal(t, response.Debit.ID, replay.Debit.ID)
+
+	body.Amount = "8.00"
+	w, _ = postTransfer(t, body)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	assert.True(t, decimal.RequireFromString("12.50").Equal(walletBalance(t, testUsers[0])))
+	assert.True(t, decimal.RequireFromString("7.50").Equal(walletBalance(t, testUsers[1])))
+	assertLedgerConsistent(t, testUsers[0])
+	assertLedgerConsistent(t, testUsers[1])
+
+	req := newAdminRequest(http.MethodGet, "/transfers/payout-1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var found apihandler.TransferResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &found))
+	assert.Equal(t, "affiliate payout", found.Reason)
+	assert.Equal(t, response.Credit.ID, found.Credit.ID)
+}
+
+func TestCreateTransfer_Rejected(t *testing.T) {
+	setupTest(t)
+	setWalletBalance(t, testUsers[0], decimal.NewFromInt(5))
+
+	tests := []struct {
+		name string
+		body apihandler.TransferRequest
+		code int
+	}{
+		{"insufficient balance", apihandler.TransferRequest{TransferID: "r-1", FromUserID: testUsers[0], ToUserID: testUsers[1], Amount: "5.01"}, http.StatusBadRequest},
+		{"same user", apihandler.TransferRequest{TransferID: "r-2", FromUserID: testUsers[0], ToUserID: testUsers[0], Amount: "1.00"}, http.StatusBadRequest},
+		{"unknown receiver", apihandler.TransferRequest{TransferID: "r-3", FromUserID: testUsers[0], ToUserID: 999999, Amount: "1.00"}, http.StatusNotFound},
+		{"missing transferId", apihandler.TransferRequest{FromUserID: testUsers[0], ToUserID: testUsers[1], Amount: "1.00"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := postTransfer(t, tt.body)
+			assert.Equal(t, tt.code, w.Code, w.Body.String())
+		})
+	}
+	assert.True(t, decimal.NewFromInt(5).Equal(walletBalance(t, testUsers[0])))
+	assert.True(t, walletBalance(t, testUsers[1]).IsZero())
+}
+
+// Transfers in opposite directions between the same users lock the wallets in the same order, so
+// they complete without deadlocking and conserve the total.
+func TestCreateTransfer_ConcurrentOppositeDirections(t *testing.T) {
+	setupTest(t)
+	setWalletBalance(t, testUsers[0], decimal.NewFromInt(100))
+	setWalletBalance(t, testUsers[1], decimal.NewFromInt(100))
+
+	var wg sync.WaitGroup
+	codes := make([]int, concurrentRequests)
+	for i := 0; i < concurrentRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			from, to := testUsers[0], testUsers[1]
+			if i%2 == 1 {
+				from, to = to, from
+			}
+			w, _ := postTransfer(t, apihandler.TransferRequest{
+				TransferID: fmt.Sprintf("concurrent-transfer-%d", i),
+				FromUserID: from,
+				ToUserID:   to,
+				Amount:     "1.00",
+			})
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		assert.Equal(t, http.StatusOK, code, "transfer %d failed", i)
+	}
+	total := walletBalance(t, testUsers[0]).Add(walletBalance(t, testUsers[1]))
+	assert.True(t, decimal.NewFromInt(200).Equal(total), "expected 200, got %s", total)
+	assertLedgerConsistent(t, testUsers[0])
+	assertLedgerConsistent(t, testUsers[1])
+}
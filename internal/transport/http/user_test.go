@@ -0,0 +1,95 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apihandler "github.com/zaynkorai/enlabs/internal/transport/http"
+)
+
+func postUser(body interface{}) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&payload).Encode(body)
+	}
+	req := newAdminRequest(http.MethodPost, "/users", &payload)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateUser(t *testing.T) {
+	setupTest(t)
+
+	w := postUser(apihandler.CreateUserRequest{ExternalRef: "crm-1001", Currency: "usd"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created apihandler.UserResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Greater(t, created.UserID, testUsers[len(testUsers)-1], "new users do not collide with seeded IDs")
+	assert.Equal(t, "crm-1001", created.ExternalRef)
+	assert.Equal(t, "active", created.Status)
+	if assert.Len(t, created.Wallets, 1) {
+		assert.Equal(t, "USD", created.Wallets[0].Currency)
+		assert.Equal(t, "0.00", created.Wallets[0].Balance)
+	}
+
+	w = postUser(apihandler.CreateUserRequest{ExternalRef: "crm-1001"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = postUser(apihandler.CreateUserRequest{Status: "closed"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Without a body the user is active with a wallet in the default currency.
+	w = postUser(nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	req := newAdminRequest(http.MethodGet, fmt.Sprintf("/users/%d", created.UserID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var fetched apihandler.UserResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+	assert.Equal(t, created.UserID, fetched.UserID)
+	assert.Equal(t, created.Wallets, fetched.Wallets)
+
+	req = newAdminRequest(http.MethodGet, "/users/999999", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListUsers(t *testing.T) {
+	setupTest(t)
+
+	var ids []uint64
+	req := newAdminRequest(http.MethodGet, "/users?limit=2", nil)
+	for {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var page apihandler.UserListResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		for _, u := range page.Users {
+			assert.Empty(t, u.Wallets)
+			ids = append(ids, u.UserID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		req = newAdminRequest(http.MethodGet, "/users?limit=2&cursor="+page.NextCursor, nil)
+	}
+	assert.Equal(t, testUsers, ids)
+
+	req = newAdminRequest(http.MethodGet, "/users?status=frozen", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page apihandler.UserListResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Empty(t, page.Users)
+}
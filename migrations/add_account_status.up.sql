@@ -0,0 +1,15 @@
+-- Account statuses decide which balance movements a user may make.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR(15) NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS self_excluded_until TIMESTAMP;
+
+-- Audit trail of account status changes; every change carries the reason given by the admin.
+CREATE TABLE IF NOT EXISTS status_changes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_status VARCHAR(15) NOT NULL,
+    to_status VARCHAR(15) NOT NULL,
+    self_excluded_until TIMESTAMP,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_status_changes_user_id ON status_changes (user_id);
@@ -0,0 +1,13 @@
+-- Every transaction records the balance immediately before and after it was applied, so that a
+-- user's balance timeline can be reconstructed and checked row by row.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS balance_before NUMERIC(20, 2);
+
+-- Rows that already have a balance_after get the balance before derived from their amount;
+-- reversals move the balance opposite to the transaction they reverse.
+UPDATE transactions t SET balance_before = t.balance_after - CASE
+        WHEN t.state = 'win' THEN t.amount
+        WHEN t.state = 'lose' THEN -t.amount
+        WHEN (SELECT o.state FROM transactions o WHERE o.id = t.reverses_id) = 'win' THEN -t.amount
+        ELSE t.amount
+    END
+WHERE t.balance_before IS NULL AND t.balance_after IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Wallets are split into real and bonus money. Existing balances are entirely real money.
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS bonus_balance NUMERIC(24, 4) NOT NULL DEFAULT 0;
+
+-- Transactions record how their amount was split, so that reversals can undo it exactly.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS bucket VARCHAR(5) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS bonus_amount NUMERIC(24, 4) NOT NULL DEFAULT 0;
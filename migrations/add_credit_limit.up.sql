@@ -0,0 +1,3 @@
+-- Wallets may be granted a credit line: losses, holds and reversals may draw the balance down to
+-- -credit_limit. The default of 0 keeps the balance from going negative.
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS credit_limit NUMERIC(24, 4) NOT NULL DEFAULT 0;
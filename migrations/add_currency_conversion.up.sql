@@ -0,0 +1,7 @@
+-- Transactions sent in a currency other than the wallet's are converted on ingestion. The amount as
+-- sent, its currency and the exchange rate applied are kept for audit; they are not set for rows
+-- that were booked in the currency they were sent in.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_amount NUMERIC(24, 4);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_currency VARCHAR(3);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(30, 12);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS rate_timestamp TIMESTAMP;
@@ -0,0 +1,5 @@
+-- Deposit limits share the table, the rules and the cooling-off period of the loss limits; kind
+-- tells them apart, and a user may have a limit of each kind per currency and period.
+ALTER TABLE loss_limits ADD COLUMN IF NOT EXISTS kind VARCHAR(10) NOT NULL DEFAULT 'loss';
+DROP INDEX IF EXISTS idx_loss_limits_user_currency_period;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loss_limits_user_kind_currency_period ON loss_limits (user_id, kind, currency, period);
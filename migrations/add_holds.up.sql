@@ -0,0 +1,19 @@
+-- Holds reserve part of a wallet's balance; the available balance is balance - held_balance.
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS held_balance NUMERIC(24, 4) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS holds (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hold_id VARCHAR(255) NOT NULL,
+    source_type VARCHAR(50) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    amount NUMERIC(24, 4) NOT NULL,
+    status VARCHAR(10) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    transaction_id BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holds_source_hold_id ON holds (source_type, hold_id);
+CREATE INDEX IF NOT EXISTS idx_holds_user_id ON holds (user_id);
+CREATE INDEX IF NOT EXISTS idx_holds_status_expires_at ON holds (status, expires_at);
@@ -0,0 +1,15 @@
+-- Responsible-gaming loss limits per user, currency and rolling period. A raise or removal is
+-- stored as pending and applies from pending_from.
+CREATE TABLE IF NOT EXISTS loss_limits (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL,
+    period VARCHAR(10) NOT NULL,
+    amount NUMERIC(24, 4) NOT NULL,
+    pending_amount NUMERIC(24, 4),
+    pending_removal BOOLEAN NOT NULL DEFAULT FALSE,
+    pending_from TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loss_limits_user_currency_period ON loss_limits (user_id, currency, period);
@@ -0,0 +1,9 @@
+-- Signatures of accepted provider requests. A signed request is rejected if its signature is found
+-- here; rows older than twice the authentication window are deleted as new ones are recorded.
+CREATE TABLE IF NOT EXISTS provider_signatures (
+    key_id VARCHAR(32) NOT NULL,
+    signature VARCHAR(64) NOT NULL,
+    seen_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key_id, signature)
+);
+CREATE INDEX IF NOT EXISTS idx_provider_signatures_seen_at ON provider_signatures (seen_at);
@@ -0,0 +1,16 @@
+-- Provider credentials for signed requests. A provider has at most two keys, the current one and
+-- the one it replaced; the secrets are needed in clear to verify HMAC signatures.
+CREATE TABLE IF NOT EXISTS providers (
+    name VARCHAR(50) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL,
+    source_types TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS provider_keys (
+    id VARCHAR(32) PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_provider_keys_provider ON provider_keys (provider);
@@ -0,0 +1,21 @@
+-- Game rounds link the stakes ('lose') and payouts ('win') a provider sends for one round of a user.
+-- status is one of open, settled or cancelled.
+CREATE TABLE IF NOT EXISTS rounds (
+    id BIGSERIAL PRIMARY KEY,
+    round_id VARCHAR(255) NOT NULL,
+    source_type VARCHAR(50) NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    game_id VARCHAR(255) NOT NULL DEFAULT '',
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(10) NOT NULL,
+    stake_amount NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    payout_amount NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rounds_source_round_id ON rounds (source_type, round_id);
+CREATE INDEX IF NOT EXISTS idx_rounds_user_id ON rounds (user_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS round_id VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS game_id VARCHAR(255) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_transactions_source_round_id ON transactions (source_type, round_id);
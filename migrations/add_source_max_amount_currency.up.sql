@@ -0,0 +1,5 @@
+-- A Source-Type's maxAmount is kept in its own currency; amounts sent in other currencies are
+-- converted before they are compared. Existing caps are in the default currency (DEFAULT_CURRENCY,
+-- 'EUR' here).
+ALTER TABLE source_types ADD COLUMN IF NOT EXISTS max_amount_currency VARCHAR(3);
+UPDATE source_types SET max_amount_currency = 'EUR' WHERE max_amount IS NOT NULL AND max_amount_currency IS NULL;
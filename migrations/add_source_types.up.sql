@@ -0,0 +1,16 @@
+-- Source-Types and their settings are managed through /admin/source-types instead of being fixed
+-- in code. The built-in sources start with the transaction types they accepted before.
+CREATE TABLE IF NOT EXISTS source_types (
+    name VARCHAR(50) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL,
+    states TEXT NOT NULL,
+    max_amount NUMERIC(24, 4),
+    check_balance BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO source_types (name, enabled, states, check_balance) VALUES
+    ('game', TRUE, 'win,lose,cancel', TRUE),
+    ('server', TRUE, 'win,lose,cancel,bonus_grant,adjustment,fee,refund', TRUE),
+    ('payment', TRUE, 'win,lose,cancel,deposit,withdrawal,fee,refund', TRUE)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+-- Transaction types beyond win, lose and cancel are registered in code; the state column is wide
+-- enough that adding one does not require a schema change.
+ALTER TABLE transactions ALTER COLUMN state TYPE VARCHAR(32);
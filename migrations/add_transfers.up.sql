@@ -0,0 +1,18 @@
+-- Transfers move real money between two users. Each is recorded as a 'debit' and a 'credit'
+-- transaction of source 'transfer' that reference it; transfer_id is the caller's idempotency key.
+CREATE TABLE IF NOT EXISTS transfers (
+    id BIGSERIAL PRIMARY KEY,
+    transfer_id VARCHAR(200) NOT NULL,
+    from_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL,
+    amount NUMERIC(24, 4) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transfers_transfer_id ON transfers (transfer_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_from_user_id ON transfers (from_user_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_to_user_id ON transfers (to_user_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_transactions_transfer_id ON transactions (transfer_id);
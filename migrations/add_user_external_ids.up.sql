@@ -0,0 +1,10 @@
+-- Providers address players by their own IDs, which are mapped to users here.
+CREATE TABLE IF NOT EXISTS user_external_ids (
+    id BIGSERIAL PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_external_ids_provider_external_id ON user_external_ids (provider, external_id);
+CREATE INDEX IF NOT EXISTS idx_user_external_ids_user_id ON user_external_ids (user_id);
@@ -0,0 +1,3 @@
+-- Users are provisioned through the API and may carry a reference to the operator's own systems.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_ref VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external_ref ON users (external_ref);
@@ -0,0 +1,8 @@
+-- Reversals ("cancel" transactions) reference the transaction they compensate, and each
+-- transaction can be reversed at most once.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reverses_id BIGINT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reverses_id ON transactions (reverses_id);
+
+-- Forced reversals may drive a balance negative, so the non-negative rule moves from a CHECK
+-- constraint to the row-locked balance update in the application.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_balance_non_negative;
@@ -0,0 +1,48 @@
+-- Double-entry ledger behind users.balance. Every balance change is recorded as a journal entry
+-- whose postings sum to zero; a user's balance equals the sum of the postings on their account.
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    code VARCHAR(100) PRIMARY KEY,
+    type VARCHAR(20) NOT NULL,
+    user_id BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_user_id ON ledger_accounts (user_id);
+
+CREATE TABLE IF NOT EXISTS ledger_journal_entries (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT,
+    description TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_journal_entries_transaction_id ON ledger_journal_entries (transaction_id);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id BIGSERIAL PRIMARY KEY,
+    journal_entry_id BIGINT NOT NULL REFERENCES ledger_journal_entries(id) ON DELETE CASCADE,
+    account_code VARCHAR(100) NOT NULL,
+    amount NUMERIC(20, 2) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_journal_entry_id ON ledger_postings (journal_entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account_code ON ledger_postings (account_code);
+
+-- Balances that predate the ledger are booked once as opening balances against an equity account.
+INSERT INTO ledger_accounts (code, type) VALUES ('equity:opening-balances', 'equity') ON CONFLICT DO NOTHING;
+INSERT INTO ledger_accounts (code, type, user_id)
+    SELECT 'user:' || id, 'user', id FROM users ON CONFLICT DO NOTHING;
+
+DO $$
+DECLARE
+    u RECORD;
+    entry_id BIGINT;
+BEGIN
+    FOR u IN
+        SELECT id, balance FROM users
+        WHERE balance <> 0
+          AND NOT EXISTS (SELECT 1 FROM ledger_postings p WHERE p.account_code = 'user:' || users.id)
+    LOOP
+        INSERT INTO ledger_journal_entries (description) VALUES ('opening balance for user ' || u.id) RETURNING id INTO entry_id;
+        INSERT INTO ledger_postings (journal_entry_id, account_code, amount) VALUES
+            (entry_id, 'equity:opening-balances', -u.balance),
+            (entry_id, 'user:' || u.id, u.balance);
+    END LOOP;
+END $$;
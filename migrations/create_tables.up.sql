@@ -1,28 +1,230 @@
+-- status is one of active, frozen, self_excluded or closed; self_excluded_until ends a self-exclusion.
+-- external_ref optionally references the user in the operator's own systems.
 CREATE TABLE IF NOT EXISTS users (
     id BIGSERIAL PRIMARY KEY,
-    balance NUMERIC(20, 2) NOT NULL DEFAULT 0.00,
+    external_ref VARCHAR(255),
+    status VARCHAR(15) NOT NULL DEFAULT 'active',
+    self_excluded_until TIMESTAMP,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external_ref ON users (external_ref);
+
+-- One wallet per user and ISO 4217 currency, opened on the first transaction in that currency.
+-- Amounts have 4 decimal places, the most used by any currency; each currency's precision is enforced by the service.
+-- balance is the wallet total; bonus_balance is the part of it that is bonus money and
+-- held_balance the part reserved by active holds. credit_limit is how far balance may go below zero.
+CREATE TABLE IF NOT EXISTS wallets (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL,
+    balance NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    bonus_balance NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    held_balance NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    credit_limit NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    version BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, currency)
+);
 
 CREATE TABLE IF NOT EXISTS transactions (
     id BIGSERIAL PRIMARY KEY,
     user_id BIGINT NOT NULL,
-    transaction_id VARCHAR(255) UNIQUE NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
     source_type VARCHAR(50) NOT NULL,
-    state VARCHAR(10) NOT NULL,
-    amount NUMERIC(20, 2) NOT NULL,
-    processed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Transaction type, see the registry in internal/domain/transaction
+    state VARCHAR(32) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    amount NUMERIC(24, 4) NOT NULL,
+    balance_before NUMERIC(24, 4),
+    balance_after NUMERIC(24, 4),
+    reverses_id BIGINT,
+    -- For the 'debit' and 'credit' legs of a transfer, the transfer they belong to
+    transfer_id BIGINT,
+    request_hash VARCHAR(64),
+    -- An instant, so that history filters with any UTC offset select the same window
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Sub-balance requested for the whole amount (empty if split by policy) and the part booked on the bonus balance
+    bucket VARCHAR(5) NOT NULL DEFAULT '',
+    bonus_amount NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    -- Conversion audit trail for transactions sent in a currency other than the wallet's
+    original_amount NUMERIC(24, 4),
+    original_currency VARCHAR(3),
+    exchange_rate NUMERIC(30, 12),
+    rate_timestamp TIMESTAMP,
+    -- Game round the transaction belongs to, scoped per source like transaction_id; empty outside rounds
+    round_id VARCHAR(255) NOT NULL DEFAULT '',
+    game_id VARCHAR(255) NOT NULL DEFAULT '',
     CONSTRAINT fk_user
         FOREIGN KEY(user_id)
             REFERENCES users(id)
             ON DELETE CASCADE
 );
 
+-- Idempotency key: transaction IDs are unique per source, not globally
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_source_transaction_id ON transactions (source_type, transaction_id);
 CREATE INDEX IF NOT EXISTS idx_transactions_transaction_id ON transactions (transaction_id);
+-- A transaction can be reversed at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reverses_id ON transactions (reverses_id);
 CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions (user_id);
+-- Backs keyset pagination of a user's history ordered by (processed_at, id)
+CREATE INDEX IF NOT EXISTS idx_transactions_user_history ON transactions (user_id, processed_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_transfer_id ON transactions (transfer_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_source_round_id ON transactions (source_type, round_id);
+
+-- Game rounds link the stakes ('lose') and payouts ('win') a provider sends for one round of a user.
+-- status is one of open, settled or cancelled.
+CREATE TABLE IF NOT EXISTS rounds (
+    id BIGSERIAL PRIMARY KEY,
+    round_id VARCHAR(255) NOT NULL,
+    source_type VARCHAR(50) NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    game_id VARCHAR(255) NOT NULL DEFAULT '',
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(10) NOT NULL,
+    stake_amount NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    payout_amount NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rounds_source_round_id ON rounds (source_type, round_id);
+CREATE INDEX IF NOT EXISTS idx_rounds_user_id ON rounds (user_id);
+
+-- Transfers move real money between two users. Each is recorded as a 'debit' and a 'credit'
+-- transaction of source 'transfer' that reference it; transfer_id is the caller's idempotency key.
+CREATE TABLE IF NOT EXISTS transfers (
+    id BIGSERIAL PRIMARY KEY,
+    transfer_id VARCHAR(200) NOT NULL,
+    from_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL,
+    amount NUMERIC(24, 4) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transfers_transfer_id ON transfers (transfer_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_from_user_id ON transfers (from_user_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_to_user_id ON transfers (to_user_id);
 
--- Insert predefined users (id 1, 2, 3) with initial balance 0.00 if they don't exist
-INSERT INTO users (id, balance) VALUES (1, 0.00) ON CONFLICT (id) DO NOTHING;
-INSERT INTO users (id, balance) VALUES (2, 0.00) ON CONFLICT (id) DO NOTHING;
-INSERT INTO users (id, balance) VALUES (3, 0.00) ON CONFLICT (id) DO NOTHING;
\ No newline at end of file
+-- Holds reserve part of a wallet's balance until they are captured by a 'lose' transaction,
+-- released, or expired by the sweeper.
+CREATE TABLE IF NOT EXISTS holds (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hold_id VARCHAR(255) NOT NULL,
+    source_type VARCHAR(50) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    amount NUMERIC(24, 4) NOT NULL,
+    status VARCHAR(10) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    transaction_id BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holds_source_hold_id ON holds (source_type, hold_id);
+CREATE INDEX IF NOT EXISTS idx_holds_user_id ON holds (user_id);
+-- Backs the sweeper's search for expired active holds
+CREATE INDEX IF NOT EXISTS idx_holds_status_expires_at ON holds (status, expires_at);
+
+-- Double-entry ledger: every balance change is a journal entry whose postings sum to zero.
+-- Each account holds a single currency; user accounts mirror one wallet each.
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    code VARCHAR(100) PRIMARY KEY,
+    type VARCHAR(20) NOT NULL,
+    user_id BIGINT,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_user_currency ON ledger_accounts (user_id, currency);
+
+CREATE TABLE IF NOT EXISTS ledger_journal_entries (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT,
+    description TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_journal_entries_transaction_id ON ledger_journal_entries (transaction_id);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id BIGSERIAL PRIMARY KEY,
+    journal_entry_id BIGINT NOT NULL REFERENCES ledger_journal_entries(id) ON DELETE CASCADE,
+    account_code VARCHAR(100) NOT NULL,
+    amount NUMERIC(24, 4) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_journal_entry_id ON ledger_postings (journal_entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account_code ON ledger_postings (account_code);
+
+-- Responsible-gaming loss and deposit limits per user, kind, currency and rolling period. A raise
+-- or removal is stored as pending and applies from pending_from.
+CREATE TABLE IF NOT EXISTS loss_limits (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL DEFAULT 'loss',
+    currency VARCHAR(3) NOT NULL,
+    period VARCHAR(10) NOT NULL,
+    amount NUMERIC(24, 4) NOT NULL,
+    pending_amount NUMERIC(24, 4),
+    pending_removal BOOLEAN NOT NULL DEFAULT FALSE,
+    pending_from TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loss_limits_user_kind_currency_period ON loss_limits (user_id, kind, currency, period);
+
+-- Audit trail of account status changes; every change carries the reason given by the admin.
+CREATE TABLE IF NOT EXISTS status_changes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_status VARCHAR(15) NOT NULL,
+    to_status VARCHAR(15) NOT NULL,
+    self_excluded_until TIMESTAMP,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_status_changes_user_id ON status_changes (user_id);
+-- Maps the IDs providers use for their players to users; a player ID belongs to one user per provider.
+CREATE TABLE IF NOT EXISTS user_external_ids (
+    id BIGSERIAL PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_external_ids_provider_external_id ON user_external_ids (provider, external_id);
+CREATE INDEX IF NOT EXISTS idx_user_external_ids_user_id ON user_external_ids (user_id);
+-- Source-Types providers send transactions with; states lists the transaction types a source may
+-- send, comma-separated. The built-in sources are created on startup.
+CREATE TABLE IF NOT EXISTS source_types (
+    name VARCHAR(50) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL,
+    states TEXT NOT NULL,
+    max_amount NUMERIC(24, 4),
+    max_amount_currency VARCHAR(3),
+    check_balance BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- Providers sign their requests with one of at most two keys; source_types lists the Source-Types a
+-- provider may use, comma-separated.
+CREATE TABLE IF NOT EXISTS providers (
+    name VARCHAR(50) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL,
+    source_types TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS provider_keys (
+    id VARCHAR(32) PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_provider_keys_provider ON provider_keys (provider);
+-- Signatures of accepted provider requests, kept for twice the authentication window to reject replays.
+CREATE TABLE IF NOT EXISTS provider_signatures (
+    key_id VARCHAR(32) NOT NULL,
+    signature VARCHAR(64) NOT NULL,
+    seen_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key_id, signature)
+);
+CREATE INDEX IF NOT EXISTS idx_provider_signatures_seen_at ON provider_signatures (seen_at);
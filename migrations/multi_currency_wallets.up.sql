@@ -0,0 +1,46 @@
+-- Balances move from users into one wallet per user and ISO 4217 currency. Existing balances,
+-- transactions and ledger accounts are assigned the default currency (DEFAULT_CURRENCY, 'EUR' here).
+BEGIN;
+
+-- Databases created from the first schema predate these columns, which the statements below rely on.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS request_hash VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS balance_before NUMERIC(24, 4),
+    ADD COLUMN IF NOT EXISTS balance_after NUMERIC(24, 4);
+
+CREATE TABLE IF NOT EXISTS wallets (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL,
+    balance NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    version BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, currency)
+);
+
+INSERT INTO wallets (user_id, currency, balance, version, created_at, updated_at)
+    SELECT id, 'EUR', balance, version, created_at, updated_at FROM users ON CONFLICT DO NOTHING;
+
+-- Stored fingerprints did not cover the currency; rows without one are compared by recomputing it.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(3);
+UPDATE transactions SET currency = 'EUR', request_hash = NULL WHERE currency IS NULL;
+ALTER TABLE transactions ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE transactions
+    ALTER COLUMN amount TYPE NUMERIC(24, 4),
+    ALTER COLUMN balance_before TYPE NUMERIC(24, 4),
+    ALTER COLUMN balance_after TYPE NUMERIC(24, 4);
+
+-- Ledger accounts hold a single currency; their codes gain a currency suffix (user:1 -> user:1:EUR).
+ALTER TABLE ledger_accounts ADD COLUMN IF NOT EXISTS currency VARCHAR(3);
+UPDATE ledger_postings SET account_code = account_code || ':EUR'
+    WHERE account_code IN (SELECT code FROM ledger_accounts WHERE currency IS NULL);
+UPDATE ledger_accounts SET code = code || ':EUR', currency = 'EUR' WHERE currency IS NULL;
+ALTER TABLE ledger_accounts ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE ledger_postings ALTER COLUMN amount TYPE NUMERIC(24, 4);
+DROP INDEX IF EXISTS idx_ledger_accounts_user_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_user_currency ON ledger_accounts (user_id, currency);
+
+ALTER TABLE users DROP COLUMN balance, DROP COLUMN version;
+
+COMMIT;
@@ -0,0 +1,3 @@
+-- History filters accept bounds with any UTC offset and compare them as instants, so processed_at
+-- is stored with its time zone. Existing values were written in the session time zone.
+ALTER TABLE transactions ALTER COLUMN processed_at TYPE TIMESTAMPTZ USING processed_at AT TIME ZONE current_setting('TimeZone');
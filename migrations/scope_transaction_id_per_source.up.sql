@@ -0,0 +1,7 @@
+-- Scope transaction ID uniqueness per source so that two providers generating the same
+-- transactionId no longer collide. Rows written under the old global constraint are already
+-- unique per source, so building the new index cannot fail on existing data.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_source_transaction_id ON transactions (source_type, transaction_id);
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_transaction_id_key;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS uni_transactions_transaction_id;
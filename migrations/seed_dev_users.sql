@@ -0,0 +1,7 @@
+-- Development only: the predefined users 1, 2 and 3, created on startup when SEED_USERS is set.
+-- Their wallets are opened by their first transactions.
+INSERT INTO users (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+INSERT INTO users (id) VALUES (2) ON CONFLICT (id) DO NOTHING;
+INSERT INTO users (id) VALUES (3) ON CONFLICT (id) DO NOTHING;
+-- Users created through the API take their IDs from the sequence, which must skip the seeded IDs.
+SELECT setval(pg_get_serial_sequence('users', 'id'), GREATEST((SELECT MAX(id) FROM users), 1));
@@ -3,9 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
+	"github.com/zaynkorai/enlabs/internal/domain/currency"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
 )
 
 type Config struct {
@@ -17,6 +20,50 @@ type Config struct {
 	AppPort    string `mapstructure:"APP_PORT"`
 	TimeZone   string `mapstructure:"TIME_ZONE"`
 	SSLMode    string `mapstructure:"SSL_MODE"`
+
+	// BalanceLockingStrategy selects how concurrent balance updates are serialized:
+	// "pessimistic" (SELECT ... FOR UPDATE) or "optimistic" (version column with retries).
+	BalanceLockingStrategy string `mapstructure:"BALANCE_LOCKING_STRATEGY"`
+	OptimisticMaxRetries   int    `mapstructure:"OPTIMISTIC_MAX_RETRIES"`
+
+	// DefaultCurrency is the ISO 4217 code assumed for transactions that do not name a currency.
+	// Balances that predate multi-currency wallets are migrated into wallets of this currency.
+	DefaultCurrency string `mapstructure:"DEFAULT_CURRENCY"`
+	// ExchangeRatesFile is a JSON file of static exchange rates. Without it, transactions can only
+	// be booked in the currency they were sent in.
+	ExchangeRatesFile string `mapstructure:"EXCHANGE_RATES_FILE"`
+	// BonusDebitOrder selects which sub-balance losses are paid from first:
+	// "real_first" or "bonus_first".
+	BonusDebitOrder string `mapstructure:"BONUS_DEBIT_ORDER"`
+
+	// HoldDefaultTTL applies to holds placed without a TTL; HoldMaxTTL bounds the TTL a caller may ask for.
+	HoldDefaultTTL time.Duration `mapstructure:"HOLD_DEFAULT_TTL"`
+	HoldMaxTTL     time.Duration `mapstructure:"HOLD_MAX_TTL"`
+	// HoldSweepInterval is how often expired holds are released.
+	HoldSweepInterval time.Duration `mapstructure:"HOLD_SWEEP_INTERVAL"`
+
+	// LossLimitCooldown is how long raising or removing a responsible-gaming loss or deposit limit takes to apply.
+	LossLimitCooldown time.Duration `mapstructure:"LOSS_LIMIT_COOLDOWN"`
+
+	// SeedUsers creates the users 1, 2 and 3 on startup. Meant for local development only;
+	// users are otherwise created through the API.
+	SeedUsers bool `mapstructure:"SEED_USERS"`
+
+	// AutoProvisionUsers creates a user the first time a provider refers to an unknown player ID.
+	AutoProvisionUsers bool `mapstructure:"AUTO_PROVISION_USERS"`
+
+	// StrictRounds rejects payouts for unknown game rounds and stakes or payouts for closed ones.
+	StrictRounds bool `mapstructure:"STRICT_ROUNDS"`
+
+	// AdminAPIKey is the key operators send in the X-Admin-Key header to use the /admin and limit routes.
+	AdminAPIKey string `mapstructure:"ADMIN_API_KEY"`
+
+	// ProviderAuth requires the requests of the provider-facing routes to be signed with a provider key.
+	ProviderAuth bool `mapstructure:"PROVIDER_AUTH"`
+	// AllowUnsignedRequests must be set to turn ProviderAuth off. Meant for local development only.
+	AllowUnsignedRequests bool `mapstructure:"INSECURE_ALLOW_UNSIGNED_REQUESTS"`
+	// ProviderAuthWindow is how far the timestamp of a signed request may be from the server's clock.
+	ProviderAuthWindow time.Duration `mapstructure:"PROVIDER_AUTH_WINDOW"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -24,6 +71,22 @@ func LoadConfig() (*Config, error) {
 	_ = godotenv.Load() // For local development, but env vars take precedence in production
 
 	viper.SetDefault("APP_PORT", "8089")
+	viper.SetDefault("BALANCE_LOCKING_STRATEGY", "pessimistic")
+	viper.SetDefault("OPTIMISTIC_MAX_RETRIES", 5)
+	viper.SetDefault("DEFAULT_CURRENCY", "EUR")
+	viper.SetDefault("EXCHANGE_RATES_FILE", "")
+	viper.SetDefault("BONUS_DEBIT_ORDER", string(user.DebitRealFirst))
+	viper.SetDefault("HOLD_DEFAULT_TTL", "15m")
+	viper.SetDefault("HOLD_MAX_TTL", "24h")
+	viper.SetDefault("HOLD_SWEEP_INTERVAL", "30s")
+	viper.SetDefault("LOSS_LIMIT_COOLDOWN", "24h")
+	viper.SetDefault("SEED_USERS", false)
+	viper.SetDefault("AUTO_PROVISION_USERS", false)
+	viper.SetDefault("STRICT_ROUNDS", false)
+	viper.SetDefault("ADMIN_API_KEY", "")
+	viper.SetDefault("PROVIDER_AUTH", true)
+	viper.SetDefault("INSECURE_ALLOW_UNSIGNED_REQUESTS", false)
+	viper.SetDefault("PROVIDER_AUTH_WINDOW", "5m")
 	viper.AutomaticEnv()
 
 	var cfg Config
@@ -74,5 +137,36 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	if cfg.BalanceLockingStrategy != "pessimistic" && cfg.BalanceLockingStrategy != "optimistic" {
+		return nil, fmt.Errorf("invalid BALANCE_LOCKING_STRATEGY %q: must be 'pessimistic' or 'optimistic'", cfg.BalanceLockingStrategy)
+	}
+	if cfg.OptimisticMaxRetries < 0 {
+		return nil, fmt.Errorf("invalid OPTIMISTIC_MAX_RETRIES %d: must not be negative", cfg.OptimisticMaxRetries)
+	}
+	if !currency.Valid(cfg.DefaultCurrency) {
+		return nil, fmt.Errorf("invalid DEFAULT_CURRENCY %q: must be an ISO 4217 currency code", cfg.DefaultCurrency)
+	}
+	if !user.ValidDebitOrder(user.DebitOrder(cfg.BonusDebitOrder)) {
+		return nil, fmt.Errorf("invalid BONUS_DEBIT_ORDER %q: must be 'real_first' or 'bonus_first'", cfg.BonusDebitOrder)
+	}
+	if cfg.HoldDefaultTTL <= 0 || cfg.HoldMaxTTL < cfg.HoldDefaultTTL {
+		return nil, fmt.Errorf("invalid HOLD_DEFAULT_TTL %s and HOLD_MAX_TTL %s: the default must be positive and not exceed the maximum", cfg.HoldDefaultTTL, cfg.HoldMaxTTL)
+	}
+	if cfg.HoldSweepInterval <= 0 {
+		return nil, fmt.Errorf("invalid HOLD_SWEEP_INTERVAL %s: must be positive", cfg.HoldSweepInterval)
+	}
+	if cfg.LossLimitCooldown < 0 {
+		return nil, fmt.Errorf("invalid LOSS_LIMIT_COOLDOWN %s: must not be negative", cfg.LossLimitCooldown)
+	}
+	if cfg.AdminAPIKey == "" {
+		return nil, fmt.Errorf("ADMIN_API_KEY environment variable not set")
+	}
+	if !cfg.ProviderAuth && !cfg.AllowUnsignedRequests {
+		return nil, fmt.Errorf("PROVIDER_AUTH is off: set INSECURE_ALLOW_UNSIGNED_REQUESTS=true to accept unsigned provider requests")
+	}
+	if cfg.ProviderAuthWindow <= 0 {
+		return nil, fmt.Errorf("invalid PROVIDER_AUTH_WINDOW %s: must be positive", cfg.ProviderAuthWindow)
+	}
+
 	return &cfg, nil
 }
@@ -0,0 +1,52 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/pkg/config"
+)
+
+func setDatabaseEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_PORT", "5432")
+	t.Setenv("DB_USER", "user")
+	t.Setenv("DB_PASSWORD", "password")
+	t.Setenv("DB_NAME", "enlabs_db")
+	t.Setenv("TIME_ZONE", "UTC")
+}
+
+func TestLoadConfig_AdminAPIKey(t *testing.T) {
+	setDatabaseEnv(t)
+	t.Setenv("ADMIN_API_KEY", "secret-admin-key")
+
+	cfg, err := config.LoadConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "secret-admin-key", cfg.AdminAPIKey)
+		assert.True(t, cfg.ProviderAuth, "provider authentication is on by default")
+	}
+}
+
+func TestLoadConfig_MissingAdminAPIKey(t *testing.T) {
+	setDatabaseEnv(t)
+	t.Setenv("ADMIN_API_KEY", "")
+
+	_, err := config.LoadConfig()
+	assert.EqualError(t, err, "ADMIN_API_KEY environment variable not set")
+}
+
+func TestLoadConfig_UnsignedRequestsNeedTheInsecureFlag(t *testing.T) {
+	setDatabaseEnv(t)
+	t.Setenv("ADMIN_API_KEY", "secret-admin-key")
+	t.Setenv("PROVIDER_AUTH", "false")
+
+	_, err := config.LoadConfig()
+	assert.Error(t, err)
+
+	t.Setenv("INSECURE_ALLOW_UNSIGNED_REQUESTS", "true")
+	cfg, err := config.LoadConfig()
+	if assert.NoError(t, err) {
+		assert.False(t, cfg.ProviderAuth)
+	}
+}
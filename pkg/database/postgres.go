@@ -1,23 +1,29 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/zaynkorai/enlabs/internal/domain/hold"
+	"github.com/zaynkorai/enlabs/internal/domain/ledger"
+	"github.com/zaynkorai/enlabs/internal/domain/limit"
+	"github.com/zaynkorai/enlabs/internal/domain/provider"
+	"github.com/zaynkorai/enlabs/internal/domain/round"
+	"github.com/zaynkorai/enlabs/internal/domain/source"
 	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/transfer"
 	"github.com/zaynkorai/enlabs/internal/domain/user"
 	"github.com/zaynkorai/enlabs/pkg/config"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
 func NewPostgresDB(cfg *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
-		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort, cfg.SSLMode, cfg.TimeZone)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(postgres.Open(dataSourceName(cfg)), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info), // Log GORM queries
 	})
 	if err != nil {
@@ -34,21 +40,62 @@ func NewPostgresDB(cfg *config.Config) (*gorm.DB, error) {
 
 	log.Println("Database connection established.")
 
-	if err = runMigrations(db); err != nil {
+	if err = runMigrations(db, cfg.DefaultCurrency); err != nil {
 		return nil, fmt.Errorf("failed to run database migrations: %w", err)
 	}
+	if cfg.SeedUsers {
+		if err = seedUsers(db); err != nil {
+			return nil, fmt.Errorf("failed to seed users: %w", err)
+		}
+	}
 
 	return db, nil
 }
 
-func runMigrations(db *gorm.DB) error {
-	err := db.AutoMigrate(&user.User{}, &transaction.Transaction{})
+func dataSourceName(cfg *config.Config) string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
+		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort, cfg.SSLMode, cfg.TimeZone)
+}
+
+func runMigrations(db *gorm.DB, defaultCurrency string) error {
+	if err := migrateToWallets(db, defaultCurrency); err != nil {
+		return err
+	}
+
+	err := db.AutoMigrate(&user.User{}, &user.Wallet{}, &transaction.Transaction{},
+		&ledger.Account{}, &ledger.JournalEntry{}, &ledger.Posting{}, &hold.Hold{}, &limit.Limit{}, &user.StatusChange{}, &user.ExternalID{},
+		&transfer.Transfer{}, &round.Round{}, &source.Source{},
+		&provider.Provider{}, &provider.Key{}, &provider.Signature{})
 	if err != nil {
 		return fmt.Errorf("failed to auto migrate database: %w", err)
 	}
 
 	log.Println("Database auto-migration completed.")
 
+	if err := runPostMigrationStatements(db); err != nil {
+		return err
+	}
+	// add_source_max_amount_currency.up.sql: caps set before they had a currency are in the default currency.
+	err = db.Exec("UPDATE source_types SET max_amount_currency = @currency WHERE max_amount IS NOT NULL AND max_amount_currency IS NULL",
+		sql.Named("currency", defaultCurrency)).Error
+	if err != nil {
+		return fmt.Errorf("failed to assign the default currency to Source-Type caps: %w", err)
+	}
+	return seedSources(db)
+}
+
+// seedSources creates the built-in Source-Types with their default settings; sources that exist
+// keep the settings they were given. See migrations/add_source_types.up.sql.
+func seedSources(db *gorm.DB) error {
+	defaults := source.Defaults()
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&defaults).Error; err != nil {
+		return fmt.Errorf("failed to seed Source-Types: %w", err)
+	}
+	return nil
+}
+
+// seedUsers creates the users 1, 2 and 3 for local development; see migrations/seed_dev_users.sql.
+func seedUsers(db *gorm.DB) error {
 	predefinedUserIDs := []uint64{1, 2, 3}
 	for _, id := range predefinedUserIDs {
 		var existingUser user.User
@@ -65,7 +112,134 @@ func runMigrations(db *gorm.DB) error {
 			}
 		}
 	}
+	// The IDs were set explicitly, so move the sequence past them for users created through the API.
+	if err := db.Exec("SELECT setval(pg_get_serial_sequence('users', 'id'), GREATEST((SELECT MAX(id) FROM users), 1))").Error; err != nil {
+		return fmt.Errorf("failed to advance the user ID sequence: %w", err)
+	}
 	log.Println("Predefined users seeding completed.")
 
 	return nil
 }
+
+// postMigrationStatements adjust constraints that auto-migration does not drop on existing databases.
+// Every statement is idempotent; the matching SQL lives in migrations/.
+var postMigrationStatements = []string{
+	// scope_transaction_id_per_source.up.sql: idempotency is keyed by (source_type, transaction_id),
+	// enforced by the unique index created by auto-migration.
+	"ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_transaction_id_key",
+	"ALTER TABLE transactions DROP CONSTRAINT IF EXISTS uni_transactions_transaction_id",
+	// allow_forced_reversals.up.sql: forced reversals may drive a balance negative; the
+	// non-negative rule is enforced on the locked row by the repository instead.
+	"ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_balance_non_negative",
+	// create_ledger.up.sql: wallet balances that predate the ledger are booked once as opening balances.
+	"INSERT INTO ledger_accounts (code, type, currency, created_at) SELECT DISTINCT 'equity:opening-balances:' || currency, 'equity', currency, NOW() FROM wallets ON CONFLICT DO NOTHING",
+	"INSERT INTO ledger_accounts (code, type, user_id, currency, created_at) SELECT 'user:' || user_id || ':' || currency, 'user', user_id, currency, NOW() FROM wallets ON CONFLICT DO NOTHING",
+	`DO $$
+DECLARE
+    w RECORD;
+    entry_id BIGINT;
+BEGIN
+    FOR w IN
+        SELECT user_id, currency, balance FROM wallets
+        WHERE balance <> 0
+          AND NOT EXISTS (SELECT 1 FROM ledger_postings p WHERE p.account_code = 'user:' || wallets.user_id || ':' || wallets.currency)
+    LOOP
+        INSERT INTO ledger_journal_entries (description, created_at) VALUES ('opening ' || w.currency || ' balance for user ' || w.user_id, NOW()) RETURNING id INTO entry_id;
+        INSERT INTO ledger_postings (journal_entry_id, account_code, amount) VALUES
+            (entry_id, 'equity:opening-balances:' || w.currency, -w.balance),
+            (entry_id, 'user:' || w.user_id || ':' || w.currency, w.balance);
+    END LOOP;
+END $$`,
+	// add_balance_before.up.sql: derive the balance before of rows that only recorded the balance after.
+	`UPDATE transactions t SET balance_before = t.balance_after - CASE
+        WHEN t.state = 'win' THEN t.amount
+        WHEN t.state = 'lose' THEN -t.amount
+        WHEN (SELECT o.state FROM transactions o WHERE o.id = t.reverses_id) = 'win' THEN -t.amount
+        ELSE t.amount
+    END
+WHERE t.balance_before IS NULL AND t.balance_after IS NOT NULL`,
+	// add_deposit_limits.up.sql: a user may have a loss and a deposit limit for the same currency and
+	// period, enforced by the unique index including the kind created by auto-migration.
+	"DROP INDEX IF EXISTS idx_loss_limits_user_currency_period",
+	// processed_at_with_time_zone.up.sql: processed_at is compared with filter bounds as an instant;
+	// stored values were written in the session time zone.
+	`DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = 'transactions' AND column_name = 'processed_at') = 'timestamp without time zone' THEN
+        ALTER TABLE transactions ALTER COLUMN processed_at TYPE TIMESTAMPTZ USING processed_at AT TIME ZONE current_setting('TimeZone');
+    END IF;
+END $$`,
+}
+
+// walletMigrationStatements move the single balance stored on users into per-currency wallets and
+// assign the default currency (bound as @currency) to existing transactions. The matching SQL lives
+// in migrations/multi_currency_wallets.up.sql.
+var walletMigrationStatements = []string{
+	// Databases created from the first schema predate the columns below, which the statements rely on.
+	"ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0",
+	"ALTER TABLE transactions ADD COLUMN IF NOT EXISTS request_hash VARCHAR(64), ADD COLUMN IF NOT EXISTS balance_before NUMERIC(24, 4), ADD COLUMN IF NOT EXISTS balance_after NUMERIC(24, 4)",
+	`CREATE TABLE IF NOT EXISTS wallets (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL,
+    balance NUMERIC(24, 4) NOT NULL DEFAULT 0,
+    version BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, currency)
+)`,
+	`INSERT INTO wallets (user_id, currency, balance, version, created_at, updated_at)
+    SELECT id, @currency, balance, version, created_at, updated_at FROM users ON CONFLICT DO NOTHING`,
+	"ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(3)",
+	// Stored fingerprints did not cover the currency; rows without one are compared by recomputing it.
+	"UPDATE transactions SET currency = @currency, request_hash = NULL WHERE currency IS NULL",
+	"ALTER TABLE transactions ALTER COLUMN currency SET NOT NULL",
+	"ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(24, 4), ALTER COLUMN balance_before TYPE NUMERIC(24, 4), ALTER COLUMN balance_after TYPE NUMERIC(24, 4)",
+	"ALTER TABLE users DROP COLUMN balance, DROP COLUMN version",
+}
+
+// ledgerWalletMigrationStatements move existing ledger accounts into the default currency.
+var ledgerWalletMigrationStatements = []string{
+	"ALTER TABLE ledger_accounts ADD COLUMN IF NOT EXISTS currency VARCHAR(3)",
+	"UPDATE ledger_postings SET account_code = account_code || ':' || @currency WHERE account_code IN (SELECT code FROM ledger_accounts WHERE currency IS NULL)",
+	"UPDATE ledger_accounts SET code = code || ':' || @currency, currency = @currency WHERE currency IS NULL",
+	"ALTER TABLE ledger_accounts ALTER COLUMN currency SET NOT NULL",
+	"ALTER TABLE ledger_postings ALTER COLUMN amount TYPE NUMERIC(24, 4)",
+	"DROP INDEX IF EXISTS idx_ledger_accounts_user_id",
+}
+
+// migrateToWallets upgrades databases created before multi-currency wallets. It runs before
+// auto-migration, only while users still has a balance column, in a single database transaction.
+func migrateToWallets(db *gorm.DB, defaultCurrency string) error {
+	if !db.Migrator().HasTable(&user.User{}) || !db.Migrator().HasColumn(&user.User{}, "balance") {
+		return nil
+	}
+
+	statements := walletMigrationStatements
+	if db.Migrator().HasTable(&ledger.Account{}) {
+		statements = append(append([]string{}, ledgerWalletMigrationStatements...), walletMigrationStatements...)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt, sql.Named("currency", defaultCurrency)).Error; err != nil {
+				return fmt.Errorf("failed to run wallet migration statement %q: %w", stmt, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Migrated user balances to %s wallets.", defaultCurrency)
+	return nil
+}
+
+func runPostMigrationStatements(db *gorm.DB) error {
+	for _, stmt := range postMigrationStatements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to run post-migration statement %q: %w", stmt, err)
+		}
+	}
+	return nil
+}
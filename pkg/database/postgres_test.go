@@ -0,0 +1,101 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/zaynkorai/enlabs/internal/domain/transaction"
+	"github.com/zaynkorai/enlabs/internal/domain/user"
+	"github.com/zaynkorai/enlabs/pkg/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// baselineSchema is the schema of the first release, with a user who has a balance and a transaction.
+const baselineSchema = `
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    balance NUMERIC(20, 2) NOT NULL DEFAULT 0.00,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    transaction_id VARCHAR(255) UNIQUE NOT NULL,
+    source_type VARCHAR(50) NOT NULL,
+    state VARCHAR(10) NOT NULL,
+    amount NUMERIC(20, 2) NOT NULL,
+    processed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user
+        FOREIGN KEY(user_id)
+            REFERENCES users(id)
+            ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_transaction_id ON transactions (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions (user_id);
+
+INSERT INTO users (id, balance) VALUES (1, 12.50);
+INSERT INTO transactions (user_id, transaction_id, source_type, state, amount) VALUES (1, 'baseline-win', 'game', 'win', 12.50);
+`
+
+// openSchema connects to the test database with a fresh schema of the given name as search path.
+func openSchema(t *testing.T, cfg *config.Config, schema string) *gorm.DB {
+	t.Helper()
+	admin, err := gorm.Open(postgres.Open(dataSourceName(cfg)), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := admin.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE; CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("Failed to create schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	db, err := gorm.Open(postgres.Open(dataSourceName(cfg)+" search_path="+schema), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestRunMigrations_UpgradesBaselineSchema(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "test-admin-key")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load test configuration: %v", err)
+	}
+	db := openSchema(t, cfg, "upgrade_from_baseline")
+	if err := db.Exec(baselineSchema).Error; err != nil {
+		t.Fatalf("Failed to create the baseline schema: %v", err)
+	}
+
+	if err := runMigrations(db, "EUR"); err != nil {
+		t.Fatalf("Failed to upgrade the baseline schema: %v", err)
+	}
+
+	assert.False(t, db.Migrator().HasColumn(&user.User{}, "balance"))
+	var wallet user.Wallet
+	assert.NoError(t, db.Where("user_id = ? AND currency = ?", 1, "EUR").First(&wallet).Error)
+	assert.True(t, decimal.RequireFromString("12.50").Equal(wallet.Balance), "got %s", wallet.Balance)
+
+	var txn transaction.Transaction
+	assert.NoError(t, db.Where("transaction_id = ?", "baseline-win").First(&txn).Error)
+	assert.Equal(t, "EUR", txn.Currency)
+	assert.False(t, txn.BalanceAfter.Valid, "the baseline did not record balances")
+
+	// Running the migrations again on the upgraded schema changes nothing.
+	assert.NoError(t, runMigrations(db, "EUR"))
+}
@@ -4,7 +4,7 @@ import "errors"
 
 type AppError struct {
 	Message string
-	Code    string // e.g., "NOT_FOUND", "VALIDATION_ERROR", "CONFLICT", "ALREADY_PROCESSED"
+	Code    string // e.g., "NOT_FOUND", "VALIDATION_ERROR", "CONFLICT", "ALREADY_PROCESSED", "VERSION_CONFLICT", "LIMIT_EXCEEDED", "ACCOUNT_RESTRICTED", "INSUFFICIENT_BALANCE", "UNAUTHORIZED"
 }
 
 func (e *AppError) Error() string {
@@ -30,9 +30,10 @@ func NewValidationError(message string) error {
 	}
 }
 
+// IsValidationError also reports insufficient balance errors, which are a kind of validation error.
 func IsValidationError(err error) bool {
 	var appErr *AppError
-	return errors.As(err, &appErr) && appErr.Code == "VALIDATION_ERROR"
+	return errors.As(err, &appErr) && (appErr.Code == "VALIDATION_ERROR" || appErr.Code == "INSUFFICIENT_BALANCE")
 }
 
 func NewConflictError(message string) error {
@@ -58,3 +59,63 @@ func IsAlreadyProcessedError(err error) bool {
 	var appErr *AppError
 	return errors.As(err, &appErr) && appErr.Code == "ALREADY_PROCESSED"
 }
+
+func NewVersionConflictError(message string) error {
+	return &AppError{
+		Message: message,
+		Code:    "VERSION_CONFLICT",
+	}
+}
+
+func IsVersionConflictError(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == "VERSION_CONFLICT"
+}
+
+func NewLimitExceededError(message string) error {
+	return &AppError{
+		Message: message,
+		Code:    "LIMIT_EXCEEDED",
+	}
+}
+
+func IsLimitExceededError(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == "LIMIT_EXCEEDED"
+}
+
+func NewAccountRestrictedError(message string) error {
+	return &AppError{
+		Message: message,
+		Code:    "ACCOUNT_RESTRICTED",
+	}
+}
+
+func IsAccountRestrictedError(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == "ACCOUNT_RESTRICTED"
+}
+
+func NewInsufficientBalanceError(message string) error {
+	return &AppError{
+		Message: message,
+		Code:    "INSUFFICIENT_BALANCE",
+	}
+}
+
+func IsInsufficientBalanceError(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == "INSUFFICIENT_BALANCE"
+}
+
+func NewUnauthorizedError(message string) error {
+	return &AppError{
+		Message: message,
+		Code:    "UNAUTHORIZED",
+	}
+}
+
+func IsUnauthorizedError(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == "UNAUTHORIZED"
+}